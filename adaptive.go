@@ -0,0 +1,167 @@
+package ctxzap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// PressureSource reports how saturated a log pipeline is, from 0 (healthy)
+// to 1 (saturated). Queues and network writers implement it to drive
+// NewAdaptiveCore.
+type PressureSource interface {
+	Pressure() float64
+}
+
+// PressureFunc adapts an ordinary function to the PressureSource interface.
+type PressureFunc func() float64
+
+// Pressure calls f().
+func (f PressureFunc) Pressure() float64 {
+	return f()
+}
+
+// AdaptiveOptions configures NewAdaptiveCore.
+type AdaptiveOptions struct {
+	// Threshold is the pressure at which sampling starts. Defaults to 0.5.
+	Threshold float64
+	// MaxLevel is the highest level subject to sampling. The zero value is
+	// InfoLevel, so warnings and errors are never dropped.
+	MaxLevel zapcore.Level
+	// NoticeInterval is how often a notice about the adaptive state is
+	// emitted while sampling. Defaults to one minute.
+	NoticeInterval time.Duration
+}
+
+// NewAdaptiveCore wraps core with a sampler driven by source. While the
+// pressure is at or above the threshold, entries at or below MaxLevel are
+// kept with probability 1-pressure, except for entries logged with the
+// NoSampling call option; everything else is unaffected. Warn-level
+// notices report when sampling starts, periodically while it is active with
+// the number of dropped entries, and when it stops.
+func NewAdaptiveCore(core zapcore.Core, source PressureSource, opts AdaptiveOptions) zapcore.Core {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 0.5
+	}
+	if opts.NoticeInterval <= 0 {
+		opts.NoticeInterval = time.Minute
+	}
+	return &adaptiveCore{
+		Core:   core,
+		source: source,
+		opts:   opts,
+		state:  &adaptiveState{},
+	}
+}
+
+type adaptiveCore struct {
+	zapcore.Core
+
+	source PressureSource
+	opts   AdaptiveOptions
+	state  *adaptiveState
+}
+
+// adaptiveState is shared by a core and all cores derived from it with With.
+type adaptiveState struct {
+	seen    atomic.Uint64
+	dropped atomic.Uint64
+
+	mu         sync.Mutex
+	degraded   bool
+	lastNotice time.Time
+}
+
+func (c *adaptiveCore) With(fields []zap.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	return &clone
+}
+
+func (c *adaptiveCore) wrapped() zapcore.Core {
+	return c.Core
+}
+
+func (c *adaptiveCore) rewrap(core zapcore.Core) zapcore.Core {
+	clone := *c
+	clone.Core = core
+	return &clone
+}
+
+func (c *adaptiveCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(entry.Level) {
+		// Entries the core would not write neither count as dropped nor
+		// drive the notices, e.g. debug entries of a tee's other cores.
+		return ce
+	}
+	if entry.Level > c.opts.MaxLevel {
+		return c.Core.Check(entry, ce)
+	}
+
+	pressure := c.source.Pressure()
+	degraded := pressure >= c.opts.Threshold
+	c.notice(entry.Time, pressure, degraded)
+
+	if degraded && !c.keep(pressure) {
+		// Entries logged with NoSampling are only recognizable from their
+		// fields, so the entry is dropped when it is written.
+		return ce.AddCore(entry, sampledCore{c})
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// sampledCore receives the entries sampled out by an adaptive core. It drops
+// them, unless they were logged with NoSampling.
+type sampledCore struct {
+	*adaptiveCore
+}
+
+func (c sampledCore) Write(entry zapcore.Entry, fields []zap.Field) error {
+	if !samplingExempt(fields) {
+		c.state.dropped.Add(1)
+		return nil
+	}
+	return (&checkedCore{Core: c.Core}).Write(entry, fields)
+}
+
+// keep deterministically keeps a 1-pressure fraction of entries.
+func (c *adaptiveCore) keep(pressure float64) bool {
+	if pressure >= 1 {
+		return false
+	}
+	n := c.state.seen.Add(1)
+	keepEvery := uint64(1 / (1 - pressure))
+	return keepEvery <= 1 || n%keepEvery == 0
+}
+
+// notice emits a Warn entry when the adaptive state changes, and periodically
+// while degraded.
+func (c *adaptiveCore) notice(now time.Time, pressure float64, degraded bool) {
+	s := c.state
+	s.mu.Lock()
+
+	var msg string
+	switch {
+	case degraded && !s.degraded:
+		msg = "log sampling enabled due to pipeline pressure"
+	case !degraded && s.degraded:
+		msg = "log sampling disabled, pipeline pressure recovered"
+	case degraded && now.Sub(s.lastNotice) >= c.opts.NoticeInterval:
+		msg = "log sampling active due to pipeline pressure"
+	default:
+		s.mu.Unlock()
+		return
+	}
+	s.degraded = degraded
+	s.lastNotice = now
+	dropped := s.dropped.Swap(0)
+	s.mu.Unlock()
+
+	entry := zapcore.Entry{Level: zapcore.WarnLevel, Time: now, Message: msg}
+	if ce := c.Core.Check(entry, nil); ce != nil {
+		ce.Write(zap.Float64("pressure", pressure), zap.Uint64("dropped", dropped))
+	}
+}
@@ -0,0 +1,115 @@
+package ctxzap
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAdaptiveCore(t *testing.T) {
+	var pressure atomic.Value
+	pressure.Store(0.0)
+	source := PressureFunc(func() float64 { return pressure.Load().(float64) })
+
+	core, observed := observer.New(zapcore.DebugLevel)
+	logger := New(zap.New(NewAdaptiveCore(core, source, AdaptiveOptions{})))
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		logger.Info(ctx, "healthy")
+	}
+	if got := observed.FilterMessage("healthy").Len(); got != 10 {
+		t.Errorf("expected all 10 entries while healthy, got %d", got)
+	}
+
+	pressure.Store(0.75)
+	for i := 0; i < 100; i++ {
+		logger.Info(ctx, "degraded")
+	}
+	logger.Error(ctx, "important")
+
+	if got := observed.FilterMessage("degraded").Len(); got != 25 {
+		t.Errorf("expected 25 of 100 entries kept at pressure 0.75, got %d", got)
+	}
+	if got := observed.FilterMessage("important").Len(); got != 1 {
+		t.Errorf("expected errors to bypass sampling, got %d", got)
+	}
+	if got := observed.FilterMessage("log sampling enabled due to pipeline pressure").Len(); got != 1 {
+		t.Errorf("expected one enabled notice, got %d", got)
+	}
+
+	pressure.Store(0.0)
+	logger.Info(ctx, "recovered")
+
+	notices := observed.FilterMessage("log sampling disabled, pipeline pressure recovered").All()
+	if len(notices) != 1 {
+		t.Fatalf("expected one recovery notice, got %d", len(notices))
+	}
+	if got := notices[0].ContextMap()["dropped"]; got != uint64(75) {
+		t.Errorf("expected 75 dropped entries reported, got %v", got)
+	}
+}
+
+func TestAdaptiveCoreIgnoresDisabledLevels(t *testing.T) {
+	source := PressureFunc(func() float64 { return 0.75 })
+	core, observed := observer.New(zapcore.InfoLevel)
+	debug, _ := observer.New(zapcore.DebugLevel)
+	logger := New(zap.New(zapcore.NewTee(NewAdaptiveCore(core, source, AdaptiveOptions{}), debug)))
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		logger.Debug(ctx, "debug")
+	}
+	if got := observed.Len(); got != 0 {
+		t.Fatalf("expected no entries for disabled levels, got %d", got)
+	}
+
+	for i := 0; i < 4; i++ {
+		logger.Info(ctx, "degraded")
+	}
+	notices := observed.FilterMessage("log sampling enabled due to pipeline pressure").All()
+	if len(notices) != 1 {
+		t.Fatalf("expected one enabled notice, got %d", len(notices))
+	}
+	if got := notices[0].ContextMap()["dropped"]; got != uint64(0) {
+		t.Errorf("expected disabled entries not to count as dropped, got %v", got)
+	}
+	if got := observed.FilterMessage("degraded").Len(); got != 1 {
+		t.Errorf("expected 1 of 4 entries kept at pressure 0.75, got %d", got)
+	}
+}
+
+func TestAdaptiveCoreNoSampling(t *testing.T) {
+	var pressure atomic.Value
+	pressure.Store(1.0)
+	source := PressureFunc(func() float64 { return pressure.Load().(float64) })
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(NewAdaptiveCore(core, source, AdaptiveOptions{})))
+	ctx := WithFields(context.Background(), zap.String("request_id", "r1"))
+
+	logger.Info(ctx, "sampled")
+	logger.Info(ctx, "audit", zap.String("role", "admin"), NoSampling())
+
+	if got := observed.FilterMessage("sampled").Len(); got != 0 {
+		t.Errorf("expected entries to be dropped at pressure 1, got %d", got)
+	}
+	audit := observed.FilterMessage("audit").All()
+	if len(audit) != 1 {
+		t.Fatalf("expected the exempt entry to be kept, got %d", len(audit))
+	}
+	if fields := audit[0].ContextMap(); len(fields) != 2 || fields["role"] != "admin" || fields["request_id"] != "r1" {
+		t.Errorf("expected the entry's fields only, got %v", fields)
+	}
+
+	pressure.Store(0.0)
+	logger.Info(ctx, "recovered")
+	notices := observed.FilterMessage("log sampling disabled, pipeline pressure recovered").All()
+	if len(notices) != 1 || notices[0].ContextMap()["dropped"] != uint64(1) {
+		t.Errorf("expected only the sampled entry to count as dropped, got %v", notices)
+	}
+}
@@ -2,6 +2,9 @@ package ctxzap
 
 import (
 	"context"
+	"io"
+	"slices"
+	"strconv"
 	"testing"
 
 	"go.uber.org/zap"
@@ -80,6 +83,24 @@ func BenchmarkWithFields(b *testing.B) {
 	}
 }
 
+func BenchmarkLayeredWithFields(b *testing.B) {
+	fields := make([]zap.Field, 20)
+	for i := range fields {
+		fields[i] = zap.Int("layer_"+strconv.Itoa(i), i)
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		ctx := context.Background()
+		for j := range fields {
+			ctx = WithFields(ctx, fields[j])
+		}
+		_ = storedFields(ctx)
+	}
+}
+
 func BenchmarkFieldsFromContext(b *testing.B) {
 	ctx := context.Background()
 	ctx = WithFields(ctx,
@@ -117,6 +138,38 @@ func BenchmarkMergeFields(b *testing.B) {
 	}
 }
 
+func BenchmarkMergeFieldsSizes(b *testing.B) {
+	for _, size := range []int{2, 4, 8, 16, 32, 64} {
+		existing := make([]zap.Field, size/2)
+		for i := range existing {
+			existing[i] = zap.String("context_"+strconv.Itoa(i), "value")
+		}
+		newFields := make([]zap.Field, size-len(existing))
+		for i := range newFields {
+			newFields[i] = zap.Int("call_"+strconv.Itoa(i), i)
+		}
+		overridden := append(slices.Clone(newFields[1:]), zap.String("context_0", "override"))
+
+		for _, bm := range []struct {
+			name  string
+			added []zap.Field
+			merge func(dst, existing, added []zap.Field) []zap.Field
+		}{
+			{"linear/disjoint", newFields, func(dst, existing, added []zap.Field) []zap.Field { return appendMergedLinear(dst, existing, added) }},
+			{"map/disjoint", newFields, func(dst, existing, added []zap.Field) []zap.Field { return appendMergedMap(dst, nil, existing, added) }},
+			{"linear/override", overridden, func(dst, existing, added []zap.Field) []zap.Field { return appendMergedLinear(dst, existing, added) }},
+			{"map/override", overridden, func(dst, existing, added []zap.Field) []zap.Field { return appendMergedMap(dst, nil, existing, added) }},
+		} {
+			b.Run(strconv.Itoa(size)+"/"+bm.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_ = bm.merge(make([]zap.Field, 0, size), existing, bm.added)
+				}
+			})
+		}
+	}
+}
+
 func BenchmarkLoggerWithManyFields(b *testing.B) {
 	logger := New(zap.NewNop())
 
@@ -167,3 +220,98 @@ func BenchmarkCtxZapProduction(b *testing.B) {
 		)
 	}
 }
+
+func BenchmarkFieldBuilder(b *testing.B) {
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := context.Background()
+
+	b.Run("variadic", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Info(ctx, "item processed",
+				zap.String("id", "item-1"),
+				zap.Int("size", i),
+				zap.Bool("cached", true),
+			)
+		}
+	})
+
+	b.Run("builder", func(b *testing.B) {
+		fields := NewFieldBuilder(3)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fields.Reset().String("id", "item-1").Int("size", i).Bool("cached", true)
+			logger.InfoB(ctx, "item processed", fields)
+		}
+	})
+}
+
+func BenchmarkLoggerCache(b *testing.B) {
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "123"),
+		zap.String("user_id", "456"),
+		zap.String("service", "api"),
+		zap.String("region", "eu-west-1"),
+	)
+
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"merged", nil},
+		{"cached", []Option{WithLoggerCache()}},
+	} {
+		logger := New(zap.New(core), bm.opts...)
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger.Info(ctx, "item processed", zap.Int("size", i))
+			}
+		})
+	}
+}
+
+func BenchmarkPooledFields(b *testing.B) {
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "123"),
+		zap.String("user_id", "456"),
+		zap.String("service", "api"),
+		zap.String("region", "eu-west-1"),
+	)
+
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"allocated", nil},
+		{"pooled", []Option{WithPooledFields()}},
+		{"without_dedup", []Option{WithoutDeduplication()}},
+		{"without_dedup_pooled", []Option{WithoutDeduplication(), WithPooledFields()}},
+	} {
+		logger := New(zap.New(core), bm.opts...)
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger.Info(ctx, "item processed", zap.Int("size", i), zap.String("user_id", "789"))
+			}
+		})
+	}
+}
+
+func BenchmarkCostAccounting(b *testing.B) {
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	logger := New(zap.New(core), WithCostAccounting(nil))
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "123"),
+		zap.String("user_id", "456"),
+		zap.String("service", "api"),
+	)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Info(ctx, "item processed", zap.Int("size", i), zap.Bool("cached", true))
+	}
+}
@@ -0,0 +1,103 @@
+package ctxzap
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys of the fields of the summary logged by EndBudget.
+const (
+	SuppressedKey        = "suppressed"
+	SuppressedByLevelKey = "suppressed_by_level"
+)
+
+// budgetContextKey is used as a key for storing a budget in context
+type budgetContextKey struct{}
+
+var budgetKey = budgetContextKey{}
+
+// budget limits the entries logged with a context. Entries at ErrorLevel
+// and above are never limited.
+type budget struct {
+	remaining  atomic.Int64
+	suppressed [zapcore.ErrorLevel - zapcore.DebugLevel]atomic.Int64
+	ended      atomic.Bool
+
+	// logger is the first logger that suppressed an entry, used by EndBudget
+	// to log the summary where the suppressed entries would have gone.
+	logger atomic.Pointer[Logger]
+}
+
+// WithBudget returns a context allowing at most n entries below ErrorLevel
+// to be logged with it, e.g. per request, to contain log storms caused by a
+// single pathological request. Entries beyond the budget are dropped and
+// counted, and EndBudget logs how many were suppressed:
+//
+//	ctx = ctxzap.WithBudget(ctx, 500)
+//	defer ctxzap.EndBudget(ctx)
+//
+// Only entries at levels enabled by the logger consume the budget. Errors
+// and more severe entries are always logged. A budget applies to the whole
+// subtree of calls below ctx; setting a new one replaces it.
+func WithBudget(ctx context.Context, n int) context.Context {
+	b := &budget{}
+	b.remaining.Store(int64(n))
+	return context.WithValue(ctx, budgetKey, b)
+}
+
+// EndBudget logs a Warn entry with the number of entries suppressed by the
+// budget set in ctx with WithBudget, in total and per level, using the first
+// logger that suppressed an entry, so the summary is written even if ctx does
+// not carry a logger. Nothing is logged if no entry was suppressed. Only the
+// first call for a budget logs.
+func EndBudget(ctx context.Context) {
+	b := budgetFrom(ctx)
+	if b == nil || !b.ended.CompareAndSwap(false, true) {
+		return
+	}
+
+	var total int64
+	var byLevel []zap.Field
+	for i := range b.suppressed {
+		if n := b.suppressed[i].Load(); n > 0 {
+			total += n
+			byLevel = append(byLevel, zap.Int64((zapcore.DebugLevel+zapcore.Level(i)).String(), n))
+		}
+	}
+	l := b.logger.Load()
+	if total == 0 || l == nil {
+		return
+	}
+
+	// Write through the underlying logger, as the budget is exhausted.
+	l.Logger.Warn("log budget exceeded, entries suppressed", l.fields(ctx, zapcore.WarnLevel, []zap.Field{
+		zap.Int64(SuppressedKey, total),
+		zap.Dict(SuppressedByLevelKey, byLevel...),
+	}, nil)...)
+}
+
+// withinBudget reports whether an entry at level may be logged with ctx,
+// consuming the budget in ctx if any.
+func (l *Logger) withinBudget(ctx context.Context, level zapcore.Level) bool {
+	b := budgetFrom(ctx)
+	if b == nil || level >= zapcore.ErrorLevel || level < zapcore.DebugLevel || !l.Core().Enabled(level) {
+		return true
+	}
+	if b.remaining.Add(-1) >= 0 {
+		return true
+	}
+	b.logger.CompareAndSwap(nil, l)
+	b.suppressed[level-zapcore.DebugLevel].Add(1)
+	return false
+}
+
+func budgetFrom(ctx context.Context) *budget {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(budgetKey).(*budget)
+	return b
+}
@@ -0,0 +1,82 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestBudget(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	logger := New(zap.New(core))
+	ctx := WithLogger(context.Background(), logger)
+	ctx = WithBudget(WithFields(ctx, zap.String("request_id", "r1")), 3)
+
+	for i := 0; i < 5; i++ {
+		logger.Debug(ctx, "debug")
+	}
+	logger.Info(ctx, "info")
+	logger.Error(ctx, "error")
+	EndBudget(ctx)
+	EndBudget(ctx)
+
+	entries := observed.All()
+	var messages []string
+	for _, e := range entries {
+		messages = append(messages, e.Message)
+	}
+	want := []string{"debug", "debug", "debug", "error", "log budget exceeded, entries suppressed"}
+	if len(messages) != len(want) {
+		t.Fatalf("expected %v, got %v", want, messages)
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, messages)
+		}
+	}
+
+	summary := entries[len(entries)-1]
+	fields := summary.ContextMap()
+	if summary.Level != zapcore.WarnLevel || fields[SuppressedKey] != int64(3) || fields["request_id"] != "r1" {
+		t.Errorf("unexpected summary: %v %v", summary.Level, fields)
+	}
+	byLevel, _ := fields[SuppressedByLevelKey].(map[string]any)
+	if byLevel["debug"] != int64(2) || byLevel["info"] != int64(1) || len(byLevel) != 2 {
+		t.Errorf("unexpected per-level counts: %v", byLevel)
+	}
+}
+
+func TestBudgetIgnoresDisabledLevels(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithLogger(WithBudget(context.Background(), 1), logger)
+
+	logger.Debug(ctx, "disabled")
+	logger.Info(ctx, "within budget")
+	EndBudget(ctx)
+
+	if observed.Len() != 1 || observed.All()[0].Message != "within budget" {
+		t.Errorf("expected only the entry within budget, got %v", observed.All())
+	}
+}
+
+func TestEndBudgetWithoutLoggerInContext(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	logger := New(zap.New(core))
+	ctx := WithBudget(context.Background(), 1)
+
+	logger.Info(ctx, "within budget")
+	logger.Info(ctx, "suppressed")
+	EndBudget(ctx)
+
+	entries := observed.All()
+	if len(entries) != 2 || entries[1].Message != "log budget exceeded, entries suppressed" {
+		t.Fatalf("expected the summary on the logger that suppressed entries, got %v", entries)
+	}
+	if n := entries[1].ContextMap()[SuppressedKey]; n != int64(1) {
+		t.Errorf("expected 1 suppressed entry, got %v", n)
+	}
+}
@@ -0,0 +1,136 @@
+package ctxzap
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldBuilder accumulates fields in a reusable backing array, for loops
+// logging so many entries that allocating a field slice per call is
+// measurable. Reset it at the start of each iteration and pass it to the
+// B logging methods:
+//
+//	b := ctxzap.NewFieldBuilder(8)
+//	for _, item := range items {
+//		b.Reset().String("id", item.ID).Int("size", item.Size)
+//		logger.InfoB(ctx, "item processed", b)
+//	}
+//
+// The fields are only valid until the next Reset, so a FieldBuilder must not
+// be used with cores that retain fields after Write returns, and must not be
+// shared between goroutines.
+type FieldBuilder struct {
+	fields []zap.Field
+}
+
+// NewFieldBuilder creates a FieldBuilder with room for capacity fields.
+func NewFieldBuilder(capacity int) *FieldBuilder {
+	return &FieldBuilder{fields: make([]zap.Field, 0, capacity)}
+}
+
+// Reset removes all fields, keeping the backing array.
+func (b *FieldBuilder) Reset() *FieldBuilder {
+	clear(b.fields)
+	b.fields = b.fields[:0]
+	return b
+}
+
+// Add appends fields.
+func (b *FieldBuilder) Add(fields ...zap.Field) *FieldBuilder {
+	b.fields = append(b.fields, fields...)
+	return b
+}
+
+// String appends a string field.
+func (b *FieldBuilder) String(key, value string) *FieldBuilder {
+	b.fields = append(b.fields, zap.String(key, value))
+	return b
+}
+
+// Int appends an int field.
+func (b *FieldBuilder) Int(key string, value int) *FieldBuilder {
+	b.fields = append(b.fields, zap.Int(key, value))
+	return b
+}
+
+// Int64 appends an int64 field.
+func (b *FieldBuilder) Int64(key string, value int64) *FieldBuilder {
+	b.fields = append(b.fields, zap.Int64(key, value))
+	return b
+}
+
+// Float64 appends a float64 field.
+func (b *FieldBuilder) Float64(key string, value float64) *FieldBuilder {
+	b.fields = append(b.fields, zap.Float64(key, value))
+	return b
+}
+
+// Bool appends a bool field.
+func (b *FieldBuilder) Bool(key string, value bool) *FieldBuilder {
+	b.fields = append(b.fields, zap.Bool(key, value))
+	return b
+}
+
+// Duration appends a time.Duration field.
+func (b *FieldBuilder) Duration(key string, value time.Duration) *FieldBuilder {
+	b.fields = append(b.fields, zap.Duration(key, value))
+	return b
+}
+
+// Error appends an "error" field, or nothing if err is nil.
+func (b *FieldBuilder) Error(err error) *FieldBuilder {
+	if err != nil {
+		b.fields = append(b.fields, zap.Error(err))
+	}
+	return b
+}
+
+// Len returns the number of fields.
+func (b *FieldBuilder) Len() int {
+	return len(b.fields)
+}
+
+// Fields returns the fields, backed by the builder's array.
+func (b *FieldBuilder) Fields() []zap.Field {
+	return b.fields
+}
+
+// DebugB logs a message at DebugLevel with the fields of b.
+func (l *Logger) DebugB(ctx context.Context, msg string, b *FieldBuilder) {
+	if !l.withinBudget(ctx, zapcore.DebugLevel) {
+		return
+	}
+	buf := l.fieldBuffer()
+	l.Logger.Debug(msg, l.fields(ctx, zapcore.DebugLevel, b.fields, buf)...)
+	buf.release()
+}
+
+// InfoB logs a message at InfoLevel with the fields of b.
+func (l *Logger) InfoB(ctx context.Context, msg string, b *FieldBuilder) {
+	if !l.withinBudget(ctx, zapcore.InfoLevel) {
+		return
+	}
+	buf := l.fieldBuffer()
+	l.Logger.Info(msg, l.fields(ctx, zapcore.InfoLevel, b.fields, buf)...)
+	buf.release()
+}
+
+// WarnB logs a message at WarnLevel with the fields of b.
+func (l *Logger) WarnB(ctx context.Context, msg string, b *FieldBuilder) {
+	if !l.withinBudget(ctx, zapcore.WarnLevel) {
+		return
+	}
+	buf := l.fieldBuffer()
+	l.Logger.Warn(msg, l.fields(ctx, zapcore.WarnLevel, b.fields, buf)...)
+	buf.release()
+}
+
+// ErrorB logs a message at ErrorLevel with the fields of b.
+func (l *Logger) ErrorB(ctx context.Context, msg string, b *FieldBuilder) {
+	buf := l.fieldBuffer()
+	l.Logger.Error(msg, l.fields(ctx, zapcore.ErrorLevel, b.fields, buf)...)
+	buf.release()
+}
@@ -0,0 +1,45 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFieldBuilder(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+
+	b := NewFieldBuilder(4)
+	for i := 0; i < 3; i++ {
+		b.Reset().String("id", "item").Int("n", i)
+		if i == 2 {
+			b.Error(errors.New("boom")).Error(nil).Duration("elapsed", time.Second)
+		}
+		logger.InfoB(ctx, "item processed", b)
+	}
+	logger.DebugB(ctx, "disabled", b)
+
+	entries := observed.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 log entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		fields := entry.ContextMap()
+		if fields["n"] != int64(i) || fields["id"] != "item" || fields["request_id"] != "123" {
+			t.Errorf("entry %d: unexpected fields %v", i, fields)
+		}
+	}
+	if fields := entries[2].ContextMap(); fields["error"] != "boom" || fields["elapsed"] != time.Second {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+	if b.Len() != 4 {
+		t.Errorf("expected 4 fields, got %d", b.Len())
+	}
+}
@@ -0,0 +1,140 @@
+package ctxzap
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// callOption is a per-call setting carried inside a zap.Field of type
+// zapcore.SkipType, so it can be passed among the fields of any logging call.
+// Such fields are stripped before the entry is written, and are ignored by
+// zap itself if they ever reach a plain zap.Logger.
+type callOption uint8
+
+const (
+	optNoContextFields callOption = 1 << iota
+	optStack
+	optNoSampling
+)
+
+// callOptions are the per-call options extracted from the fields of a call.
+type callOptions struct {
+	flags callOption
+	merge MergeStrategy
+}
+
+// mergeOption carries the MergeStrategy set with MergeWith.
+type mergeOption struct {
+	merge MergeStrategy
+}
+
+// callOptionKey marks fields that carry a callOption.
+const callOptionKey = "ctxzap.call_option"
+
+func (o callOption) field() zap.Field {
+	return zap.Field{Key: callOptionKey, Type: zapcore.SkipType, Interface: o}
+}
+
+// NoContextFields is a per-call option that excludes context fields from the
+// entry, e.g. for aggregate statistics logged from inside a request handler:
+//
+//	logger.Info(ctx, "cache stats", zap.Int("hits", hits), ctxzap.NoContextFields())
+func NoContextFields() zap.Field {
+	return optNoContextFields.field()
+}
+
+// WithStack is a per-call option that attaches a "stacktrace" field with the
+// stack of the logging call, regardless of the logger's stacktrace level.
+func WithStack() zap.Field {
+	return optStack.field()
+}
+
+// NoSampling is a per-call option that exempts the entry from the sampling
+// of NewAdaptiveCore, e.g. for audit entries that must be kept under
+// pressure:
+//
+//	logger.Info(ctx, "permission granted", zap.String("role", role), ctxzap.NoSampling())
+//
+// Samplers of the wrapped zap core, such as zapcore.NewSamplerWithOptions,
+// still apply.
+func NoSampling() zap.Field {
+	return optNoSampling.field()
+}
+
+// samplingExemption marks the entries logged with NoSampling. Unlike call
+// options, it is kept among the fields so that the adaptive core sees it; as
+// a zapcore.SkipType field, it is not encoded.
+type samplingExemption struct{}
+
+// samplingExemptionKey is the key of the samplingExemption field.
+const samplingExemptionKey = "ctxzap.sampling_exemption"
+
+func samplingExemptionField() zap.Field {
+	return zap.Field{Key: samplingExemptionKey, Type: zapcore.SkipType, Interface: samplingExemption{}}
+}
+
+// samplingExempt reports whether fields contain the samplingExemption field.
+// The field is recognized by its value, as transformers may rename it.
+func samplingExempt(fields []zap.Field) bool {
+	for i := range fields {
+		if fields[i].Type == zapcore.SkipType {
+			if _, ok := fields[i].Interface.(samplingExemption); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MergeWith is a per-call option that combines context fields and call-site
+// fields with merge instead of the logger's MergeStrategy, e.g. to let a
+// field deliberately override a context field on a logger using
+// ErrorOnConflict:
+//
+//	logger.Info(ctx, "impersonating", zap.String("user_id", target), ctxzap.MergeWith(ctxzap.MergeFields))
+func MergeWith(merge MergeStrategy) zap.Field {
+	return zap.Field{Key: callOptionKey, Type: zapcore.SkipType, Interface: mergeOption{merge: merge}}
+}
+
+// extractCallOptions separates per-call options from fields. The input slice
+// is returned unchanged when it contains no options.
+func extractCallOptions(fields []zap.Field) (callOptions, []zap.Field) {
+	idx := -1
+	for i := range fields {
+		if isCallOption(fields[i]) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return callOptions{}, fields
+	}
+
+	var opts callOptions
+	rest := make([]zap.Field, idx, len(fields))
+	copy(rest, fields[:idx])
+	for _, f := range fields[idx:] {
+		if !isCallOption(f) {
+			rest = append(rest, f)
+			continue
+		}
+		switch o := f.Interface.(type) {
+		case callOption:
+			opts.flags |= o
+		case mergeOption:
+			opts.merge = o.merge
+		}
+	}
+	return opts, rest
+}
+
+func isCallOption(f zap.Field) bool {
+	if f.Type != zapcore.SkipType || f.Key != callOptionKey {
+		return false
+	}
+	switch f.Interface.(type) {
+	case callOption, mergeOption:
+		return true
+	}
+	return false
+}
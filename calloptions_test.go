@@ -0,0 +1,83 @@
+package ctxzap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNoContextFields(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+	logger.Info(ctx, "stats", zap.Int("hits", 10), NoContextFields())
+
+	fields := observed.All()[0].ContextMap()
+	if _, ok := fields["request_id"]; ok {
+		t.Error("expected context fields to be excluded")
+	}
+	if fields["hits"] != int64(10) {
+		t.Errorf("expected hits=10, got %v", fields["hits"])
+	}
+	if len(fields) != 1 {
+		t.Errorf("expected 1 field, got %d: %v", len(fields), fields)
+	}
+}
+
+func TestWithStack(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+	logger.Info(ctx, "with stack", WithStack())
+
+	fields := observed.All()[0].ContextMap()
+	stack, ok := fields["stacktrace"].(string)
+	if !ok {
+		t.Fatal("expected stacktrace field")
+	}
+	if !strings.HasPrefix(stack, "github.com/algobardo/ctxzap.TestWithStack") {
+		t.Errorf("expected stack to start at the caller, got %q", stack)
+	}
+	if fields["request_id"] != "123" {
+		t.Errorf("expected context fields to be kept, got %v", fields["request_id"])
+	}
+}
+
+func TestExtractCallOptionsKeepsInput(t *testing.T) {
+	fields := []zap.Field{zap.String("a", "1"), NoContextFields(), zap.String("b", "2")}
+
+	opts, rest := extractCallOptions(fields)
+	if opts.flags != optNoContextFields {
+		t.Errorf("expected NoContextFields option, got %v", opts)
+	}
+	if len(rest) != 2 || rest[0].Key != "a" || rest[1].Key != "b" {
+		t.Errorf("unexpected remaining fields: %v", rest)
+	}
+	if !isCallOption(fields[1]) {
+		t.Error("input slice must not be modified")
+	}
+}
+
+func TestBare(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	bare := logger.Bare()
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+	bare.Info(ctx, "bare")
+	logger.Info(ctx, "regular")
+
+	entries := observed.All()
+	if _, ok := entries[0].ContextMap()["request_id"]; ok {
+		t.Error("expected bare logger to skip context fields")
+	}
+	if entries[1].ContextMap()["request_id"] != "123" {
+		t.Error("expected parent logger to keep context fields")
+	}
+}
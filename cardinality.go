@@ -0,0 +1,135 @@
+package ctxzap
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// CardinalityAlert reports a field whose number of distinct values exceeded
+// the threshold within a window.
+type CardinalityAlert struct {
+	Key string
+	// Distinct is the number of distinct values seen when the threshold was
+	// exceeded, i.e. Threshold+1.
+	Distinct int
+	// WindowStart is the start of the window in which it happened.
+	WindowStart time.Time
+	Window      time.Duration
+}
+
+// CardinalityOptions configures NewCardinalityCore.
+type CardinalityOptions struct {
+	// Keys lists the fields to watch.
+	Keys []string
+	// Threshold is the number of distinct values per field and window above
+	// which OnExceeded is called.
+	Threshold int
+	// Window is the length of the fixed windows over which distinct values
+	// are counted, based on entry times. Defaults to one minute.
+	Window time.Duration
+	// OnExceeded is called at most once per field and window, synchronously
+	// from the write, so it should return quickly.
+	OnExceeded func(CardinalityAlert)
+}
+
+// WithCardinalityWatch makes the logger track the distinct values of the
+// configured fields, e.g. to catch a bug generating random user IDs before it
+// inflates downstream index costs. Entries are written unchanged.
+func WithCardinalityWatch(opts CardinalityOptions) Option {
+	return func(l *Logger) {
+		l.Logger = l.Logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewCardinalityCore(core, opts)
+		}))
+	}
+}
+
+// NewCardinalityCore wraps core with the cardinality tracking described in
+// WithCardinalityWatch. Memory is bounded: at most Threshold+1 values are
+// kept per field.
+func NewCardinalityCore(core zapcore.Core, opts CardinalityOptions) zapcore.Core {
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	return decorate(core, &cardinalityDecorator{
+		watch: &cardinalityWatch{
+			opts:   opts,
+			values: make(map[string]map[string]struct{}, len(opts.Keys)),
+		},
+	})
+}
+
+type cardinalityDecorator struct {
+	watch *cardinalityWatch
+	// context holds the watched fields added with With.
+	context []zap.Field
+}
+
+type cardinalityWatch struct {
+	opts CardinalityOptions
+
+	mu          sync.Mutex
+	windowStart time.Time
+	values      map[string]map[string]struct{}
+}
+
+func (d *cardinalityDecorator) with(fields []zap.Field) (decoration, []zap.Field) {
+	clone := *d
+	for _, f := range fields {
+		if slices.Contains(d.watch.opts.Keys, f.Key) {
+			clone.context = append(clone.context[:len(clone.context):len(clone.context)], f)
+		}
+	}
+	return &clone, fields
+}
+
+func (d *cardinalityDecorator) write(core zapcore.Core, entry zapcore.Entry, fields []zap.Field) error {
+	d.watch.observe(entry.Time, d.context)
+	d.watch.observe(entry.Time, fields)
+	return core.Write(entry, fields)
+}
+
+func (w *cardinalityWatch) observe(now time.Time, fields []zap.Field) {
+	var alerts []CardinalityAlert
+
+	w.mu.Lock()
+	// Entries timestamped before the current window, e.g. late or from a
+	// clock stepping back, count towards it rather than restarting it.
+	if start := now.Truncate(w.opts.Window); start.After(w.windowStart) {
+		w.windowStart = start
+		clear(w.values)
+	}
+	for _, f := range fields {
+		if !slices.Contains(w.opts.Keys, f.Key) {
+			continue
+		}
+		seen := w.values[f.Key]
+		if seen == nil {
+			seen = make(map[string]struct{})
+			w.values[f.Key] = seen
+		}
+		// Stop tracking once the threshold is exceeded in this window.
+		if len(seen) > w.opts.Threshold {
+			continue
+		}
+		seen[fieldString(f)] = struct{}{}
+		if len(seen) > w.opts.Threshold {
+			alerts = append(alerts, CardinalityAlert{
+				Key:         f.Key,
+				Distinct:    len(seen),
+				WindowStart: w.windowStart,
+				Window:      w.opts.Window,
+			})
+		}
+	}
+	w.mu.Unlock()
+
+	if w.opts.OnExceeded != nil {
+		for _, alert := range alerts {
+			w.opts.OnExceeded(alert)
+		}
+	}
+}
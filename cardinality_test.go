@@ -0,0 +1,94 @@
+package ctxzap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCardinalityWatch(t *testing.T) {
+	var alerts []CardinalityAlert
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithCardinalityWatch(CardinalityOptions{
+		Keys:       []string{"user_id", "tenant"},
+		Threshold:  3,
+		OnExceeded: func(alert CardinalityAlert) { alerts = append(alerts, alert) },
+	}))
+	ctx := context.Background()
+
+	tenant := logger.With(zap.String("tenant", "acme"))
+	for i := 0; i < 10; i++ {
+		tenant.Info(ctx, "request", zap.String("user_id", fmt.Sprintf("user-%d", i%5)))
+	}
+
+	if len(observed.All()) != 10 {
+		t.Errorf("expected entries to be written unchanged, got %d", len(observed.All()))
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %v", alerts)
+	}
+	if alerts[0].Key != "user_id" || alerts[0].Distinct != 4 || alerts[0].Window != time.Minute {
+		t.Errorf("unexpected alert: %+v", alerts[0])
+	}
+}
+
+func TestCardinalityWindows(t *testing.T) {
+	var alerts int
+	core, _ := observer.New(zapcore.InfoLevel)
+	watched := NewCardinalityCore(core, CardinalityOptions{
+		Keys:       []string{"id"},
+		Threshold:  1,
+		Window:     time.Minute,
+		OnExceeded: func(CardinalityAlert) { alerts++ },
+	})
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	write := func(at time.Duration, id string) {
+		entry := zapcore.Entry{Level: zapcore.InfoLevel, Time: start.Add(at)}
+		if ce := watched.Check(entry, nil); ce != nil {
+			ce.Write(zap.String("id", id))
+		}
+	}
+
+	write(0, "a")
+	write(time.Second, "b")
+	write(2*time.Second, "c")
+	write(time.Minute, "d")
+	write(time.Minute+time.Second, "d")
+
+	if alerts != 1 {
+		t.Errorf("expected 1 alert per exceeded window, got %d", alerts)
+	}
+}
+
+func TestCardinalityWindowsOnlyAdvance(t *testing.T) {
+	var alerts int
+	core, _ := observer.New(zapcore.InfoLevel)
+	watched := NewCardinalityCore(core, CardinalityOptions{
+		Keys:       []string{"id"},
+		Threshold:  1,
+		Window:     time.Minute,
+		OnExceeded: func(CardinalityAlert) { alerts++ },
+	})
+
+	start := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	write := func(at time.Duration, id string) {
+		entry := zapcore.Entry{Level: zapcore.InfoLevel, Time: start.Add(at)}
+		if ce := watched.Check(entry, nil); ce != nil {
+			ce.Write(zap.String("id", id))
+		}
+	}
+
+	write(0, "a")
+	write(-time.Second, "b")
+	write(time.Second, "c")
+
+	if alerts != 1 {
+		t.Errorf("expected an entry from the past to count towards the current window, got %d alerts", alerts)
+	}
+}
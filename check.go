@@ -0,0 +1,65 @@
+package ctxzap
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// CheckedEntry is an entry that passed Logger.Check, bound to the context
+// whose fields it will carry.
+type CheckedEntry struct {
+	ce     *zapcore.CheckedEntry
+	logger *Logger
+	ctx    context.Context
+	level  zapcore.Level
+}
+
+// Check returns a CheckedEntry if logging a message at level is enabled, and
+// nil otherwise, so that expensive fields are only built when they will be
+// written:
+//
+//	if ce := logger.Check(ctx, zap.DebugLevel, "cache state"); ce != nil {
+//		ce.Write(zap.Object("cache", cache.Snapshot()))
+//	}
+//
+// The entry is written with the context fields of ctx merged with the fields
+// passed to Write, exactly as by the level methods. Check shadows the
+// context-free zap.Logger.Check, which remains available through the
+// embedded Logger.
+func (l *Logger) Check(ctx context.Context, level zapcore.Level, msg string) *CheckedEntry {
+	if !l.withinBudget(ctx, level) {
+		return nil
+	}
+	ce := l.Logger.Check(level, msg)
+	if ce == nil {
+		return nil
+	}
+	return &CheckedEntry{ce: ce, logger: l, ctx: ctx, level: level}
+}
+
+// Enabled reports whether an entry at level logged with ctx would be
+// written as far as can be known without logging it: the level must be
+// enabled by the core, and a budget set with WithBudget in ctx must not be
+// exhausted. Unlike Check, it consumes no budget. Sampling is decided per
+// entry, and consulting a sampler counts as an entry, so Enabled does not
+// account for it; use Check for a definitive answer.
+func (l *Logger) Enabled(ctx context.Context, level zapcore.Level) bool {
+	if !l.Core().Enabled(level) {
+		return false
+	}
+	b := budgetFrom(ctx)
+	return b == nil || level >= zapcore.ErrorLevel || b.remaining.Load() > 0
+}
+
+// Write writes the entry with the context fields and fields. It must be
+// called at most once.
+func (e *CheckedEntry) Write(fields ...zap.Field) {
+	e.ce.Write(e.logger.fields(e.ctx, e.level, fields, nil)...)
+}
+
+// Entry returns the entry being checked.
+func (e *CheckedEntry) Entry() zapcore.Entry {
+	return e.ce.Entry
+}
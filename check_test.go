@@ -0,0 +1,80 @@
+package ctxzap
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCheck(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)))
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+
+	if ce := logger.Check(ctx, zapcore.DebugLevel, "disabled"); ce != nil {
+		t.Error("expected nil for a disabled level")
+	}
+
+	ce := logger.Check(ctx, zapcore.InfoLevel, "enabled")
+	if ce == nil {
+		t.Fatal("expected a checked entry")
+	}
+	if ce.Entry().Message != "enabled" {
+		t.Errorf("unexpected entry: %v", ce.Entry())
+	}
+	ce.Write(zap.Int("n", 1))
+
+	entries := observed.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["request_id"] != "123" || fields["n"] != int64(1) {
+		t.Errorf("expected context and call fields, got %v", fields)
+	}
+	if file := filepath.Base(entries[0].Caller.File); file != "check_test.go" {
+		t.Errorf("expected caller in check_test.go, got %s", file)
+	}
+}
+
+func TestCheckWithinBudget(t *testing.T) {
+	core, _ := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithBudget(context.Background(), 1)
+
+	if logger.Check(ctx, zapcore.InfoLevel, "first") == nil {
+		t.Error("expected the first entry within budget")
+	}
+	if logger.Check(ctx, zapcore.InfoLevel, "second") != nil {
+		t.Error("expected nil beyond the budget")
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithBudget(context.Background(), 1)
+
+	if logger.Enabled(ctx, zapcore.DebugLevel) {
+		t.Error("expected DebugLevel to be disabled by the core")
+	}
+	for range 2 {
+		if !logger.Enabled(ctx, zapcore.InfoLevel) {
+			t.Fatal("expected InfoLevel to be enabled without consuming the budget")
+		}
+	}
+	logger.Info(ctx, "consumes the budget")
+	if logger.Enabled(ctx, zapcore.WarnLevel) {
+		t.Error("expected WarnLevel to be disabled by the exhausted budget")
+	}
+	if !logger.Enabled(ctx, zapcore.ErrorLevel) || !logger.Enabled(context.Background(), zapcore.InfoLevel) {
+		t.Error("expected errors and contexts without a budget to be enabled")
+	}
+	if observed.Len() != 1 {
+		t.Errorf("expected 1 entry, got %d", observed.Len())
+	}
+}
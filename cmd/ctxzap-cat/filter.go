@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type condition struct {
+	key   string
+	value string
+}
+
+// filter selects records; all configured conditions must match.
+type filter struct {
+	where    []condition
+	minLevel *zapcore.Level
+	since    time.Time
+	until    time.Time
+}
+
+func newFilter(where []string, level, since, until string) (*filter, error) {
+	f := &filter{}
+
+	for _, w := range where {
+		key, value, _ := strings.Cut(w, "=")
+		f.where = append(f.where, condition{key: key, value: value})
+	}
+
+	if level != "" {
+		var l zapcore.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid -level: %w", err)
+		}
+		f.minLevel = &l
+	}
+
+	var err error
+	if f.since, err = parseTime(since); err != nil {
+		return nil, fmt.Errorf("invalid -since: %w", err)
+	}
+	if f.until, err = parseTime(until); err != nil {
+		return nil, fmt.Errorf("invalid -until: %w", err)
+	}
+	return f, nil
+}
+
+func (f *filter) empty() bool {
+	return len(f.where) == 0 && f.minLevel == nil && f.since.IsZero() && f.until.IsZero()
+}
+
+func (f *filter) match(r *record) bool {
+	if f.minLevel != nil && r.level < *f.minLevel {
+		return false
+	}
+	if !f.since.IsZero() && r.time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !r.time.Before(f.until) {
+		return false
+	}
+	for _, c := range f.where {
+		v, ok := r.lookup(c.key)
+		if !ok || fmt.Sprint(v) != c.value {
+			return false
+		}
+	}
+	return true
+}
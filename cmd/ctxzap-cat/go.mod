@@ -0,0 +1,13 @@
+module github.com/algobardo/ctxzap/cmd/ctxzap-cat
+
+go 1.24.5
+
+require (
+	github.com/algobardo/ctxzap/ctxzapzstd v0.1.0
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/klauspost/compress v1.19.2 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+)
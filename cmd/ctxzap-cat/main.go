@@ -0,0 +1,160 @@
+// Command ctxzap-cat reads ctxzap NDJSON logs, filters them and
+// pretty-prints them with zap's console encoder. Files written by the
+// ctxzapzstd sink are decompressed transparently.
+//
+// Usage:
+//
+//	ctxzap-cat [flags] [file ...]
+//
+// With no files, standard input is read. Flags:
+//
+//	-where key=value  keep entries whose field equals value; nested fields use
+//	                  dotted keys and the flag may be repeated
+//	-level level      keep entries at or above level
+//	-since time       keep entries at or after time (RFC 3339)
+//	-until time       keep entries before time (RFC 3339)
+//	-raw              print matching lines unchanged instead of pretty-printing
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/algobardo/ctxzap/ctxzapzstd"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxLineSize bounds the length of a single log line.
+const maxLineSize = 16 << 20
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "ctxzap-cat:", err)
+		os.Exit(1)
+	}
+}
+
+type whereFlags []string
+
+func (w *whereFlags) String() string { return strings.Join(*w, ",") }
+
+func (w *whereFlags) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return errors.New("expected key=value")
+	}
+	*w = append(*w, v)
+	return nil
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("ctxzap-cat", flag.ContinueOnError)
+
+	var (
+		where whereFlags
+		level = fs.String("level", "", "minimum level")
+		since = fs.String("since", "", "earliest time (RFC 3339)")
+		until = fs.String("until", "", "latest time, exclusive (RFC 3339)")
+		raw   = fs.Bool("raw", false, "print matching lines unchanged")
+	)
+	fs.Var(&where, "where", "field filter key=value (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter, err := newFilter(where, *level, *since, *until)
+	if err != nil {
+		return err
+	}
+
+	p := &printer{filter: filter, raw: *raw, out: stdout, enc: newConsoleEncoder()}
+
+	if fs.NArg() == 0 {
+		return p.process(stdin)
+	}
+	for _, path := range fs.Args() {
+		if err := p.processFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type printer struct {
+	filter *filter
+	raw    bool
+	out    io.Writer
+	enc    zapcore.Encoder
+}
+
+func (p *printer) processFile(path string) error {
+	f, err := os.Open(path) //nolint:gosec // reading user-provided files is the purpose of this command
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return p.process(f)
+}
+
+func (p *printer) process(r io.Reader) error {
+	rc, err := ctxzapzstd.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if err := p.line(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (p *printer) line(line []byte) error {
+	rec, err := parseRecord(line)
+	if err != nil {
+		// Not a JSON entry (e.g. a panic trace): show it only when unfiltered.
+		if p.filter.empty() {
+			_, err = fmt.Fprintf(p.out, "%s\n", line)
+			return err
+		}
+		return nil
+	}
+
+	if !p.filter.match(rec) {
+		return nil
+	}
+
+	if p.raw {
+		_, err = fmt.Fprintf(p.out, "%s\n", line)
+		return err
+	}
+
+	buf, err := p.enc.EncodeEntry(rec.entry(), rec.zapFields())
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	_, err = p.out.Write(buf.Bytes())
+	return err
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const input = `{"level":"info","ts":1700000000.5,"msg":"start","request_id":"abc","http":{"status":200}}
+{"level":"debug","ts":1700000001,"msg":"detail","request_id":"abc"}
+{"level":"error","ts":1700000002,"msg":"failed","request_id":"def"}
+not json
+`
+
+func TestRunFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "where",
+			args:     []string{"-raw", "-where", "request_id=abc"},
+			expected: []string{"start", "detail"},
+		},
+		{
+			name:     "nested where",
+			args:     []string{"-raw", "-where", "http.status=200"},
+			expected: []string{"start"},
+		},
+		{
+			name:     "level",
+			args:     []string{"-raw", "-level", "info"},
+			expected: []string{"start", "failed"},
+		},
+		{
+			name:     "time range",
+			args:     []string{"-raw", "-since", "2023-11-14T22:13:21Z", "-until", "2023-11-14T22:13:22Z"},
+			expected: []string{"detail"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(tt.args, strings.NewReader(input), &out); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("expected %d lines, got %d: %q", len(tt.expected), len(lines), out.String())
+			}
+			for i, msg := range tt.expected {
+				if !strings.Contains(lines[i], `"msg":"`+msg+`"`) {
+					t.Errorf("line %d: expected msg %q, got %q", i, msg, lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRunPretty(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-where", "request_id=def"}, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	got := out.String()
+	if !strings.Contains(got, "ERROR\tfailed\t") || !strings.Contains(got, `{"request_id": "def"}`) {
+		t.Errorf("unexpected console output: %q", got)
+	}
+}
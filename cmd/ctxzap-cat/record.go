@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys written by zap's production and development encoder configs.
+const (
+	levelKey      = "level"
+	timeKey       = "ts"
+	messageKey    = "msg"
+	nameKey       = "logger"
+	callerKey     = "caller"
+	functionKey   = "function"
+	stacktraceKey = "stacktrace"
+)
+
+// record is a decoded NDJSON entry.
+type record struct {
+	level   zapcore.Level
+	time    time.Time
+	message string
+	name    string
+	caller  string
+	stack   string
+	fields  map[string]any
+}
+
+func parseRecord(line []byte) (*record, error) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, errors.New("not a JSON object")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	rec := &record{level: zapcore.InfoLevel, fields: fields}
+	if v, ok := takeString(fields, levelKey); ok {
+		if err := rec.level.UnmarshalText([]byte(strings.ToLower(v))); err != nil {
+			return nil, fmt.Errorf("invalid level %q", v)
+		}
+	}
+	rec.time = takeTime(fields)
+	rec.message, _ = takeString(fields, messageKey)
+	rec.name, _ = takeString(fields, nameKey)
+	rec.caller, _ = takeString(fields, callerKey)
+	rec.stack, _ = takeString(fields, stacktraceKey)
+	delete(fields, functionKey)
+	return rec, nil
+}
+
+func takeString(fields map[string]any, key string) (string, bool) {
+	v, ok := fields[key].(string)
+	if ok {
+		delete(fields, key)
+	}
+	return v, ok
+}
+
+// takeTime accepts both epoch seconds (production) and ISO 8601 strings
+// (development).
+func takeTime(fields map[string]any) time.Time {
+	switch v := fields[timeKey].(type) {
+	case json.Number:
+		delete(fields, timeKey)
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9))
+	case string:
+		delete(fields, timeKey)
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// lookup resolves a dotted key against the record's fields.
+func (r *record) lookup(key string) (any, bool) {
+	if v, ok := r.fields[key]; ok {
+		return v, true
+	}
+
+	var current any = r.fields
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (r *record) entry() zapcore.Entry {
+	entry := zapcore.Entry{
+		Level:      r.level,
+		Time:       r.time,
+		LoggerName: r.name,
+		Message:    r.message,
+		Stack:      r.stack,
+	}
+	if r.caller != "" {
+		file, line := splitCaller(r.caller)
+		entry.Caller = zapcore.EntryCaller{Defined: true, File: file, Line: line}
+	}
+	return entry
+}
+
+func splitCaller(caller string) (string, int) {
+	i := strings.LastIndexByte(caller, ':')
+	if i < 0 {
+		return caller, 0
+	}
+	var line int
+	if _, err := fmt.Sscanf(caller[i+1:], "%d", &line); err != nil {
+		return caller, 0
+	}
+	return caller[:i], line
+}
+
+// zapFields returns the remaining fields sorted by key.
+func (r *record) zapFields() []zap.Field {
+	keys := make([]string, 0, len(r.fields))
+	for k := range r.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, toField(k, r.fields[k]))
+	}
+	return fields
+}
+
+func toField(key string, v any) zap.Field {
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return zap.Int64(key, i)
+		}
+		if f, err := n.Float64(); err == nil {
+			return zap.Float64(key, f)
+		}
+		return zap.String(key, n.String())
+	}
+	return zap.Any(key, v)
+}
+
+func newConsoleEncoder() zapcore.Encoder {
+	cfg := zap.NewDevelopmentEncoderConfig()
+	cfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	return zapcore.NewConsoleEncoder(cfg)
+}
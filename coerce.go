@@ -0,0 +1,337 @@
+package ctxzap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Coercion is a target representation for a field value.
+type Coercion int
+
+const (
+	// CoerceString renders any value as a string: numbers and booleans in
+	// their usual form, durations like "1.5s", times in RFC 3339, errors and
+	// Stringers with their methods, and objects as JSON.
+	CoerceString Coercion = iota + 1
+	// CoerceSeconds renders durations as floating-point seconds.
+	CoerceSeconds
+	// CoerceMillis renders durations as integer milliseconds.
+	CoerceMillis
+)
+
+// CoercionRule selects the fields a coercion applies to. Key is matched
+// with path.Match, so "*_id" matches every key ending in "_id".
+type CoercionRule struct {
+	Key string
+	To  Coercion
+}
+
+// CoerceTypes returns a Transformer converting field values according to
+// rules, so that a key always reaches the log pipeline with the same type
+// regardless of how each call site logged it. The first matching rule wins;
+// fields a coercion does not apply to (e.g. a string under CoerceSeconds)
+// are left unchanged.
+func CoerceTypes(rules ...CoercionRule) Transformer {
+	return func(fields []zap.Field) []zap.Field {
+		for i := range fields {
+			for _, rule := range rules {
+				if matched, _ := path.Match(rule.Key, fields[i].Key); matched {
+					fields[i] = coerce(fields[i], rule.To)
+					break
+				}
+			}
+		}
+		return fields
+	}
+}
+
+func coerce(f zap.Field, to Coercion) zap.Field {
+	switch to {
+	case CoerceString:
+		switch f.Type {
+		case zapcore.StringType, zapcore.SkipType, zapcore.NamespaceType:
+			return f
+		case zapcore.InlineMarshalerType:
+			return coerceInline(f, to)
+		}
+		return zap.String(f.Key, fieldString(f))
+	case CoerceSeconds:
+		if f.Type == zapcore.DurationType {
+			return zap.Float64(f.Key, time.Duration(f.Integer).Seconds())
+		}
+	case CoerceMillis:
+		if f.Type == zapcore.DurationType {
+			return zap.Int64(f.Key, time.Duration(f.Integer).Milliseconds())
+		}
+	}
+	return f
+}
+
+// coerceInline coerces the value an inline field adds under its own key,
+// e.g. the raw number of Bytes, keeping the other values it adds, such as
+// the humanized companion.
+func coerceInline(f zap.Field, to Coercion) zap.Field {
+	m, ok := f.Interface.(zapcore.ObjectMarshaler)
+	if !ok {
+		return f
+	}
+	f.Interface = zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		return m.MarshalLogObject(coercingEncoder{ObjectEncoder: enc, key: f.Key, to: to})
+	})
+	return f
+}
+
+// fieldString renders the value of f as a string.
+func fieldString(f zap.Field) string {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.BoolType:
+		return strconv.FormatBool(f.Integer == 1)
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return strconv.FormatInt(f.Integer, 10)
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return strconv.FormatUint(uint64(f.Integer), 10)
+	case zapcore.Float64Type:
+		return strconv.FormatFloat(math.Float64frombits(uint64(f.Integer)), 'g', -1, 64)
+	case zapcore.Float32Type:
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(f.Integer))), 'g', -1, 32)
+	case zapcore.DurationType:
+		return time.Duration(f.Integer).String()
+	case zapcore.TimeType:
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Interface.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		return t.Format(time.RFC3339Nano)
+	case zapcore.TimeFullType:
+		if t, ok := f.Interface.(time.Time); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+	case zapcore.BinaryType, zapcore.ByteStringType:
+		return string(f.Interface.([]byte))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return err.Error()
+		}
+	case zapcore.ReflectType:
+		if data, err := json.Marshal(f.Interface); err == nil {
+			return string(data)
+		}
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		// Encode the value under a fixed key, as the field's key may be
+		// empty.
+		enc := zapcore.NewMapObjectEncoder()
+		f.Key = "value"
+		f.AddTo(enc)
+		if data, err := json.Marshal(enc.Fields[f.Key]); err == nil {
+			return string(data)
+		}
+	}
+	// Complex numbers, Stringers and anything else.
+	return fmt.Sprint(f.Interface)
+}
+
+// coercingEncoder coerces the value added under key, passing other values
+// to the wrapped encoder unchanged.
+type coercingEncoder struct {
+	zapcore.ObjectEncoder
+	key string
+	to  Coercion
+}
+
+// add adds f, which was added under the coerced key, coerced.
+func (e coercingEncoder) add(f zap.Field) {
+	coerce(f, e.to).AddTo(e.ObjectEncoder)
+}
+
+func (e coercingEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	if key != e.key {
+		return e.ObjectEncoder.AddArray(key, v)
+	}
+	e.add(zap.Array(key, v))
+	return nil
+}
+
+func (e coercingEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	if key != e.key {
+		return e.ObjectEncoder.AddObject(key, v)
+	}
+	e.add(zap.Object(key, v))
+	return nil
+}
+
+func (e coercingEncoder) AddReflected(key string, v interface{}) error {
+	if key != e.key {
+		return e.ObjectEncoder.AddReflected(key, v)
+	}
+	e.add(zap.Reflect(key, v))
+	return nil
+}
+
+func (e coercingEncoder) AddBinary(key string, v []byte) {
+	if key != e.key {
+		e.ObjectEncoder.AddBinary(key, v)
+		return
+	}
+	e.add(zap.Binary(key, v))
+}
+
+func (e coercingEncoder) AddByteString(key string, v []byte) {
+	if key != e.key {
+		e.ObjectEncoder.AddByteString(key, v)
+		return
+	}
+	e.add(zap.ByteString(key, v))
+}
+
+func (e coercingEncoder) AddBool(key string, v bool) {
+	if key != e.key {
+		e.ObjectEncoder.AddBool(key, v)
+		return
+	}
+	e.add(zap.Bool(key, v))
+}
+
+func (e coercingEncoder) AddComplex128(key string, v complex128) {
+	if key != e.key {
+		e.ObjectEncoder.AddComplex128(key, v)
+		return
+	}
+	e.add(zap.Complex128(key, v))
+}
+
+func (e coercingEncoder) AddComplex64(key string, v complex64) {
+	if key != e.key {
+		e.ObjectEncoder.AddComplex64(key, v)
+		return
+	}
+	e.add(zap.Complex64(key, v))
+}
+
+func (e coercingEncoder) AddDuration(key string, v time.Duration) {
+	if key != e.key {
+		e.ObjectEncoder.AddDuration(key, v)
+		return
+	}
+	e.add(zap.Duration(key, v))
+}
+
+func (e coercingEncoder) AddFloat64(key string, v float64) {
+	if key != e.key {
+		e.ObjectEncoder.AddFloat64(key, v)
+		return
+	}
+	e.add(zap.Float64(key, v))
+}
+
+func (e coercingEncoder) AddFloat32(key string, v float32) {
+	if key != e.key {
+		e.ObjectEncoder.AddFloat32(key, v)
+		return
+	}
+	e.add(zap.Float32(key, v))
+}
+
+func (e coercingEncoder) AddInt(key string, v int) {
+	if key != e.key {
+		e.ObjectEncoder.AddInt(key, v)
+		return
+	}
+	e.add(zap.Int(key, v))
+}
+
+func (e coercingEncoder) AddInt64(key string, v int64) {
+	if key != e.key {
+		e.ObjectEncoder.AddInt64(key, v)
+		return
+	}
+	e.add(zap.Int64(key, v))
+}
+
+func (e coercingEncoder) AddInt32(key string, v int32) {
+	if key != e.key {
+		e.ObjectEncoder.AddInt32(key, v)
+		return
+	}
+	e.add(zap.Int32(key, v))
+}
+
+func (e coercingEncoder) AddInt16(key string, v int16) {
+	if key != e.key {
+		e.ObjectEncoder.AddInt16(key, v)
+		return
+	}
+	e.add(zap.Int16(key, v))
+}
+
+func (e coercingEncoder) AddInt8(key string, v int8) {
+	if key != e.key {
+		e.ObjectEncoder.AddInt8(key, v)
+		return
+	}
+	e.add(zap.Int8(key, v))
+}
+
+func (e coercingEncoder) AddTime(key string, v time.Time) {
+	if key != e.key {
+		e.ObjectEncoder.AddTime(key, v)
+		return
+	}
+	e.add(zap.Time(key, v))
+}
+
+func (e coercingEncoder) AddUint(key string, v uint) {
+	if key != e.key {
+		e.ObjectEncoder.AddUint(key, v)
+		return
+	}
+	e.add(zap.Uint(key, v))
+}
+
+func (e coercingEncoder) AddUint64(key string, v uint64) {
+	if key != e.key {
+		e.ObjectEncoder.AddUint64(key, v)
+		return
+	}
+	e.add(zap.Uint64(key, v))
+}
+
+func (e coercingEncoder) AddUint32(key string, v uint32) {
+	if key != e.key {
+		e.ObjectEncoder.AddUint32(key, v)
+		return
+	}
+	e.add(zap.Uint32(key, v))
+}
+
+func (e coercingEncoder) AddUint16(key string, v uint16) {
+	if key != e.key {
+		e.ObjectEncoder.AddUint16(key, v)
+		return
+	}
+	e.add(zap.Uint16(key, v))
+}
+
+func (e coercingEncoder) AddUint8(key string, v uint8) {
+	if key != e.key {
+		e.ObjectEncoder.AddUint8(key, v)
+		return
+	}
+	e.add(zap.Uint8(key, v))
+}
+
+func (e coercingEncoder) AddUintptr(key string, v uintptr) {
+	if key != e.key {
+		e.ObjectEncoder.AddUintptr(key, v)
+		return
+	}
+	e.add(zap.Uintptr(key, v))
+}
@@ -0,0 +1,428 @@
+package ctxzap
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ellipsis marks truncated console text.
+const ellipsis = "…"
+
+// ConsoleOptions configures NewConsoleEncoder. Widths are in characters;
+// zero leaves the column or value unchanged.
+type ConsoleOptions struct {
+	// LevelWidth pads the level column, so that messages line up.
+	LevelWidth int
+	// NameWidth pads or truncates the logger name column.
+	NameWidth int
+	// CallerWidth pads the caller column, or truncates it from the left so
+	// that the file name and line stay visible.
+	CallerWidth int
+	// MaxFieldWidth truncates string field values longer than it.
+	MaxFieldWidth int
+	// Theme colors the columns and fields. If nil, only the colors written
+	// by the encoder config (e.g. zapcore.CapitalColorLevelEncoder) appear.
+	Theme *ConsoleTheme
+	// NoColor removes all colors from the output. It is implied when the
+	// NO_COLOR environment variable is set; see ColorsEnabled.
+	NoColor bool
+	// LevelGlyphs prefixes the level column with a glyph per level, e.g.
+	// DefaultLevelGlyphs for demos and workshops. Most terminals render
+	// emoji two columns wide; account for it in LevelWidth.
+	LevelGlyphs map[zapcore.Level]string
+	// Highlight lists field keys written before the other fields, in the
+	// theme's Highlight color. Fields added with With are not highlighted.
+	Highlight []string
+	// Templates renders known messages as sentences built from the entry's
+	// fields, which are still written after it. Fields added with With are
+	// not available to templates.
+	Templates *MessageTemplates
+	// Locale selects the templates' locale, e.g. the operator's language.
+	Locale string
+	// MaxRetainedBuffer is the capacity, in bytes, above which an output
+	// buffer grown by a large entry is released instead of being reused, so
+	// that occasional large payloads do not pin memory in long-lived
+	// processes. Defaults to DefaultMaxRetainedBuffer; negative retains all
+	// buffers. Values below zap's initial buffer size of 1 KiB are raised to
+	// it.
+	MaxRetainedBuffer int
+}
+
+// DefaultMaxRetainedBuffer is the default ConsoleOptions.MaxRetainedBuffer.
+const DefaultMaxRetainedBuffer = 64 << 10
+
+// minRetainedBuffer is the size of the buffers created by zap's pools.
+const minRetainedBuffer = 1 << 10
+
+// DefaultLevelGlyphs maps levels to emoji markers.
+var DefaultLevelGlyphs = map[zapcore.Level]string{
+	zapcore.DebugLevel:  "🐛",
+	zapcore.InfoLevel:   "💬",
+	zapcore.WarnLevel:   "🚧",
+	zapcore.ErrorLevel:  "🔥",
+	zapcore.DPanicLevel: "💥",
+	zapcore.PanicLevel:  "💥",
+	zapcore.FatalLevel:  "💀",
+}
+
+// Color is an ANSI SGR parameter sequence, such as "31" for red or "1;31"
+// for bold red.
+type Color string
+
+// Common colors.
+const (
+	ColorRed     Color = "31"
+	ColorGreen   Color = "32"
+	ColorYellow  Color = "33"
+	ColorBlue    Color = "34"
+	ColorMagenta Color = "35"
+	ColorCyan    Color = "36"
+	ColorGray    Color = "90"
+	ColorBold    Color = "1"
+)
+
+// wrap colors s; an empty color leaves it unchanged.
+func (c Color) wrap(s string) string {
+	if c == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + string(c) + "m" + s + "\x1b[0m"
+}
+
+// ConsoleTheme assigns colors to the parts of console entries. Empty colors
+// leave the part unchanged.
+type ConsoleTheme struct {
+	// Levels colors the level column per level, replacing any color written
+	// by the level encoder.
+	Levels  map[zapcore.Level]Color
+	Time    Color
+	Name    Color
+	Caller  Color
+	Message Color
+	// Fields colors the fields, whether from the context or the call site.
+	Fields Color
+	// Highlight colors the fields listed in ConsoleOptions.Highlight.
+	// Defaults to ColorBold.
+	Highlight Color
+}
+
+// DefaultConsoleTheme uses zap's level colors and dims the time and caller.
+var DefaultConsoleTheme = ConsoleTheme{
+	Levels: map[zapcore.Level]Color{
+		zapcore.DebugLevel:  ColorMagenta,
+		zapcore.InfoLevel:   ColorBlue,
+		zapcore.WarnLevel:   ColorYellow,
+		zapcore.ErrorLevel:  ColorRed,
+		zapcore.DPanicLevel: ColorRed,
+		zapcore.PanicLevel:  ColorRed,
+		zapcore.FatalLevel:  ColorRed,
+	},
+	Time:   ColorGray,
+	Caller: ColorGray,
+	Fields: ColorCyan,
+}
+
+// ColorsEnabled reports whether console output to f should be colored,
+// following the NO_COLOR and FORCE_COLOR conventions: NO_COLOR disables
+// colors, FORCE_COLOR enables them, and otherwise they are enabled only if
+// f is a terminal.
+func ColorsEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return true
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+var consoleBufferPool = buffer.NewPool()
+
+// NewConsoleEncoder creates a console encoder for human-readable local
+// output. It writes the same layout as zapcore.NewConsoleEncoder, with
+// columns aligned and long values truncated with an ellipsis according to
+// opts.
+func NewConsoleEncoder(cfg zapcore.EncoderConfig, opts ConsoleOptions) zapcore.Encoder {
+	if cfg.ConsoleSeparator == "" {
+		cfg.ConsoleSeparator = "\t"
+	}
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		opts.NoColor = true
+	}
+	if opts.Theme == nil {
+		opts.Theme = &ConsoleTheme{}
+	}
+	switch {
+	case opts.MaxRetainedBuffer == 0:
+		opts.MaxRetainedBuffer = DefaultMaxRetainedBuffer
+	case opts.MaxRetainedBuffer > 0 && opts.MaxRetainedBuffer < minRetainedBuffer:
+		opts.MaxRetainedBuffer = minRetainedBuffer
+	}
+
+	// A console encoder without entry keys writes just the fields, as the
+	// JSON object zap's console encoder uses.
+	fieldsConfig := cfg
+	fieldsConfig.TimeKey = ""
+	fieldsConfig.LevelKey = ""
+	fieldsConfig.NameKey = ""
+	fieldsConfig.CallerKey = ""
+	fieldsConfig.FunctionKey = ""
+	fieldsConfig.MessageKey = ""
+	fieldsConfig.StacktraceKey = ""
+	fieldsConfig.SkipLineEnding = true
+
+	fieldsEncoder := zapcore.NewConsoleEncoder(fieldsConfig)
+	return &consoleEncoder{
+		Encoder: fieldsEncoder.Clone(),
+		empty:   fieldsEncoder,
+		cfg:     &cfg,
+		opts:    opts,
+	}
+}
+
+type consoleEncoder struct {
+	// Encoder accumulates the fields added with With.
+	zapcore.Encoder
+
+	// empty is a fields encoder without fields; it is only cloned.
+	empty zapcore.Encoder
+	cfg   *zapcore.EncoderConfig
+	opts  ConsoleOptions
+}
+
+func (e *consoleEncoder) Clone() zapcore.Encoder {
+	return &consoleEncoder{Encoder: e.Encoder.Clone(), empty: e.empty, cfg: e.cfg, opts: e.opts}
+}
+
+// buffer returns a pooled buffer, releasing pooled buffers larger than
+// MaxRetainedBuffer. The loop ends at the latest with a new buffer from the
+// pool.
+func (e *consoleEncoder) buffer() *buffer.Buffer {
+	for {
+		buf := consoleBufferPool.Get()
+		if e.opts.MaxRetainedBuffer < 0 || buf.Cap() <= e.opts.MaxRetainedBuffer {
+			return buf
+		}
+	}
+}
+
+func (e *consoleEncoder) AddString(key, value string) {
+	e.Encoder.AddString(key, truncateEnd(value, e.opts.MaxFieldWidth))
+}
+
+func (e *consoleEncoder) EncodeEntry(ent zapcore.Entry, fields []zap.Field) (*buffer.Buffer, error) {
+	cfg := e.cfg
+	line := e.buffer()
+
+	theme := e.opts.Theme
+
+	var columns []string
+	if cfg.TimeKey != "" && cfg.EncodeTime != nil && !ent.Time.IsZero() {
+		columns = append(columns, theme.Time.wrap(encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+			cfg.EncodeTime(ent.Time, enc)
+		})))
+	}
+	if cfg.LevelKey != "" && cfg.EncodeLevel != nil {
+		level := encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+			cfg.EncodeLevel(ent.Level, enc)
+		})
+		if color, ok := theme.Levels[ent.Level]; ok {
+			level = color.wrap(stripColors(level))
+		}
+		if glyph, ok := e.opts.LevelGlyphs[ent.Level]; ok {
+			level = glyph + " " + level
+		}
+		columns = append(columns, padColumn(level, e.opts.LevelWidth))
+	}
+	if cfg.NameKey != "" && (ent.LoggerName != "" || e.opts.NameWidth > 0) {
+		name := ent.LoggerName
+		if name != "" && cfg.EncodeName != nil {
+			name = encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+				cfg.EncodeName(ent.LoggerName, enc)
+			})
+		}
+		columns = append(columns, padColumn(theme.Name.wrap(truncateEnd(name, e.opts.NameWidth)), e.opts.NameWidth))
+	}
+	if cfg.CallerKey != "" && cfg.EncodeCaller != nil && (ent.Caller.Defined || e.opts.CallerWidth > 0) {
+		var caller string
+		if ent.Caller.Defined {
+			caller = encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+				cfg.EncodeCaller(ent.Caller, enc)
+			})
+		}
+		columns = append(columns, padColumn(theme.Caller.wrap(truncateStart(caller, e.opts.CallerWidth)), e.opts.CallerWidth))
+	}
+	if cfg.FunctionKey != "" && ent.Caller.Defined {
+		columns = append(columns, ent.Caller.Function)
+	}
+	if cfg.MessageKey != "" {
+		msg := ent.Message
+		if e.opts.Templates != nil {
+			if rendered, ok := e.opts.Templates.Render(e.opts.Locale, msg, fields); ok {
+				msg = rendered
+			}
+		}
+		columns = append(columns, theme.Message.wrap(msg))
+	}
+	line.AppendString(strings.Join(columns, cfg.ConsoleSeparator))
+
+	if err := e.writeFields(line, fields); err != nil {
+		line.Free()
+		return nil, err
+	}
+
+	if ent.Stack != "" && cfg.StacktraceKey != "" {
+		line.AppendByte('\n')
+		line.AppendString(ent.Stack)
+	}
+	line.AppendString(cfg.LineEnding)
+
+	if e.opts.NoColor {
+		stripped := stripColors(line.String())
+		line.Reset()
+		line.AppendString(stripped)
+	}
+	return line, nil
+}
+
+// writeFields appends the accumulated and entry fields, if any.
+func (e *consoleEncoder) writeFields(line *buffer.Buffer, fields []zap.Field) error {
+	var highlighted zapcore.Encoder
+	if len(e.opts.Highlight) > 0 {
+		highlighted = e.empty.Clone()
+	}
+
+	enc := e.Encoder.Clone()
+	for _, f := range fields {
+		if f.Type == zapcore.StringType {
+			f.String = truncateEnd(f.String, e.opts.MaxFieldWidth)
+		}
+		if highlighted != nil && slices.Contains(e.opts.Highlight, f.Key) {
+			f.AddTo(highlighted)
+			continue
+		}
+		f.AddTo(enc)
+	}
+
+	if highlighted != nil {
+		color := e.opts.Theme.Highlight
+		if color == "" {
+			color = ColorBold
+		}
+		if err := e.appendFields(line, highlighted, color); err != nil {
+			return err
+		}
+	}
+	return e.appendFields(line, enc, e.opts.Theme.Fields)
+}
+
+// appendFields appends the fields accumulated in enc, if any, in color.
+// The fields are encoded into a buffer from zap's pool, which is returned to
+// it only if it stays within MaxRetainedBuffer, as with the output buffers.
+func (e *consoleEncoder) appendFields(line *buffer.Buffer, enc zapcore.Encoder, color Color) error {
+	object, err := enc.EncodeEntry(zapcore.Entry{}, nil)
+	if err != nil {
+		return err
+	}
+	defer e.release(object)
+
+	if object.Len() > 0 {
+		line.AppendString(e.cfg.ConsoleSeparator)
+		if color != "" {
+			line.AppendString("\x1b[" + string(color) + "m")
+		}
+		_, _ = line.Write(object.Bytes())
+		if color != "" {
+			line.AppendString("\x1b[0m")
+		}
+	}
+	return nil
+}
+
+// release frees buf unless it grew beyond MaxRetainedBuffer, in which case
+// it is left to the garbage collector.
+func (e *consoleEncoder) release(buf *buffer.Buffer) {
+	if e.opts.MaxRetainedBuffer < 0 || buf.Cap() <= e.opts.MaxRetainedBuffer {
+		buf.Free()
+	}
+}
+
+// encodePrimitive returns the text written by an encoder function such as
+// EncodeLevel, formatted as zap's console encoder does.
+func encodePrimitive(encode func(zapcore.PrimitiveArrayEncoder)) string {
+	enc := zapcore.NewMapObjectEncoder()
+	_ = enc.AddArray("v", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		encode(arr)
+		return nil
+	}))
+
+	values, _ := enc.Fields["v"].([]interface{})
+	var sb strings.Builder
+	for _, v := range values {
+		fmt.Fprint(&sb, v)
+	}
+	return sb.String()
+}
+
+// padColumn pads s with spaces to width visible characters.
+func padColumn(s string, width int) string {
+	if n := visibleWidth(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// truncateEnd shortens s to width characters, ending it with an ellipsis.
+func truncateEnd(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + ellipsis
+}
+
+// truncateStart shortens s to width characters, starting it with an
+// ellipsis.
+func truncateStart(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return ellipsis + string(runes[len(runes)-width+1:])
+}
+
+// stripColors removes ANSI color sequences from s.
+func stripColors(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			if end := strings.IndexByte(s[i:], 'm'); end >= 0 {
+				i += end + 1
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+		i++
+	}
+	return sb.String()
+}
+
+// visibleWidth returns the number of characters of s, ignoring ANSI color
+// sequences.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(stripColors(s))
+}
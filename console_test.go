@@ -0,0 +1,188 @@
+package ctxzap
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func consoleTestEntry() zapcore.Entry {
+	return zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "api.handlers",
+		Message:    "request served",
+		Caller:     zapcore.NewEntryCaller(0, "/src/github.com/acme/service/internal/handlers/users.go", 42, true),
+	}
+}
+
+func TestConsoleEncoderMatchesZap(t *testing.T) {
+	cfg := zap.NewDevelopmentEncoderConfig()
+	fields := []zap.Field{zap.String("request_id", "req-1"), zap.Int("status", 200), zap.Error(errors.New("boom"))}
+
+	zapEnc := zapcore.NewConsoleEncoder(cfg)
+	zapEnc.AddString("component", "users")
+	ourEnc := NewConsoleEncoder(cfg, ConsoleOptions{})
+	ourEnc.AddString("component", "users")
+
+	for _, ent := range []zapcore.Entry{consoleTestEntry(), {Level: zapcore.WarnLevel, Message: "bare", Stack: "goroutine 1"}} {
+		want, err := zapEnc.EncodeEntry(ent, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ourEnc.EncodeEntry(ent, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("expected %q, got %q", want.String(), got.String())
+		}
+	}
+}
+
+func TestConsoleEncoderColumns(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	cfg := zap.NewDevelopmentEncoderConfig()
+	cfg.TimeKey = ""
+	cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	enc := NewConsoleEncoder(cfg, ConsoleOptions{LevelWidth: 5, NameWidth: 8, CallerWidth: 16, MaxFieldWidth: 6})
+
+	buf, err := enc.EncodeEntry(consoleTestEntry(), []zap.Field{zap.String("token", "abcdefghij"), zap.Int("n", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\t")
+	if len(columns) != 5 {
+		t.Fatalf("expected 5 columns, got %q", columns)
+	}
+	if columns[0] != "\x1b[34mINFO\x1b[0m " {
+		t.Errorf("expected the colored level padded to 5 characters, got %q", columns[0])
+	}
+	if columns[1] != "api.han…" {
+		t.Errorf("expected the name truncated to 8 characters, got %q", columns[1])
+	}
+	if columns[2] != "…ers/users.go:42" {
+		t.Errorf("expected the caller truncated from the left and padded, got %q", columns[2])
+	}
+	if columns[4] != `{"token": "abcde…", "n": 1}` {
+		t.Errorf("expected long values truncated, got %q", columns[4])
+	}
+}
+
+func TestConsoleEncoderTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	cfg := zap.NewDevelopmentEncoderConfig()
+	cfg.TimeKey = ""
+	cfg.CallerKey = ""
+	cfg.NameKey = ""
+	cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	theme := &ConsoleTheme{
+		Levels: map[zapcore.Level]Color{zapcore.InfoLevel: ColorGreen},
+		Fields: ColorCyan,
+	}
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "hi"}
+	fields := []zap.Field{zap.Int("n", 1)}
+
+	buf, err := NewConsoleEncoder(cfg, ConsoleOptions{Theme: theme}).EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x1b[32mINFO\x1b[0m\thi\t\x1b[36m{\"n\": 1}\x1b[0m\n"; buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+
+	buf, err = NewConsoleEncoder(cfg, ConsoleOptions{Theme: theme, NoColor: true}).EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INFO\thi\t{\"n\": 1}\n"; buf.String() != want {
+		t.Errorf("expected no colors, got %q", buf.String())
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	buf, err = NewConsoleEncoder(cfg, ConsoleOptions{}).EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Errorf("expected NO_COLOR to disable colors, got %q", buf.String())
+	}
+}
+
+func TestColorsEnabled(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	if ColorsEnabled(f) {
+		t.Error("expected no colors for a regular file")
+	}
+
+	t.Setenv("FORCE_COLOR", "1")
+	if !ColorsEnabled(f) {
+		t.Error("expected FORCE_COLOR to enable colors")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if ColorsEnabled(f) {
+		t.Error("expected NO_COLOR to take precedence")
+	}
+}
+
+func TestConsoleEncoderGlyphsAndHighlight(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	cfg := zap.NewDevelopmentEncoderConfig()
+	cfg.TimeKey = ""
+	cfg.CallerKey = ""
+	cfg.NameKey = ""
+	enc := NewConsoleEncoder(cfg, ConsoleOptions{
+		LevelGlyphs: DefaultLevelGlyphs,
+		Highlight:   []string{"user_id"},
+	})
+	enc.AddString("component", "users")
+
+	buf, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "failed"}, []zap.Field{
+		zap.Int("attempt", 2),
+		zap.String("user_id", "u1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "🔥 ERROR\tfailed\t\x1b[1m{\"user_id\": \"u1\"}\x1b[0m\t{\"component\": \"users\", \"attempt\": 2}\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestConsoleEncoderReleasesLargeBuffers(t *testing.T) {
+	enc := NewConsoleEncoder(zap.NewDevelopmentEncoderConfig(), ConsoleOptions{MaxRetainedBuffer: 4 << 10})
+	large := []zap.Field{zap.String("payload", strings.Repeat("x", 256<<10))}
+
+	for i := 0; i < 10; i++ {
+		buf, err := enc.EncodeEntry(consoleTestEntry(), large)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Free()
+
+		buf, err = enc.EncodeEntry(consoleTestEntry(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.Cap() > 4<<10 {
+			t.Fatalf("expected a buffer of at most 4 KiB after a large entry, got %d bytes", buf.Cap())
+		}
+		buf.Free()
+	}
+}
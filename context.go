@@ -2,8 +2,12 @@ package ctxzap
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // contextKey is used as a key for storing fields in context
@@ -11,38 +15,259 @@ type contextKey struct{}
 
 var fieldsKey = contextKey{}
 
+// loggerContextKey is used as a key for storing a Logger in context
+type loggerContextKey struct{}
+
+var loggerKey = loggerContextKey{}
+
+// nopLogger is returned by L when no logger is stored in the context and
+// none was set with SetGlobal.
+var nopLogger = New(zap.NewNop())
+
 // WithFields adds zap fields to the context. Multiple calls to WithFields
 // will accumulate fields. If a field with the same key already exists,
 // it will be overwritten by the new value.
+//
+// The fields stored in a context are never modified: every call stores a
+// copy of fields linked to the fields of ctx, so any number of goroutines may
+// derive contexts from the same parent concurrently, and later changes to the
+// fields slice passed in do not affect the context. Adding fields does not
+// copy the fields already in ctx; they are merged once, when first needed.
 func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
 	if len(fields) == 0 {
 		return ctx
 	}
+	if provenanceEnabled.Load() {
+		keys := make([]string, len(fields))
+		for i := range fields {
+			keys[i] = fields[i].Key
+		}
+		ctx = withProvenance(ctx, keys)
+	}
+
+	return context.WithValue(ctx, fieldsKey, newFieldNode(fieldNodeFrom(ctx), slices.Clone(fields)))
+}
+
+// WithFieldsMap adds the entries of m to the context as fields, in key
+// order, for metadata that arrives as a map from configuration or JSON.
+// Values are converted with zap.Any, which picks the typed field for
+// strings, numbers, bools, times, durations and errors, and falls back to
+// reflection otherwise.
+func WithFieldsMap(ctx context.Context, m map[string]any) context.Context {
+	if len(m) == 0 {
+		return ctx
+	}
 
-	existingFields := FieldsFromContext(ctx)
-	if len(existingFields) == 0 {
-		return context.WithValue(ctx, fieldsKey, fields)
+	fields := make([]zap.Field, 0, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		fields = append(fields, zap.Any(key, m[key]))
+	}
+	return WithFields(ctx, fields...)
+}
+
+// WithNamespace adds fields to the context grouped under namespace, so that
+// they are written as a nested object ({"http": {"method": "GET"}}) rather
+// than as flat keys that may collide with the fields of other layers. Calls
+// with the same namespace merge into one object, later fields overriding
+// earlier ones with the same key.
+func WithNamespace(ctx context.Context, namespace string, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
 	}
 
-	// Merge fields with existing ones
-	mergedFields := MergeFields(existingFields, fields)
-	return context.WithValue(ctx, fieldsKey, mergedFields)
+	group := fields
+	for _, f := range storedFields(ctx) {
+		if existing, ok := f.Interface.(fieldGroup); ok && f.Key == namespace {
+			group = MergeFields(existing, fields)
+		}
+	}
+	return WithFields(ctx, zap.Object(namespace, fieldGroup(slices.Clip(slices.Clone(group)))))
+}
+
+// WithoutFields returns a context without the fields stored under keys, for
+// sub-operations that must not inherit some of the parent's fields, e.g. a
+// user_id while processing another user. Values mirrored with
+// RegisterContextValue and the values of typed keys remain available.
+func WithoutFields(ctx context.Context, keys ...string) context.Context {
+	existingFields := storedFields(ctx)
+	if len(keys) == 0 || len(existingFields) == 0 {
+		return ctx
+	}
+
+	remaining := make([]zap.Field, 0, len(existingFields))
+	for _, f := range existingFields {
+		if !slices.Contains(keys, f.Key) {
+			remaining = append(remaining, f)
+		}
+	}
+	if len(remaining) == len(existingFields) {
+		return ctx
+	}
+	ctx = withoutProvenance(ctx, keys)
+	return context.WithValue(ctx, fieldsKey, resolvedFieldNode(slices.Clip(remaining)))
+}
+
+// ClearFields returns a context without any of the fields stored with
+// WithFields. Like WithoutFields, it does not affect mirrored values.
+func ClearFields(ctx context.Context) context.Context {
+	if len(storedFields(ctx)) == 0 {
+		return ctx
+	}
+	if ctx.Value(provenanceKey) != nil {
+		ctx = context.WithValue(ctx, provenanceKey, map[string]FieldOrigin(nil))
+	}
+	return context.WithValue(ctx, fieldsKey, resolvedFieldNode(nil))
 }
 
 // FieldsFromContext extracts all zap fields stored in the context.
 // Returns an empty slice if no fields are found.
 func FieldsFromContext(ctx context.Context) []zap.Field {
+	fields := storedFields(ctx)
+	if fields == nil {
+		return nil
+	}
+
+	// Return a copy to prevent external modifications, without expired
+	// fields added with WithFieldsTTL.
+	result := make([]zap.Field, 0, len(fields))
+	var now time.Time
+	for _, f := range fields {
+		if f, ok := liveField(f, &now); ok {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// HasField reports whether ctx holds a field with key, added with
+// WithFields.
+func HasField(ctx context.Context, key string) bool {
+	_, ok := GetField(ctx, key)
+	return ok
+}
+
+// GetField returns the field stored in ctx under key, for middleware that
+// needs a single value, such as a request ID for a response header, without
+// copying all the fields.
+func GetField(ctx context.Context, key string) (zap.Field, bool) {
+	for _, f := range storedFields(ctx) {
+		if f.Key == key {
+			var now time.Time
+			return liveField(f, &now)
+		}
+	}
+	return zap.Field{}, false
+}
+
+// RangeFields calls fn for each field stored in ctx, in the order
+// FieldsFromContext returns them, until fn returns false. Unlike
+// FieldsFromContext, it does not copy the fields, so middleware and
+// enrichers can inspect them without allocating:
+//
+//	ctxzap.RangeFields(ctx, func(f zap.Field) bool {
+//		if f.Key == "tenant" {
+//			tenant = f.String
+//			return false
+//		}
+//		return true
+//	})
+func RangeFields(ctx context.Context, fn func(zap.Field) bool) {
+	var now time.Time
+	for _, f := range storedFields(ctx) {
+		if f, ok := liveField(f, &now); ok && !fn(f) {
+			return
+		}
+	}
+}
+
+// FieldsMapFromContext returns the fields stored in ctx as a map from key to
+// value, with values as a zapcore.MapObjectEncoder sees them: strings,
+// numbers, bools, durations and times keep their Go types, and objects and
+// arrays become maps and slices. It returns nil if there are no fields.
+func FieldsMapFromContext(ctx context.Context) map[string]any {
+	fields := storedFields(ctx)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	var now time.Time
+	for _, f := range fields {
+		if f, ok := liveField(f, &now); ok {
+			f.AddTo(enc)
+		}
+	}
+	return enc.Fields
+}
+
+// storedFields returns the fields stored in ctx by WithFields, which must
+// not be modified.
+func storedFields(ctx context.Context) []zap.Field {
 	if ctx == nil {
 		return nil
 	}
+	return fieldNodeFrom(ctx).resolve()
+}
 
-	fields, ok := ctx.Value(fieldsKey).([]zap.Field)
-	if !ok {
+func fieldNodeFrom(ctx context.Context) *fieldNode {
+	if ctx == nil {
 		return nil
 	}
+	n, _ := ctx.Value(fieldsKey).(*fieldNode)
+	return n
+}
 
-	// Return a copy to prevent external modifications
-	result := make([]zap.Field, len(fields))
-	copy(result, fields)
-	return result
+// WithLogger returns a context carrying logger. Code retrieving its logger
+// with L uses it for the whole subtree of calls below ctx, which allows a
+// test or a tenant with a dedicated sink to swap the backing logger without
+// changing how loggers are injected.
+func WithLogger(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// L returns the logger stored in ctx by WithLogger, or the global logger set
+// with SetGlobal if there is none, which is a no-op logger by default.
+func L(ctx context.Context) *Logger {
+	if ctx == nil {
+		return globalLogger()
+	}
+	if logger, ok := ctx.Value(loggerKey).(*Logger); ok && logger != nil {
+		return logger
+	}
+	return globalLogger()
+}
+
+// ToContext is an alias of WithLogger, for code used to the
+// ToContext/FromContext naming of other logging libraries.
+func ToContext(ctx context.Context, logger *Logger) context.Context {
+	return WithLogger(ctx, logger)
+}
+
+// FromContext is an alias of L: it returns the logger stored in ctx, or the
+// global logger if there is none.
+func FromContext(ctx context.Context) *Logger {
+	return L(ctx)
+}
+
+// entryFields returns the context fields to include in a log entry: values
+// mirrored through RegisterContextValue followed by fields added with
+// WithFields, which take precedence.
+func entryFields(ctx context.Context) []zap.Field {
+	return withMirrored(ctx, FieldsFromContext(ctx))
+}
+
+// sharedEntryFields is entryFields without the defensive copy: the result
+// may be the slice stored in ctx, and must not be modified.
+func sharedEntryFields(ctx context.Context) []zap.Field {
+	return withMirrored(ctx, liveFields(storedFields(ctx)))
+}
+
+// withMirrored merges fields over the values of ctx mirrored through
+// RegisterContextValue.
+func withMirrored(ctx context.Context, fields []zap.Field) []zap.Field {
+	mirrored := mirroredFields(ctx)
+	if len(mirrored) == 0 {
+		return fields
+	}
+	return MergeFields(mirrored, fields)
 }
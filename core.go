@@ -0,0 +1,119 @@
+package ctxzap
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// decoratorCore is implemented by the cores ctxzap installs around a
+// logger's core, so that the core beneath them can be replaced.
+type decoratorCore interface {
+	zapcore.Core
+
+	// wrapped returns the decorated core.
+	wrapped() zapcore.Core
+	// rewrap returns a copy of the decorator, with the same state, around
+	// core.
+	rewrap(core zapcore.Core) zapcore.Core
+}
+
+// WithCore returns a child logger whose core is replaced by fn applied to it,
+// like WithOptions(zap.WrapCore(fn)), except that the cores installed by
+// ctxzap (WithTransformers, WithIntegrity, WithLogID, ...) stay on top: fn
+// receives the core beneath them, and they are re-applied, in their original
+// order and with their state, around its result. For example, teeing to a
+// second destination keeps redaction and log IDs on both, while each
+// destination still filters entries by its own level:
+//
+//	logger = logger.WithCore(func(core zapcore.Core) zapcore.Core {
+//		return zapcore.NewTee(core, auditCore)
+//	})
+func (l *Logger) WithCore(fn func(zapcore.Core) zapcore.Core) *Logger {
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return replaceBaseCore(core, fn)
+	}))
+}
+
+func replaceBaseCore(core zapcore.Core, fn func(zapcore.Core) zapcore.Core) zapcore.Core {
+	if d, ok := core.(decoratorCore); ok {
+		return d.rewrap(replaceBaseCore(d.wrapped(), fn))
+	}
+	return fn(core)
+}
+
+// decoration is the behavior of a core installed by ctxzap around a logger's
+// core: it changes or observes the entries on their way to the decorated core.
+type decoration interface {
+	// with returns the decoration of a child core with fields added, and the
+	// fields to add to the decorated core.
+	with(fields []zap.Field) (decoration, []zap.Field)
+	// write writes entry to core, the decorated core.
+	write(core zapcore.Core, entry zapcore.Entry, fields []zap.Field) error
+}
+
+// decoratedCore applies a decoration to the entries logged through its core.
+type decoratedCore struct {
+	zapcore.Core
+
+	decoration decoration
+}
+
+func decorate(core zapcore.Core, d decoration) zapcore.Core {
+	return &decoratedCore{Core: core, decoration: d}
+}
+
+func (c *decoratedCore) With(fields []zap.Field) zapcore.Core {
+	d, fields := c.decoration.with(fields)
+	return decorate(c.Core.With(fields), d)
+}
+
+func (c *decoratedCore) wrapped() zapcore.Core {
+	return c.Core
+}
+
+func (c *decoratedCore) rewrap(core zapcore.Core) zapcore.Core {
+	return decorate(core, c.decoration)
+}
+
+func (c *decoratedCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// Let the wrapped core decide (e.g. sampling), but write through c, to
+	// the cores the wrapped core selected: each core of a tee keeps its own
+	// level.
+	checked := c.Core.Check(entry, nil)
+	if checked == nil {
+		return ce
+	}
+	return ce.AddCore(entry, c.rewrap(&checkedCore{Core: c.Core, checked: checked}))
+}
+
+func (c *decoratedCore) Write(entry zapcore.Entry, fields []zap.Field) error {
+	return c.decoration.write(c.Core, entry, fields)
+}
+
+// checkedCore writes entries to the cores selected by the Check of its core,
+// held in checked. A CheckedEntry is written once, so further entries, such
+// as the parts of a split message, are checked again.
+type checkedCore struct {
+	zapcore.Core
+
+	checked *zapcore.CheckedEntry
+	errs    writeErrors
+}
+
+func (c *checkedCore) Write(entry zapcore.Entry, fields []zap.Field) error {
+	checked := c.checked
+	c.checked = nil
+	if checked == nil {
+		if checked = c.Core.Check(entry, nil); checked == nil {
+			return nil
+		}
+	}
+
+	c.errs = c.errs[:0]
+	checked.Entry = entry
+	checked.ErrorOutput = &c.errs
+	checked.Write(fields...)
+	return errors.Join(c.errs...)
+}
@@ -0,0 +1,95 @@
+package ctxzap
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithCoreKeepsDecorators(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	audit, audited := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithTransformers(RenameKeys(DotsToUnderscores)), WithIntegrity())
+
+	logger.Info(context.Background(), "first")
+	logger = logger.WithCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, audit)
+	})
+	logger.Info(context.Background(), "second", zap.String("http.method", "GET"))
+
+	if observed.Len() != 2 || audited.Len() != 1 {
+		t.Fatalf("expected 2 entries and 1 audited entry, got %d and %d", observed.Len(), audited.Len())
+	}
+	for _, entry := range []observer.LoggedEntry{observed.All()[1], audited.All()[0]} {
+		fields := entry.ContextMap()
+		if fields["http_method"] != "GET" {
+			t.Errorf("expected transformers to apply, got %v", fields)
+		}
+		// The integrity core keeps its sequence across the replacement.
+		if fields[SequenceKey] != uint64(2) {
+			t.Errorf("expected seq 2, got %v", fields[SequenceKey])
+		}
+	}
+}
+
+func TestWithCoreTeeKeepsLevels(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	audit, audited := observer.New(zapcore.ErrorLevel)
+	logger := New(zap.New(core), WithLogID(), WithIntegrity()).WithCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, audit)
+	})
+
+	logger.Debug(context.Background(), "debug")
+	logger.Info(context.Background(), "info")
+	logger.Error(context.Background(), "error")
+
+	if observed.Len() != 3 || audited.Len() != 1 {
+		t.Fatalf("expected 3 entries and 1 audited entry, got %d and %d", observed.Len(), audited.Len())
+	}
+	if entry := audited.All()[0]; entry.Message != "error" || entry.ContextMap()[SequenceKey] != uint64(3) || entry.ContextMap()[LogIDKey] == nil {
+		t.Errorf("expected the stamped error entry, got %v", entry)
+	}
+}
+
+func TestWithCoreWithoutDecorators(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core)).WithCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{}), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	})
+
+	logger.Info(context.Background(), "replaced")
+	if observed.Len() != 0 {
+		t.Errorf("expected the core to be replaced, got %d entries", observed.Len())
+	}
+}
+
+func TestDecoratorsKeepTeeLevels(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		option Option
+	}{
+		{"integrity", WithIntegrity()},
+		{"log id", WithLogID()},
+		{"transformers", WithTransformers(RenameKeys(DotsToUnderscores))},
+		{"cardinality watch", WithCardinalityWatch(CardinalityOptions{Keys: []string{"user_id"}})},
+		{"cost accounting", WithCostAccounting(nil)},
+		{"message options", WithMessageOptions(MessageOptions{MultiLine: MultiLineSplit})},
+		{"shutdown report", WithShutdownReport(nil)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			debug, all := observer.New(zapcore.DebugLevel)
+			errs, errors := observer.New(zapcore.ErrorLevel)
+			logger := New(zap.New(zapcore.NewTee(debug, errs)), tt.option)
+
+			logger.Debug(context.Background(), "debug")
+			logger.Error(context.Background(), "error")
+			if all.Len() != 2 || errors.Len() != 1 || errors.All()[0].Message != "error" {
+				t.Errorf("expected 2 entries and 1 error, got %v and %v", all.All(), errors.All())
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package ctxzap
+
+import (
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// OtherCostKey collects the bytes of messages and field keys seen after
+// maxCostKeys distinct ones, so that dynamic messages cannot grow the
+// statistics without bound.
+const OtherCostKey = "(other)"
+
+const maxCostKeys = 1000
+
+// ByteCount is the number of entries and encoded bytes attributed to a
+// message or field key.
+type ByteCount struct {
+	Key     string
+	Entries uint64
+	Bytes   uint64
+}
+
+// CostStats is a snapshot of the bytes accounted by WithCostAccounting.
+// Messages and Fields are sorted by decreasing Bytes, so the most expensive
+// log lines and fields come first.
+type CostStats struct {
+	Entries  uint64
+	Bytes    uint64
+	Messages []ByteCount
+	Fields   []ByteCount
+}
+
+// WithCostAccounting makes the logger attribute the size of every written
+// entry to its message, and the size of every field to its key, as encoded by
+// enc. If enc is nil, zap's production JSON encoder is used. The entries
+// themselves are written unchanged; the counts are available from Stats on
+// the logger and on loggers derived from it.
+func WithCostAccounting(enc zapcore.Encoder) Option {
+	return func(l *Logger) {
+		if enc == nil {
+			enc = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		}
+		cost := &costAccounting{
+			messages: make(map[string]*ByteCount),
+			fields:   make(map[string]*ByteCount),
+		}
+		l.Logger = l.Logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return decorate(core, &costDecorator{cost: cost, enc: enc})
+		}))
+		l.cost = cost
+	}
+}
+
+// Stats returns the byte accounting of the logger. It is empty unless the
+// logger was created with WithCostAccounting.
+func (l *Logger) Stats() CostStats {
+	if l.cost == nil {
+		return CostStats{}
+	}
+	return l.cost.snapshot()
+}
+
+type costDecorator struct {
+	cost *costAccounting
+	// enc holds the accumulated With fields, which are part of every entry.
+	enc zapcore.Encoder
+	// context holds the With fields, accounted with every entry.
+	context []zap.Field
+}
+
+type costAccounting struct {
+	mu       sync.Mutex
+	entries  uint64
+	bytes    uint64
+	messages map[string]*ByteCount
+	fields   map[string]*ByteCount
+}
+
+func (d *costDecorator) with(fields []zap.Field) (decoration, []zap.Field) {
+	enc := d.enc.Clone()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	return &costDecorator{
+		cost:    d.cost,
+		enc:     enc,
+		context: append(d.context[:len(d.context):len(d.context)], fields...),
+	}, fields
+}
+
+func (d *costDecorator) write(core zapcore.Core, entry zapcore.Entry, fields []zap.Field) error {
+	if buf, err := d.enc.EncodeEntry(entry, fields); err == nil {
+		sizer := fieldSizers.Get().(*fieldSizer)
+		sizes := make([]int, 0, len(d.context)+len(fields))
+		for _, f := range d.context {
+			sizes = append(sizes, sizer.size(f))
+		}
+		for _, f := range fields {
+			sizes = append(sizes, sizer.size(f))
+		}
+		fieldSizers.Put(sizer)
+		d.cost.add(entry.Message, uint64(buf.Len()), d.context, fields, sizes)
+		buf.Free()
+	}
+	return core.Write(entry, fields)
+}
+
+// fieldSizer measures the encoded size of single fields, reusing its encoder
+// for all the fields of an entry.
+type fieldSizer struct {
+	enc   zapcore.Encoder
+	field [1]zap.Field
+}
+
+var fieldSizers = sync.Pool{
+	New: func() any {
+		return &fieldSizer{enc: zapcore.NewJSONEncoder(zapcore.EncoderConfig{})}
+	},
+}
+
+// size returns the encoded size of f on its own, measured as the growth of
+// an entry without other fields.
+func (s *fieldSizer) size(f zap.Field) int {
+	s.field[0] = f
+	buf, err := s.enc.EncodeEntry(zapcore.Entry{}, s.field[:])
+	s.field[0] = zap.Field{}
+	if err != nil {
+		return 0
+	}
+	defer buf.Free()
+	return buf.Len() - emptyEntrySize()
+}
+
+// emptyEntrySize is the size of an entry without fields, as encoded by
+// fieldSizer.
+var emptyEntrySize = sync.OnceValue(func() int {
+	buf, _ := zapcore.NewJSONEncoder(zapcore.EncoderConfig{}).EncodeEntry(zapcore.Entry{}, nil)
+	defer buf.Free()
+	return buf.Len()
+})
+
+func (a *costAccounting) add(msg string, size uint64, context, fields []zap.Field, sizes []int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.entries++
+	a.bytes += size
+	a.count(a.messages, msg, size)
+	for i, f := range context {
+		a.count(a.fields, f.Key, uint64(sizes[i]))
+	}
+	for i, f := range fields {
+		a.count(a.fields, f.Key, uint64(sizes[len(context)+i]))
+	}
+}
+
+func (a *costAccounting) count(counts map[string]*ByteCount, key string, size uint64) {
+	bc, ok := counts[key]
+	if !ok {
+		if len(counts) >= maxCostKeys {
+			key = OtherCostKey
+			bc = counts[key]
+		}
+		if bc == nil {
+			bc = &ByteCount{Key: key}
+			counts[key] = bc
+		}
+	}
+	bc.Entries++
+	bc.Bytes += size
+}
+
+func (a *costAccounting) snapshot() CostStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return CostStats{
+		Entries:  a.entries,
+		Bytes:    a.bytes,
+		Messages: sortedCounts(a.messages),
+		Fields:   sortedCounts(a.fields),
+	}
+}
+
+func sortedCounts(counts map[string]*ByteCount) []ByteCount {
+	sorted := make([]ByteCount, 0, len(counts))
+	for _, bc := range counts {
+		sorted = append(sorted, *bc)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Bytes != sorted[j].Bytes {
+			return sorted[i].Bytes > sorted[j].Bytes
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
@@ -0,0 +1,75 @@
+package ctxzap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCostAccounting(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithCostAccounting(nil))
+	ctx := context.Background()
+
+	child := logger.With(zap.String("service", "api"))
+	child.Info(ctx, "request", zap.String("body", "a very long request body"))
+	child.Info(ctx, "request", zap.String("body", "short"))
+	logger.Info(ctx, "tick")
+	logger.Debug(ctx, "not written", zap.String("body", "ignored"))
+
+	if len(observed.All()) != 3 {
+		t.Fatalf("expected entries to be written unchanged, got %d", len(observed.All()))
+	}
+
+	stats := child.Stats()
+	if stats.Entries != 3 {
+		t.Errorf("expected 3 entries, got %d", stats.Entries)
+	}
+	if len(stats.Messages) != 2 || stats.Messages[0].Key != "request" || stats.Messages[0].Entries != 2 {
+		t.Errorf("unexpected message stats: %+v", stats.Messages)
+	}
+	var total uint64
+	for _, m := range stats.Messages {
+		total += m.Bytes
+	}
+	if total != stats.Bytes {
+		t.Errorf("message bytes %d do not add up to total %d", total, stats.Bytes)
+	}
+
+	want := []ByteCount{
+		{Key: "body", Entries: 2, Bytes: uint64(len(`"body":"a very long request body"`) + len(`"body":"short"`))},
+		{Key: "service", Entries: 2, Bytes: uint64(2 * len(`"service":"api"`))},
+	}
+	if fmt.Sprint(stats.Fields) != fmt.Sprint(want) {
+		t.Errorf("expected field stats %v, got %v", want, stats.Fields)
+	}
+}
+
+func TestCostAccountingDisabled(t *testing.T) {
+	logger := New(zap.NewNop())
+	if stats := logger.Stats(); stats.Entries != 0 || stats.Messages != nil {
+		t.Errorf("expected empty stats, got %+v", stats)
+	}
+}
+
+func TestCostAccountingBoundsKeys(t *testing.T) {
+	core, _ := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithCostAccounting(nil))
+	for i := 0; i < maxCostKeys+10; i++ {
+		logger.Info(context.Background(), fmt.Sprintf("message %d", i))
+	}
+
+	stats := logger.Stats()
+	if len(stats.Messages) != maxCostKeys+1 {
+		t.Fatalf("expected %d messages, got %d", maxCostKeys+1, len(stats.Messages))
+	}
+	for _, m := range stats.Messages {
+		if m.Key == OtherCostKey && m.Entries != 10 {
+			t.Errorf("expected 10 entries in %s, got %d", OtherCostKey, m.Entries)
+		}
+	}
+}
@@ -2,7 +2,16 @@ package ctxzap
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -385,3 +394,404 @@ func TestMergeFields(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeFieldsLinearMatchesMap(t *testing.T) {
+	// Few keys and values, so that inputs hold duplicates, within and across
+	// the slices, with equal and different values.
+	fields := func(n, seed int) []zap.Field {
+		out := make([]zap.Field, n)
+		for i := range out {
+			seed = seed*31 + 7
+			out[i] = zap.Int(string(rune('a'+seed%5)), seed/5%2)
+		}
+		return out
+	}
+	format := func(fields []zap.Field) string {
+		var out []string
+		for _, f := range fields {
+			out = append(out, f.Key+"="+strconv.FormatInt(f.Integer, 10))
+		}
+		return strings.Join(out, ",")
+	}
+
+	for seed := 0; seed < 500; seed++ {
+		existing, added := fields(seed%6, seed), fields(seed%5, seed+1000)
+		linear := format(appendMergedLinear(nil, existing, added))
+		if want := format(appendMergedMap(nil, nil, existing, added)); linear != want {
+			t.Fatalf("merging %s with %s: expected %s, got %s", format(existing), format(added), want, linear)
+		}
+	}
+}
+
+type localeKey struct{}
+
+func TestRegisterContextValue(t *testing.T) {
+	RegisterContextValue(localeKey{}, "locale", func(v any) zap.Field {
+		return zap.String("", v.(string))
+	})
+	defer UnregisterContextValue(localeKey{})
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	ctx := context.WithValue(context.Background(), localeKey{}, "it-IT")
+	logger.Info(ctx, "mirrored")
+
+	ctx = WithFields(ctx, zap.String("locale", "en-US"))
+	logger.Info(ctx, "overridden")
+
+	logger.Info(context.Background(), "absent")
+
+	entries := observed.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 log entries, got %d", len(entries))
+	}
+
+	if got := entries[0].ContextMap()["locale"]; got != "it-IT" {
+		t.Errorf("expected mirrored locale=it-IT, got %v", got)
+	}
+	if got := entries[1].ContextMap()["locale"]; got != "en-US" {
+		t.Errorf("expected context field to win, got %v", got)
+	}
+	if _, ok := entries[2].ContextMap()["locale"]; ok {
+		t.Error("expected no locale field when value is absent")
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+	L(ctx).Info(ctx, "dropped")
+
+	ctx = WithLogger(ctx, logger)
+	L(ctx).Info(ctx, "scoped")
+
+	entries := observed.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	if entries[0].Message != "scoped" || entries[0].ContextMap()["request_id"] != "123" {
+		t.Errorf("unexpected entry: %v %v", entries[0].Message, entries[0].ContextMap())
+	}
+
+	if L(nil) == nil {
+		t.Error("expected a no-op logger for nil context")
+	}
+}
+
+func TestToContext(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	if FromContext(context.Background()) != nopLogger {
+		t.Error("expected a no-op logger when none is stored")
+	}
+
+	ctx := ToContext(context.Background(), logger)
+	if FromContext(ctx) != logger || L(ctx) != logger {
+		t.Error("expected the stored logger")
+	}
+	FromContext(ctx).Info(ctx, "scoped")
+	if observed.Len() != 1 {
+		t.Errorf("expected 1 log entry, got %d", observed.Len())
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+
+	logger.WithContext(ctx).Info("from a library", zap.Int("n", 1))
+	logger.Bare().WithContext(ctx).Info("bare")
+	if logger.WithContext(context.Background()) != logger.Logger {
+		t.Error("expected the logger itself when the context has no fields")
+	}
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+	if fields := entries[0].ContextMap(); fields["request_id"] != "123" || fields["n"] != int64(1) {
+		t.Errorf("expected context fields, got %v", fields)
+	}
+	if _, ok := entries[1].ContextMap()["request_id"]; ok {
+		t.Error("expected no context fields from a bare logger")
+	}
+}
+
+func TestWithFieldsCopiesInput(t *testing.T) {
+	fields := []zap.Field{zap.String("user_id", "alice")}
+	ctx := WithFields(context.Background(), fields...)
+	fields[0] = zap.String("user_id", "mallory")
+
+	if got := FieldsFromContext(ctx)[0].String; got != "alice" {
+		t.Errorf("expected the context to keep its copy, got %q", got)
+	}
+}
+
+// TestConcurrentWithFields derives contexts from a shared parent in many
+// goroutines; run with -race.
+func TestConcurrentWithFields(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	parent := WithFields(context.Background(), zap.String("request_id", "123"), zap.String("service", "api"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			worker := strconv.Itoa(i)
+			for j := 0; j < 50; j++ {
+				ctx := WithFields(parent, zap.String("worker", worker))
+				ctx = WithFields(ctx, zap.Int("iteration", j))
+				logger.Info(ctx, "work")
+				if got := FieldsFromContext(ctx)[2].String; got != worker {
+					t.Errorf("expected worker %s, got %s", worker, got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if observed.Len() != 16*50 {
+		t.Errorf("expected %d entries, got %d", 16*50, observed.Len())
+	}
+	if len(FieldsFromContext(parent)) != 2 {
+		t.Errorf("expected the parent to be unchanged, got %v", FieldsFromContext(parent))
+	}
+}
+
+func TestWithFieldsMap(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx := WithFieldsMap(context.Background(), map[string]any{
+		"service": "api",
+		"port":    8080,
+		"debug":   true,
+		"started": ts,
+		"timeout": time.Second,
+		"cause":   errors.New("boom"),
+		"tags":    []string{"a", "b"},
+	})
+
+	fields := FieldsFromContext(ctx)
+	want := []struct {
+		key string
+		typ zapcore.FieldType
+	}{
+		{"cause", zapcore.ErrorType},
+		{"debug", zapcore.BoolType},
+		{"port", zapcore.Int64Type},
+		{"service", zapcore.StringType},
+		{"started", zapcore.TimeType},
+		{"tags", zapcore.ArrayMarshalerType},
+		{"timeout", zapcore.DurationType},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %d fields, got %v", len(want), fields)
+	}
+	for i, w := range want {
+		if fields[i].Key != w.key || fields[i].Type != w.typ {
+			t.Errorf("field %d: expected %s of type %v, got %s of type %v", i, w.key, w.typ, fields[i].Key, fields[i].Type)
+		}
+	}
+
+	if WithFieldsMap(ctx, nil) != ctx {
+		t.Error("expected an empty map to return ctx unchanged")
+	}
+}
+
+func TestWithoutFields(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	parent := WithFields(context.Background(),
+		zap.String("request_id", "123"),
+		zap.String("user_id", "alice"),
+		zap.String("session", "s1"),
+	)
+
+	child := WithoutFields(parent, "user_id", "session", "missing")
+	logger.Info(child, "child")
+	logger.Info(parent, "parent")
+	logger.Info(ClearFields(parent), "cleared")
+
+	entries := observed.All()
+	if fields := entries[0].ContextMap(); len(fields) != 1 || fields["request_id"] != "123" {
+		t.Errorf("expected only request_id, got %v", fields)
+	}
+	if fields := entries[1].ContextMap(); len(fields) != 3 {
+		t.Errorf("expected the parent to keep its fields, got %v", fields)
+	}
+	if fields := entries[2].ContextMap(); len(fields) != 0 {
+		t.Errorf("expected no fields after ClearFields, got %v", fields)
+	}
+
+	if WithoutFields(parent, "missing") != parent {
+		t.Error("expected ctx unchanged when no key matches")
+	}
+	if ctx := WithFields(ClearFields(parent), zap.Int("n", 1)); len(FieldsFromContext(ctx)) != 1 {
+		t.Errorf("expected fields added after ClearFields, got %v", FieldsFromContext(ctx))
+	}
+}
+
+func TestWithNamespace(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	ctx := WithFields(context.Background(), zap.String("method", "flat"))
+	ctx = WithNamespace(ctx, "http", zap.String("method", "GET"), zap.Int("status", 200))
+	ctx = WithNamespace(ctx, "http", zap.Int("status", 404))
+	ctx = WithNamespace(ctx, "db", zap.String("table", "users"))
+	logger.Info(ctx, "served")
+
+	fields := observed.All()[0].ContextMap()
+	want := map[string]any{
+		"method": "flat",
+		"http":   map[string]any{"method": "GET", "status": int64(404)},
+		"db":     map[string]any{"table": "users"},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %v, got %v", want, fields)
+	}
+	for key, value := range want {
+		if fmt.Sprint(fields[key]) != fmt.Sprint(value) {
+			t.Errorf("expected %s=%v, got %v", key, value, fields[key])
+		}
+	}
+}
+
+func TestFieldAccessors(t *testing.T) {
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "abc"),
+		zap.Int("attempt", 1),
+		zap.Duration("timeout", time.Second),
+	)
+	ctx = WithNamespace(ctx, "http", zap.String("method", "GET"))
+	ctx = WithFields(ctx, zap.Int("attempt", 2))
+
+	if !HasField(ctx, "request_id") || HasField(ctx, "missing") {
+		t.Error("unexpected HasField results")
+	}
+	if f, ok := GetField(ctx, "attempt"); !ok || f.Integer != 2 {
+		t.Errorf("expected the latest attempt field, got %v, %v", f, ok)
+	}
+	if _, ok := GetField(context.Background(), "attempt"); ok {
+		t.Error("expected no field in an empty context")
+	}
+
+	m := FieldsMapFromContext(ctx)
+	if m["request_id"] != "abc" || m["attempt"] != int64(2) || m["timeout"] != time.Second {
+		t.Errorf("unexpected map: %v", m)
+	}
+	if http, _ := m["http"].(map[string]any); http["method"] != "GET" {
+		t.Errorf("expected the namespace as a nested map, got %v", m["http"])
+	}
+	if FieldsMapFromContext(context.Background()) != nil {
+		t.Error("expected nil for an empty context")
+	}
+}
+
+func TestRangeFields(t *testing.T) {
+	ctx := WithFields(context.Background(), zap.String("request_id", "abc"), zap.Int("attempt", 1))
+	ctx = WithFieldsTTL(ctx, 0, zap.String("message_id", "m1"))
+	ctx = WithFields(ctx, zap.Int("attempt", 2))
+
+	var keys []string
+	RangeFields(ctx, func(f zap.Field) bool {
+		keys = append(keys, f.Key)
+		return true
+	})
+	if want := FieldsFromContext(ctx); len(keys) != len(want) || keys[0] != want[0].Key || keys[1] != want[1].Key || keys[2] != want[2].Key {
+		t.Errorf("expected the keys of %v, got %v", want, keys)
+	}
+
+	BumpFieldGeneration(ctx)
+	keys = keys[:0]
+	RangeFields(ctx, func(f zap.Field) bool {
+		keys = append(keys, f.Key)
+		return f.Key != "request_id"
+	})
+	if len(keys) != 1 || keys[0] != "request_id" {
+		t.Errorf("expected iteration to stop after request_id, got %v", keys)
+	}
+
+	RangeFields(context.Background(), func(zap.Field) bool {
+		t.Error("expected no fields in an empty context")
+		return true
+	})
+	if allocs := testing.AllocsPerRun(100, func() {
+		RangeFields(ctx, func(zap.Field) bool { return true })
+	}); allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestWithFieldsLayerOrdering(t *testing.T) {
+	keys := func(fields []zap.Field) string {
+		var out []string
+		for _, f := range fields {
+			out = append(out, f.Key+"="+fmt.Sprint(f.Integer))
+		}
+		return strings.Join(out, ",")
+	}
+
+	parent := WithFields(context.Background(), zap.Int("a", 1), zap.Int("b", 2))
+	parent = WithFields(parent, zap.Int("c", 3))
+	if got := keys(FieldsFromContext(parent)); got != "a=1,b=2,c=3" {
+		t.Fatalf("unexpected parent fields: %s", got)
+	}
+
+	// Resolved on top of the cached parent: equal overrides keep their
+	// position, changed ones move to the end, and the last duplicate wins.
+	ctx := WithFields(parent, zap.Int("a", 1), zap.Int("b", 20), zap.Int("d", 4), zap.Int("d", 40))
+	ctx = WithFields(ctx, zap.Int("c", 30))
+	if got := keys(FieldsFromContext(ctx)); got != "a=1,b=20,d=40,c=30" {
+		t.Errorf("unexpected fields: %s", got)
+	}
+	for _, tt := range []struct {
+		existing, added []zap.Field
+	}{
+		{[]zap.Field{zap.Int("a", 1), zap.Int("b", 2)}, []zap.Field{zap.Int("b", 3), zap.Int("c", 4)}},
+		{[]zap.Field{zap.Int("a", 1)}, []zap.Field{zap.Int("a", 1), zap.Int("b", 2)}},
+	} {
+		got := keys(FieldsFromContext(WithFields(WithFields(context.Background(), tt.existing...), tt.added...)))
+		if want := keys(MergeFields(tt.existing, tt.added)); got != want {
+			t.Errorf("expected MergeFields order %s, got %s", want, got)
+		}
+	}
+}
+
+func TestNewDevelopment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.json")
+
+	logger, err := NewDevelopment(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+	logger.Info(ctx, "hello")
+	// Close closes the file, so later entries no longer reach it.
+	_ = logger.Close()
+	logger.Info(ctx, "after close")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("expected a JSON entry, got %q: %v", data, err)
+	}
+	if entry["msg"] != "hello" || entry["request_id"] != "123" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+	if caller, _ := entry["caller"].(string); !strings.Contains(caller, "/ctxzap_test.go:") {
+		t.Errorf("expected caller to point at the test, got %q", caller)
+	}
+}
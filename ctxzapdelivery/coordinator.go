@@ -0,0 +1,220 @@
+// Package ctxzapdelivery provides at-least-once delivery of encoded log
+// entries to sinks that acknowledge durable writes, for audit and analytics
+// destinations where losing entries is unacceptable.
+package ctxzapdelivery
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// ErrTooManyPending is returned by Write when the number of
+	// unacknowledged entries reaches the configured limit.
+	ErrTooManyPending = errors.New("ctxzapdelivery: too many unacknowledged entries")
+	// ErrUnacknowledged is returned by Sync and Close when entries are still
+	// unacknowledged after the sync timeout.
+	ErrUnacknowledged = errors.New("ctxzapdelivery: entries not acknowledged before timeout")
+	// ErrClosed is returned by Write after Close.
+	ErrClosed = errors.New("ctxzapdelivery: coordinator closed")
+)
+
+// Envelope is an encoded entry with a delivery ID. The same ID is reused
+// when an entry is redelivered, so sinks can deduplicate.
+type Envelope struct {
+	ID   uint64
+	Data []byte
+}
+
+// AckingSink writes entries and acknowledges those that are durably stored.
+type AckingSink interface {
+	// Deliver sends env. The sink calls ack with env.ID once the entry is
+	// durably written, possibly asynchronously. An error or a missing
+	// acknowledgment causes the entry to be redelivered.
+	Deliver(env Envelope, ack func(id uint64)) error
+}
+
+// Options configures a Coordinator.
+type Options struct {
+	// RetryInterval is how long an entry may stay unacknowledged before it
+	// is redelivered. Defaults to 5 seconds.
+	RetryInterval time.Duration
+	// MaxPending bounds the number of unacknowledged entries. Zero means no
+	// limit.
+	MaxPending int
+	// SyncTimeout bounds how long Sync waits for acknowledgments. Defaults
+	// to 30 seconds.
+	SyncTimeout time.Duration
+}
+
+// Coordinator is a zapcore.WriteSyncer delivering entries to an AckingSink
+// with at-least-once semantics: every written entry is redelivered until it
+// is acknowledged, and Sync waits until all entries are acknowledged.
+type Coordinator struct {
+	sink AckingSink
+	opts Options
+
+	mu      sync.Mutex
+	pending map[uint64]*pendingEntry
+	nextID  uint64
+	drained chan struct{}
+	closed  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type pendingEntry struct {
+	data   []byte
+	sentAt time.Time
+}
+
+var _ zapcore.WriteSyncer = (*Coordinator)(nil)
+
+// NewCoordinator creates a Coordinator and starts its redelivery loop. Call
+// Close to stop it.
+func NewCoordinator(sink AckingSink, opts Options) *Coordinator {
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 5 * time.Second
+	}
+	if opts.SyncTimeout <= 0 {
+		opts.SyncTimeout = 30 * time.Second
+	}
+
+	c := &Coordinator{
+		sink:    sink,
+		opts:    opts,
+		pending: make(map[uint64]*pendingEntry),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go c.loop()
+	return c
+}
+
+// Write assigns p a delivery ID and delivers it. A delivery error is not
+// returned: the entry stays pending and is retried.
+func (c *Coordinator) Write(p []byte) (int, error) {
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return 0, ErrClosed
+	}
+	if c.opts.MaxPending > 0 && len(c.pending) >= c.opts.MaxPending {
+		c.mu.Unlock()
+		return 0, ErrTooManyPending
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = &pendingEntry{data: data, sentAt: time.Now()}
+	c.mu.Unlock()
+
+	_ = c.sink.Deliver(Envelope{ID: id, Data: data}, c.ack)
+	return len(p), nil
+}
+
+// Sync waits until every written entry is acknowledged, up to SyncTimeout.
+func (c *Coordinator) Sync() error {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	if c.drained == nil {
+		c.drained = make(chan struct{})
+	}
+	drained := c.drained
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.opts.SyncTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		return nil
+	case <-timer.C:
+		return ErrUnacknowledged
+	}
+}
+
+// Close stops accepting entries, waits for pending ones like Sync, then stops
+// the redelivery loop.
+func (c *Coordinator) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	err := c.Sync()
+	close(c.stop)
+	<-c.done
+	return err
+}
+
+// Pending returns the number of unacknowledged entries.
+func (c *Coordinator) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+func (c *Coordinator) ack(id uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.pending, id)
+	if len(c.pending) == 0 && c.drained != nil {
+		close(c.drained)
+		c.drained = nil
+	}
+}
+
+func (c *Coordinator) loop() {
+	defer close(c.done)
+
+	// Half of a 1ns RetryInterval is 0, which time.NewTicker rejects.
+	ticker := time.NewTicker(max(c.opts.RetryInterval/2, minRedeliveryTick))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.redeliver(now)
+		}
+	}
+}
+
+// minRedeliveryTick bounds how often the redelivery loop runs for tiny
+// retry intervals.
+const minRedeliveryTick = time.Millisecond
+
+// redeliver resends entries unacknowledged for longer than RetryInterval, in
+// ID order.
+func (c *Coordinator) redeliver(now time.Time) {
+	c.mu.Lock()
+	var due []Envelope
+	for id, p := range c.pending {
+		if now.Sub(p.sentAt) >= c.opts.RetryInterval {
+			p.sentAt = now
+			due = append(due, Envelope{ID: id, Data: p.data})
+		}
+	}
+	c.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	for _, env := range due {
+		_ = c.sink.Deliver(env, c.ack)
+	}
+}
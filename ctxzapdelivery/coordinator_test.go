@@ -0,0 +1,88 @@
+package ctxzapdelivery
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lossySink fails the first delivery of every entry and acknowledges
+// redeliveries.
+type lossySink struct {
+	mu        sync.Mutex
+	attempts  map[uint64]int
+	delivered []string
+}
+
+func (s *lossySink) Deliver(env Envelope, ack func(uint64)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts[env.ID]++
+	if s.attempts[env.ID] == 1 {
+		return errors.New("transient failure")
+	}
+	s.delivered = append(s.delivered, string(env.Data))
+	go ack(env.ID)
+	return nil
+}
+
+func TestCoordinatorRedelivers(t *testing.T) {
+	sink := &lossySink{attempts: make(map[uint64]int)}
+	c := NewCoordinator(sink, Options{RetryInterval: 10 * time.Millisecond, SyncTimeout: time.Second})
+
+	for _, msg := range []string{"a", "b"} {
+		if _, err := c.Write([]byte(msg)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.delivered) != 2 || sink.delivered[0] != "a" || sink.delivered[1] != "b" {
+		t.Errorf("expected both entries delivered in order, got %v", sink.delivered)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("expected no pending entries, got %d", c.Pending())
+	}
+}
+
+func TestCoordinatorTinyRetryInterval(t *testing.T) {
+	sink := &lossySink{attempts: make(map[uint64]int)}
+	c := NewCoordinator(sink, Options{RetryInterval: time.Nanosecond, SyncTimeout: time.Second})
+
+	if _, err := c.Write([]byte("a")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("expected no pending entries, got %d", c.Pending())
+	}
+}
+
+// silentSink never acknowledges.
+type silentSink struct{}
+
+func (silentSink) Deliver(Envelope, func(uint64)) error { return nil }
+
+func TestCoordinatorLimits(t *testing.T) {
+	c := NewCoordinator(silentSink{}, Options{MaxPending: 1, SyncTimeout: 20 * time.Millisecond})
+	defer func() { _ = c.Close() }()
+
+	if _, err := c.Write([]byte("a")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := c.Write([]byte("b")); !errors.Is(err, ErrTooManyPending) {
+		t.Errorf("expected ErrTooManyPending, got %v", err)
+	}
+	if err := c.Sync(); !errors.Is(err, ErrUnacknowledged) {
+		t.Errorf("expected ErrUnacknowledged, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Package ctxzapfault injects failures into log destinations, so that tests
+// of buffering, spilling and retrying writers can cover realistic outages:
+// random write errors, partial writes, latency and hard outages.
+package ctxzapfault
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrInjected is returned by writes and syncs failed on purpose.
+var ErrInjected = errors.New("ctxzapfault: injected failure")
+
+// Options configures the faults injected by a Writer. Rates are
+// probabilities between 0 and 1, evaluated independently for every call.
+type Options struct {
+	// ErrorRate is the probability that a write fails without writing
+	// anything.
+	ErrorRate float64
+	// PartialRate is the probability that a write only writes a random
+	// prefix of its input and returns io.ErrShortWrite.
+	PartialRate float64
+	// SyncErrorRate is the probability that a sync fails.
+	SyncErrorRate float64
+	// Latency is added to every write, plus a random delay of up to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+	// Seed seeds the random source, so that a failing test can be replayed.
+	Seed uint64
+}
+
+// Stats counts the calls handled by a Writer.
+type Stats struct {
+	Writes  uint64
+	Errors  uint64
+	Partial uint64
+}
+
+// Writer is a zapcore.WriteSyncer that forwards to another one while
+// injecting faults.
+type Writer struct {
+	ws   zapcore.WriteSyncer
+	opts Options
+
+	mu    sync.Mutex
+	rand  *rand.Rand
+	down  bool
+	stats Stats
+}
+
+var _ zapcore.WriteSyncer = (*Writer)(nil)
+
+// New creates a Writer injecting faults described by opts into ws.
+func New(ws zapcore.WriteSyncer, opts Options) *Writer {
+	return &Writer{
+		ws:   ws,
+		opts: opts,
+		rand: rand.New(rand.NewPCG(opts.Seed, opts.Seed)), //nolint:gosec // reproducible test randomness
+	}
+}
+
+// SetDown starts (true) or ends (false) a hard outage, during which every
+// write and sync fails regardless of the configured rates.
+func (w *Writer) SetDown(down bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.down = down
+}
+
+// Stats returns the number of writes and injected faults so far.
+func (w *Writer) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// Write forwards p after the configured latency, unless a fault is
+// injected.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.stats.Writes++
+	delay := w.opts.Latency
+	if w.opts.Jitter > 0 {
+		delay += time.Duration(w.rand.Int64N(int64(w.opts.Jitter)))
+	}
+	fail := w.down || w.chance(w.opts.ErrorRate)
+	partial := !fail && len(p) > 1 && w.chance(w.opts.PartialRate)
+	n := len(p)
+	switch {
+	case fail:
+		w.stats.Errors++
+	case partial:
+		w.stats.Partial++
+		n = 1 + w.rand.IntN(len(p)-1)
+	}
+	w.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if fail {
+		return 0, ErrInjected
+	}
+	written, err := w.ws.Write(p[:n])
+	if err == nil && partial {
+		err = io.ErrShortWrite
+	}
+	return written, err
+}
+
+// Sync syncs the wrapped destination, unless a fault is injected.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	fail := w.down || w.chance(w.opts.SyncErrorRate)
+	if fail {
+		w.stats.Errors++
+	}
+	w.mu.Unlock()
+
+	if fail {
+		return ErrInjected
+	}
+	return w.ws.Sync()
+}
+
+func (w *Writer) chance(rate float64) bool {
+	return rate > 0 && w.rand.Float64() < rate
+}
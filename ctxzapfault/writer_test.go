@@ -0,0 +1,103 @@
+package ctxzapfault
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWriterInjectsErrors(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(zapcore.AddSync(&buf), Options{ErrorRate: 0.5, Seed: 1})
+
+	var failed int
+	for i := 0; i < 1000; i++ {
+		n, err := w.Write([]byte("x"))
+		switch {
+		case errors.Is(err, ErrInjected):
+			failed++
+			if n != 0 {
+				t.Fatalf("expected nothing written on failure, got %d", n)
+			}
+		case err != nil:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if failed < 400 || failed > 600 {
+		t.Errorf("expected about half the writes to fail, got %d", failed)
+	}
+	if buf.Len() != 1000-failed {
+		t.Errorf("expected %d bytes written, got %d", 1000-failed, buf.Len())
+	}
+	if stats := w.Stats(); stats.Writes != 1000 || stats.Errors != uint64(failed) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestWriterPartialWrites(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(zapcore.AddSync(&buf), Options{PartialRate: 1, Seed: 1})
+
+	n, err := w.Write([]byte("hello world"))
+	if !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("expected a short write, got %v", err)
+	}
+	if n < 1 || n >= len("hello world") || buf.String() != "hello world"[:n] {
+		t.Errorf("expected a strict prefix to be written, got %d bytes %q", n, buf.String())
+	}
+}
+
+func TestWriterOutage(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(zapcore.AddSync(&buf), Options{})
+
+	w.SetDown(true)
+	if _, err := w.Write([]byte("lost")); !errors.Is(err, ErrInjected) {
+		t.Errorf("expected write to fail during outage, got %v", err)
+	}
+	if err := w.Sync(); !errors.Is(err, ErrInjected) {
+		t.Errorf("expected sync to fail during outage, got %v", err)
+	}
+
+	w.SetDown(false)
+	if _, err := w.Write([]byte("kept")); err != nil {
+		t.Errorf("unexpected error after outage: %v", err)
+	}
+	if buf.String() != "kept" {
+		t.Errorf("expected only the write after the outage, got %q", buf.String())
+	}
+}
+
+func TestWriterLatency(t *testing.T) {
+	w := New(zapcore.AddSync(io.Discard), Options{Latency: 10 * time.Millisecond})
+
+	start := time.Now()
+	_, _ = w.Write([]byte("x"))
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("expected at least 10ms of latency, got %v", elapsed)
+	}
+}
+
+func TestWriterIsReproducible(t *testing.T) {
+	run := func() []bool {
+		w := New(zapcore.AddSync(io.Discard), Options{ErrorRate: 0.5, Seed: 42})
+		results := make([]bool, 20)
+		for i := range results {
+			_, err := w.Write([]byte("x"))
+			results[i] = err != nil
+		}
+		return results
+	}
+
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatal("expected the same seed to inject the same faults")
+		}
+	}
+}
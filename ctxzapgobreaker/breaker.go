@@ -0,0 +1,80 @@
+// Package ctxzapgobreaker logs the state transitions and rejections of
+// github.com/sony/gobreaker circuit breakers.
+package ctxzapgobreaker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+	"go.uber.org/zap"
+
+	"github.com/algobardo/ctxzap"
+	"github.com/algobardo/ctxzap/internal/rejectlog"
+)
+
+// BreakerKey is the key of the field naming the circuit breaker.
+const BreakerKey = "breaker"
+
+// LogStateChanges returns st with an OnStateChange hook logging every
+// transition with logger, at Warn level when the breaker opens and at Info
+// level otherwise. An existing hook is still called.
+func LogStateChanges(logger *ctxzap.Logger, st gobreaker.Settings) gobreaker.Settings {
+	next := st.OnStateChange
+	st.OnStateChange = func(name string, from, to gobreaker.State) {
+		log := logger.Info
+		if to == gobreaker.StateOpen {
+			log = logger.Warn
+		}
+		log(context.Background(), "circuit breaker state changed",
+			zap.String(BreakerKey, name),
+			zap.Stringer("from", from),
+			zap.Stringer("to", to),
+		)
+		if next != nil {
+			next(name, from, to)
+		}
+	}
+	return st
+}
+
+// Breaker wraps a circuit breaker to log the calls it rejects, with the
+// context fields of the rejected call. Rejections are summarized: the first
+// one is logged immediately, then at most one entry per interval reports
+// how many calls were rejected since the previous one.
+type Breaker[T any] struct {
+	cb         *gobreaker.CircuitBreaker[T]
+	rejections *rejectlog.Summarizer
+}
+
+// New wraps cb, logging rejections with logger at most once per interval.
+func New[T any](cb *gobreaker.CircuitBreaker[T], logger *ctxzap.Logger, interval time.Duration) *Breaker[T] {
+	return &Breaker[T]{
+		cb:         cb,
+		rejections: rejectlog.New(logger, "circuit breaker rejected calls", interval),
+	}
+}
+
+// Execute runs req through the circuit breaker.
+func (b *Breaker[T]) Execute(ctx context.Context, req func() (T, error)) (T, error) {
+	result, err := b.cb.Execute(req)
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		b.rejections.Reject(ctx,
+			zap.String(BreakerKey, b.cb.Name()),
+			zap.Stringer("state", b.cb.State()),
+		)
+	}
+	return result, err
+}
+
+// Sync logs the rejections not reported yet, if any, then flushes the
+// logger. Call it before shutting down.
+func (b *Breaker[T]) Sync() error {
+	return b.rejections.Sync()
+}
+
+// CircuitBreaker returns the wrapped circuit breaker.
+func (b *Breaker[T]) CircuitBreaker() *gobreaker.CircuitBreaker[T] {
+	return b.cb
+}
@@ -0,0 +1,53 @@
+package ctxzapgobreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/algobardo/ctxzap"
+)
+
+func TestBreaker(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := ctxzap.New(zap.New(core))
+
+	hooked := false
+	st := LogStateChanges(logger, gobreaker.Settings{
+		Name:          "payments",
+		ReadyToTrip:   func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 1 },
+		OnStateChange: func(string, gobreaker.State, gobreaker.State) { hooked = true },
+	})
+	b := New(gobreaker.NewCircuitBreaker[int](st), logger, time.Hour)
+
+	ctx := ctxzap.WithFields(context.Background(), zap.String("request_id", "req-1"))
+	failing := func() (int, error) { return 0, errors.New("unavailable") }
+	for i := 0; i < 3; i++ {
+		_, _ = b.Execute(ctx, failing)
+	}
+
+	if !hooked {
+		t.Error("expected the existing OnStateChange hook to be called")
+	}
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	transition := entries[0]
+	if transition.Level != zapcore.WarnLevel || transition.ContextMap()["to"] != "open" {
+		t.Errorf("unexpected transition entry: %v %v", transition.Level, transition.ContextMap())
+	}
+
+	rejected := entries[1].ContextMap()
+	if rejected[BreakerKey] != "payments" || rejected["request_id"] != "req-1" || rejected["rejected"] != int64(1) {
+		t.Errorf("unexpected rejection entry: %v", rejected)
+	}
+}
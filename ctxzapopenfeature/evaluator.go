@@ -0,0 +1,41 @@
+// Package ctxzapopenfeature implements ctxzap.FlagEvaluator on top of the
+// OpenFeature Go SDK.
+package ctxzapopenfeature
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/algobardo/ctxzap"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// Evaluator resolves flag variants through an OpenFeature client. The
+// evaluation context is taken from the client, the global API and the
+// transaction context stored in ctx, as OpenFeature does for any evaluation.
+type Evaluator struct {
+	client *openfeature.Client
+}
+
+var _ ctxzap.FlagEvaluator = (*Evaluator)(nil)
+
+// NewEvaluator creates an Evaluator backed by client.
+func NewEvaluator(client *openfeature.Client) *Evaluator {
+	return &Evaluator{client: client}
+}
+
+// FlagVariant returns the variant name reported by the provider. Providers
+// that do not report variants fall back to the resolved value.
+func (e *Evaluator) FlagVariant(ctx context.Context, flag string) (string, bool) {
+	details, err := e.client.ObjectValueDetails(ctx, flag, nil, openfeature.EvaluationContext{})
+	if err != nil {
+		return "", false
+	}
+	if details.Variant != "" {
+		return details.Variant, true
+	}
+	if details.Value == nil {
+		return "", false
+	}
+	return fmt.Sprint(details.Value), true
+}
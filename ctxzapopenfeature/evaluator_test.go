@@ -0,0 +1,46 @@
+package ctxzapopenfeature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/algobardo/ctxzap"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/open-feature/go-sdk/openfeature/memprovider"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEvaluator(t *testing.T) {
+	provider := memprovider.NewInMemoryProvider(map[string]memprovider.InMemoryFlag{
+		"checkout_v2": {
+			Key:            "checkout_v2",
+			State:          memprovider.Enabled,
+			DefaultVariant: "treatment",
+			Variants:       map[string]any{"control": false, "treatment": true},
+		},
+	})
+	if err := openfeature.SetNamedProviderAndWait("ctxzap-test", provider); err != nil {
+		t.Fatalf("failed to set provider: %v", err)
+	}
+
+	evaluator := NewEvaluator(openfeature.NewClient("ctxzap-test"))
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := ctxzap.New(zap.New(core))
+
+	ctx := ctxzap.WithFlags(context.Background(), evaluator, "checkout_v2", "missing_flag")
+	logger.Info(ctx, "request handled")
+
+	flags, ok := observed.All()[0].ContextMap()[ctxzap.FlagsKey].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected feature_flags object")
+	}
+	if flags["checkout_v2"] != "treatment" {
+		t.Errorf("expected checkout_v2=treatment, got %v", flags["checkout_v2"])
+	}
+	if _, ok := flags["missing_flag"]; ok {
+		t.Error("expected unresolved flag to be omitted")
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/algobardo/ctxzap/ctxzapopenfeature
+
+go 1.24.5
+
+require (
+	github.com/algobardo/ctxzap v0.1.0
+	github.com/open-feature/go-sdk v1.16.0
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.3 // indirect
+	go.uber.org/mock v0.6.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+)
@@ -0,0 +1,11 @@
+module github.com/algobardo/ctxzap/ctxzaprate
+
+go 1.24.5
+
+require (
+	github.com/algobardo/ctxzap v0.1.0
+	go.uber.org/zap v1.27.0
+	golang.org/x/time v0.14.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
@@ -0,0 +1,72 @@
+// Package ctxzaprate logs the requests rejected by golang.org/x/time/rate
+// limiters.
+package ctxzaprate
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/algobardo/ctxzap"
+	"github.com/algobardo/ctxzap/internal/rejectlog"
+)
+
+// LimiterKey is the key of the field naming the limiter.
+const LimiterKey = "limiter"
+
+// Limiter wraps a rate.Limiter to log the requests it rejects, with the
+// context fields of the rejected request. Rejections are summarized: the
+// first one is logged immediately, then at most one entry per interval
+// reports how many requests were rejected since the previous one.
+type Limiter struct {
+	*rate.Limiter
+
+	name       string
+	rejections *rejectlog.Summarizer
+}
+
+// New wraps limiter, logging its rejections with logger at most once per
+// interval. name identifies the limiter in the entries.
+func New(limiter *rate.Limiter, name string, logger *ctxzap.Logger, interval time.Duration) *Limiter {
+	return &Limiter{
+		Limiter:    limiter,
+		name:       name,
+		rejections: rejectlog.New(logger, "rate limit exceeded", interval),
+	}
+}
+
+// AllowCtx reports whether a request may happen now, logging a rejection if
+// not.
+func (l *Limiter) AllowCtx(ctx context.Context) bool {
+	if l.Allow() {
+		return true
+	}
+	l.reject(ctx)
+	return false
+}
+
+// WaitCtx blocks until a request may happen, like rate.Limiter.Wait. A
+// request that cannot be admitted before ctx is done is logged as rejected.
+func (l *Limiter) WaitCtx(ctx context.Context) error {
+	err := l.Wait(ctx)
+	if err != nil {
+		l.reject(ctx, zap.Error(err))
+	}
+	return err
+}
+
+// Sync logs the rejections not reported yet, if any, then flushes the
+// logger. Call it before shutting down.
+func (l *Limiter) Sync() error {
+	return l.rejections.Sync()
+}
+
+func (l *Limiter) reject(ctx context.Context, fields ...zap.Field) {
+	l.rejections.Reject(ctx, append([]zap.Field{
+		zap.String(LimiterKey, l.name),
+		zap.Float64("limit", float64(l.Limit())),
+		zap.Int("burst", l.Burst()),
+	}, fields...)...)
+}
@@ -0,0 +1,52 @@
+package ctxzaprate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/time/rate"
+
+	"github.com/algobardo/ctxzap"
+)
+
+func TestLimiter(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	l := New(rate.NewLimiter(rate.Every(time.Hour), 1), "api", ctxzap.New(zap.New(core)), time.Hour)
+
+	ctx := ctxzap.WithFields(context.Background(), zap.String("tenant", "acme"))
+	if !l.AllowCtx(ctx) {
+		t.Fatal("expected the first request to be allowed")
+	}
+	for i := 0; i < 3; i++ {
+		if l.AllowCtx(ctx) {
+			t.Fatal("expected further requests to be rejected")
+		}
+	}
+
+	entries := observed.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected rejections to be summarized in 1 entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields[LimiterKey] != "api" || fields["tenant"] != "acme" || fields["burst"] != int64(1) {
+		t.Errorf("unexpected entry: %v", fields)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	if err := l.WaitCtx(waitCtx); err == nil {
+		t.Error("expected Wait to fail before the next token")
+	}
+
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	entries = observed.All()
+	if len(entries) != 2 || entries[1].ContextMap()["rejected"] != int64(3) {
+		t.Errorf("expected Sync to log the 3 pending rejections, got %d entries", len(entries))
+	}
+}
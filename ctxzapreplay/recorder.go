@@ -0,0 +1,156 @@
+// Package ctxzapreplay records log entries, including context fields, with
+// their field types intact, and replays them through any zapcore.Core. This
+// lets production incidents be re-rendered locally with development
+// formatting.
+package ctxzapreplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/algobardo/ctxzap/internal/fieldcodec"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxRecordSize bounds the size of a single recorded entry when replaying.
+const maxRecordSize = 16 << 20
+
+// record is the on-disk form of an entry, one JSON object per line.
+type record struct {
+	Time     time.Time          `json:"time"`
+	Level    zapcore.Level      `json:"level"`
+	Logger   string             `json:"logger,omitempty"`
+	Message  string             `json:"msg"`
+	Caller   string             `json:"caller,omitempty"`
+	Line     int                `json:"line,omitempty"`
+	Function string             `json:"function,omitempty"`
+	Stack    string             `json:"stack,omitempty"`
+	Fields   []fieldcodec.Field `json:"fields,omitempty"`
+}
+
+// recorder is a zapcore.Core writing records to a shared writer.
+type recorder struct {
+	zapcore.LevelEnabler
+
+	out    *output
+	fields []zap.Field
+}
+
+type output struct {
+	mu sync.Mutex
+	w  zapcore.WriteSyncer
+}
+
+// NewRecorder returns a core that records every enabled entry to w. Combine
+// it with the regular core using zapcore.NewTee to record alongside normal
+// output.
+func NewRecorder(w zapcore.WriteSyncer, enab zapcore.LevelEnabler) zapcore.Core {
+	return &recorder{LevelEnabler: enab, out: &output{w: w}}
+}
+
+func (r *recorder) With(fields []zap.Field) zapcore.Core {
+	clone := *r
+	clone.fields = make([]zap.Field, 0, len(r.fields)+len(fields))
+	clone.fields = append(clone.fields, r.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (r *recorder) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if r.Enabled(entry.Level) {
+		return ce.AddCore(entry, r)
+	}
+	return ce
+}
+
+func (r *recorder) Write(entry zapcore.Entry, fields []zap.Field) error {
+	all := fields
+	if len(r.fields) > 0 {
+		all = make([]zap.Field, 0, len(r.fields)+len(fields))
+		all = append(all, r.fields...)
+		all = append(all, fields...)
+	}
+
+	wire, err := fieldcodec.ToWire(all)
+	if err != nil {
+		return err
+	}
+
+	rec := record{
+		Time:     entry.Time,
+		Level:    entry.Level,
+		Logger:   entry.LoggerName,
+		Message:  entry.Message,
+		Function: entry.Caller.Function,
+		Stack:    entry.Stack,
+		Fields:   wire,
+	}
+	if entry.Caller.Defined {
+		rec.Caller = entry.Caller.File
+		rec.Line = entry.Caller.Line
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	_, err = r.out.w.Write(data)
+	return err
+}
+
+func (r *recorder) Sync() error {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	return r.out.w.Sync()
+}
+
+// Replay re-emits every entry recorded in src through core. Entries go
+// through core.Check, so the core's level and sampling still apply; fatal
+// and panic entries are written without exiting or panicking.
+func Replay(src io.Reader, core zapcore.Core) error {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return err
+		}
+
+		fields, err := fieldcodec.FromWire(rec.Fields)
+		if err != nil {
+			return err
+		}
+
+		entry := zapcore.Entry{
+			Level:      rec.Level,
+			Time:       rec.Time,
+			LoggerName: rec.Logger,
+			Message:    rec.Message,
+			Stack:      rec.Stack,
+		}
+		if rec.Caller != "" {
+			entry.Caller = zapcore.EntryCaller{Defined: true, File: rec.Caller, Line: rec.Line, Function: rec.Function}
+		}
+
+		if ce := core.Check(entry, nil); ce != nil {
+			ce.Write(fields...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return core.Sync()
+}
@@ -0,0 +1,50 @@
+package ctxzapreplay
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/algobardo/ctxzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder(zapcore.AddSync(&buf), zapcore.DebugLevel)
+
+	logger := ctxzap.New(zap.New(recorder, zap.AddCaller())).With(zap.String("service", "api"))
+	ctx := ctxzap.WithFields(context.Background(), zap.String("request_id", "abc"))
+	logger.Info(ctx, "handled", zap.Duration("elapsed", 250*time.Millisecond))
+	logger.Debug(ctx, "detail")
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	if err := Replay(&buf, core); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	entries := observed.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry at info level, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Message != "handled" || !entry.Caller.Defined {
+		t.Errorf("unexpected entry: %+v", entry.Entry)
+	}
+
+	fields := entry.ContextMap()
+	expected := map[string]interface{}{
+		"service":    "api",
+		"request_id": "abc",
+		"elapsed":    250 * time.Millisecond,
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("field %q: expected %v, got %v", k, v, fields[k])
+		}
+	}
+}
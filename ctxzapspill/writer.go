@@ -0,0 +1,270 @@
+// Package ctxzapspill protects a log destination against short outages by
+// spilling entries to a bounded on-disk buffer while the destination fails,
+// and replaying them in order once it recovers.
+package ctxzapspill
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Writer is a zapcore.WriteSyncer that forwards writes to a primary
+// destination (typically a network sink) and spills them to a file when the
+// destination returns an error.
+//
+// Spilled entries are replayed, oldest first, before any new entry is
+// forwarded, so ordering is preserved. JSON entries are tagged with a
+// "spill_id" field unique per process and entry, which lets downstream
+// systems discard duplicates if the destination accepted an entry it also
+// reported as failed, or if a crash interrupted a replay. When the spill
+// file reaches its size limit, new entries are dropped and counted. If the
+// destination writes only part of an entry, the rest is spilled and
+// replayed, so that the entry is completed.
+//
+// After a failure, writes go straight to the spill file for a retry
+// interval, doubling from 100ms up to 30s while the destination keeps
+// failing, rather than retrying the destination on every write. Sync
+// retries immediately.
+type Writer struct {
+	mu      sync.Mutex
+	primary zapcore.WriteSyncer
+	path    string
+	file    *os.File
+	// The file is only appended to; the bytes before head have been
+	// replayed, those from head to size are pending.
+	head     int64
+	size     int64
+	maxBytes int64
+	prefix   string
+	seq      uint64
+	dropped  uint64
+
+	now     func() time.Time
+	backoff time.Duration
+	retryAt time.Time
+}
+
+var _ zapcore.WriteSyncer = (*Writer)(nil)
+
+const (
+	minRetryInterval = 100 * time.Millisecond
+	maxRetryInterval = 30 * time.Second
+)
+
+// New creates a Writer spilling to the file at path, which is limited to
+// maxBytes. Entries left in the file by a previous process are replayed on
+// the first successful write.
+func New(primary zapcore.WriteSyncer, path string, maxBytes int64) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600) //nolint:gosec // path is chosen by the application
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	token := make([]byte, 4)
+	if _, err := rand.Read(token); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &Writer{
+		primary:  primary,
+		path:     path,
+		file:     file,
+		size:     info.Size(),
+		maxBytes: maxBytes,
+		prefix:   hex.EncodeToString(token) + "-",
+		now:      time.Now,
+	}, nil
+}
+
+// Write forwards p to the primary destination, replaying any spilled entries
+// first. If the destination fails, p is spilled instead and no error is
+// returned unless the spill file itself cannot be written.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.pending() > 0 {
+		if w.now().Before(w.retryAt) {
+			return w.spill(p)
+		}
+		if err := w.drain(); err != nil {
+			return w.spill(p)
+		}
+	}
+
+	n, err := w.primary.Write(p)
+	if err != nil {
+		w.failed()
+		if n > 0 {
+			// The destination holds the start of the entry: spill the rest
+			// as is, to be appended to it.
+			return len(p), w.append(p[n:])
+		}
+		return w.spill(p)
+	}
+	return len(p), nil
+}
+
+// Sync replays spilled entries if possible, then syncs the primary
+// destination and the spill file.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var drainErr error
+	if w.pending() > 0 {
+		drainErr = w.drain()
+	}
+	return errors.Join(drainErr, w.primary.Sync(), w.file.Sync())
+}
+
+// Close closes the spill file. Entries still spilled remain on disk and are
+// replayed by the next Writer opened on the same path.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// Stats reports the bytes currently spilled and the number of entries
+// dropped because the spill file was full.
+func (w *Writer) Stats() (spilledBytes int64, dropped uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pending(), w.dropped
+}
+
+func (w *Writer) pending() int64 {
+	return w.size - w.head
+}
+
+// failed delays the next replay attempt after a failure of the destination.
+func (w *Writer) failed() {
+	w.backoff = min(max(2*w.backoff, minRetryInterval), maxRetryInterval)
+	w.retryAt = w.now().Add(w.backoff)
+}
+
+func (w *Writer) spill(p []byte) (int, error) {
+	w.seq++
+	entry := w.mark(p, w.prefix+strconv.FormatUint(w.seq, 10))
+
+	if w.pending()+int64(len(entry)) > w.maxBytes {
+		w.dropped++
+		return len(p), nil
+	}
+	if err := w.append(entry); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// append adds data to the pending entries, reclaiming the space of the
+// replayed ones first if the file would exceed its size limit.
+func (w *Writer) append(data []byte) error {
+	if w.head > 0 && w.size+int64(len(data)) > w.maxBytes {
+		if err := w.compact(); err != nil {
+			return err
+		}
+	}
+	if _, err := w.file.WriteAt(data, w.size); err != nil {
+		return err
+	}
+	w.size += int64(len(data))
+	return nil
+}
+
+// mark adds a spill_id field to JSON object entries; other entries are
+// spilled unchanged.
+func (w *Writer) mark(p []byte, id string) []byte {
+	trimmed := bytes.TrimLeft(p, " \t")
+	if len(trimmed) < 2 || trimmed[0] != '{' {
+		return p
+	}
+
+	marked := make([]byte, 0, len(p)+len(id)+16)
+	marked = append(marked, `{"spill_id":"`...)
+	marked = append(marked, id...)
+	marked = append(marked, '"')
+	if rest := bytes.TrimLeft(trimmed[1:], " \t"); len(rest) > 0 && rest[0] != '}' {
+		marked = append(marked, ',')
+	}
+	return append(marked, trimmed[1:]...)
+}
+
+// drain replays the pending entries in order, line by line. On failure, the
+// bytes the destination accepted, even part of a line, are not replayed
+// again.
+func (w *Writer) drain() error {
+	r := bufio.NewReader(io.NewSectionReader(w.file, w.head, w.pending()))
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			n, werr := w.primary.Write(line)
+			w.head += int64(n)
+			if werr != nil {
+				w.failed()
+				if w.head >= w.size/2 {
+					return errors.Join(werr, w.compact())
+				}
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	w.head, w.size = 0, 0
+	w.backoff, w.retryAt = 0, time.Time{}
+	return w.file.Truncate(0)
+}
+
+// compact copies the pending entries to a new file and renames it over the
+// spill file, so that a crash leaves either file complete. It is only
+// needed once the replayed entries take up at least half of the file, which
+// bounds the copying to the size of the replayed entries.
+func (w *Writer) compact() error {
+	tmpPath := w.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o600) //nolint:gosec // path is chosen by the application
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, io.NewSectionReader(w.file, w.head, w.pending()))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, w.path)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	_ = w.file.Close()
+	w.file = tmp
+	w.size -= w.head
+	w.head = 0
+	return nil
+}
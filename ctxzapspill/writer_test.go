@@ -0,0 +1,295 @@
+package ctxzapspill
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/algobardo/ctxzap/ctxzapfault"
+)
+
+// flakyWriter fails while down is set.
+type flakyWriter struct {
+	down bool
+	buf  bytes.Buffer
+}
+
+func (f *flakyWriter) Write(p []byte) (int, error) {
+	if f.down {
+		return 0, errors.New("unavailable")
+	}
+	return f.buf.Write(p)
+}
+
+func (f *flakyWriter) Sync() error { return nil }
+
+func TestSpillAndReplay(t *testing.T) {
+	primary := &flakyWriter{}
+	w, err := New(primary, filepath.Join(t.TempDir(), "spill"), 1<<20)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer w.Close()
+
+	write := func(s string) {
+		t.Helper()
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	write(`{"msg":"1"}` + "\n")
+	primary.down = true
+	write(`{"msg":"2"}` + "\n")
+	write(`{"msg":"3"}` + "\n")
+
+	if size, _ := w.Stats(); size == 0 {
+		t.Fatal("expected entries to be spilled")
+	}
+
+	primary.down = false
+	now = now.Add(minRetryInterval)
+	write(`{"msg":"4"}` + "\n")
+
+	lines := strings.Split(strings.TrimSpace(primary.buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d: %q", len(lines), primary.buf.String())
+	}
+	for i, msg := range []string{"1", "2", "3", "4"} {
+		if !strings.Contains(lines[i], `"msg":"`+msg+`"`) {
+			t.Errorf("line %d: expected msg %s, got %q", i, msg, lines[i])
+		}
+	}
+	if !strings.HasPrefix(lines[1], `{"spill_id":"`) || strings.Contains(lines[3], "spill_id") {
+		t.Errorf("expected only spilled entries to be marked: %q", lines)
+	}
+	if size, _ := w.Stats(); size != 0 {
+		t.Errorf("expected spill file to be empty after replay, got %d bytes", size)
+	}
+}
+
+func TestSpillBounded(t *testing.T) {
+	primary := &flakyWriter{down: true}
+	w, err := New(primary, filepath.Join(t.TempDir(), "spill"), 64)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer w.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte(`{"msg":"entry"}` + "\n")); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	size, dropped := w.Stats()
+	if size > 64 {
+		t.Errorf("expected spill file within 64 bytes, got %d", size)
+	}
+	if dropped == 0 {
+		t.Error("expected entries to be dropped once the spill file is full")
+	}
+}
+
+func TestSpillSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+
+	w, err := New(&flakyWriter{down: true}, path, 1<<20)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	_, _ = w.Write([]byte(`{"msg":"before restart"}` + "\n"))
+	_ = w.Close()
+
+	primary := &flakyWriter{}
+	w, err = New(primary, path, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer w.Close()
+
+	if err := w.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if !strings.Contains(primary.buf.String(), "before restart") {
+		t.Errorf("expected spilled entry to be replayed, got %q", primary.buf.String())
+	}
+}
+
+func TestRandomFailuresPreserveOrder(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts ctxzapfault.Options
+	}{
+		{"errors", ctxzapfault.Options{ErrorRate: 0.3, Seed: 7}},
+		{"short writes", ctxzapfault.Options{PartialRate: 0.2, Seed: 7}},
+		{"errors and short writes", ctxzapfault.Options{ErrorRate: 0.2, PartialRate: 0.2, Seed: 7}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			primary := ctxzapfault.New(zapcore.AddSync(&buf), tt.opts)
+			w, err := New(primary, filepath.Join(t.TempDir(), "spill"), 1<<20)
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+			defer w.Close()
+
+			for i := 0; i < 200; i++ {
+				if _, err := w.Write([]byte(fmt.Sprintf(`{"msg":"%d"}`+"\n", i))); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+			for i := 0; i < 10000; i++ {
+				if size, _ := w.Stats(); size == 0 {
+					break
+				}
+				_ = w.Sync()
+			}
+
+			if stats := primary.Stats(); stats.Errors+stats.Partial == 0 {
+				t.Fatal("expected injected failures")
+			}
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 200 {
+				t.Fatalf("expected 200 lines, got %d", len(lines))
+			}
+			for i, line := range lines {
+				if !json.Valid([]byte(line)) || !strings.Contains(line, fmt.Sprintf(`"msg":"%d"`, i)) {
+					t.Fatalf("line %d corrupted or out of order: %q", i, line)
+				}
+			}
+		})
+	}
+}
+
+// shortWriter writes at most limit bytes per call while limit is set.
+type shortWriter struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+func (s *shortWriter) Write(p []byte) (int, error) {
+	if s.limit > 0 && len(p) > s.limit {
+		n, _ := s.buf.Write(p[:s.limit])
+		return n, io.ErrShortWrite
+	}
+	return s.buf.Write(p)
+}
+
+func (s *shortWriter) Sync() error { return nil }
+
+func TestShortWritesAreCompleted(t *testing.T) {
+	primary := &shortWriter{limit: 5}
+	w, err := New(primary, filepath.Join(t.TempDir(), "spill"), 1<<20)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer w.Close()
+
+	_, _ = w.Write([]byte(`{"msg":"first"}` + "\n"))
+	_, _ = w.Write([]byte(`{"msg":"second"}` + "\n"))
+	primary.limit = 0
+	if err := w.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(primary.buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != `{"msg":"first"}` || !strings.HasSuffix(lines[1], `"msg":"second"}`) {
+		t.Errorf("expected both entries complete, got %q", primary.buf.String())
+	}
+}
+
+func TestReplayBacksOff(t *testing.T) {
+	primary := &flakyWriter{down: true}
+	w, err := New(primary, filepath.Join(t.TempDir(), "spill"), 1<<20)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer w.Close()
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	_, _ = w.Write([]byte(`{"msg":"1"}` + "\n"))
+	primary.down = false
+	_, _ = w.Write([]byte(`{"msg":"2"}` + "\n"))
+	if primary.buf.Len() != 0 {
+		t.Fatalf("expected no replay within the retry interval, got %q", primary.buf.String())
+	}
+
+	primary.down = true
+	now = now.Add(minRetryInterval)
+	_, _ = w.Write([]byte(`{"msg":"3"}` + "\n"))
+	if w.backoff != 2*minRetryInterval {
+		t.Errorf("expected the retry interval to double, got %v", w.backoff)
+	}
+
+	primary.down = false
+	now = now.Add(w.backoff)
+	_, _ = w.Write([]byte(`{"msg":"4"}` + "\n"))
+	if got := strings.Count(primary.buf.String(), "\n"); got != 4 || w.backoff != 0 {
+		t.Errorf("expected 4 entries replayed and the interval reset, got %d and %v", got, w.backoff)
+	}
+}
+
+// countingWriter fails after accepting limit writes while limit is set.
+type countingWriter struct {
+	limit, writes int
+	buf           bytes.Buffer
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.limit > 0 && c.writes >= c.limit {
+		return 0, errors.New("unavailable")
+	}
+	c.writes++
+	return c.buf.Write(p)
+}
+
+func (c *countingWriter) Sync() error { return nil }
+
+func TestPartialReplayCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	primary := &countingWriter{limit: 1}
+	w, err := New(primary, path, 1<<20)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer w.Close()
+
+	primary.writes = 1
+	for i := 0; i < 4; i++ {
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"msg":"%d"}`+"\n", i)))
+	}
+	primary.writes = 0
+	_ = w.Sync() // replays one of the 4 entries
+	if w.head == 0 || w.size != 4*w.head {
+		t.Fatalf("expected the file kept while most entries are pending, got head %d of %d", w.head, w.size)
+	}
+	primary.writes = 0
+	primary.limit = 2
+	_ = w.Sync() // replays two more: 3 of 4 replayed
+	if w.head != 0 {
+		t.Errorf("expected the replayed entries to be compacted away, got head %d", w.head)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != w.size {
+		t.Errorf("expected the spill file to hold the pending entry only, got %v, %v", info, err)
+	}
+
+	primary.limit = 0
+	_ = w.Sync()
+	if got := strings.Count(primary.buf.String(), "\n"); got != 4 {
+		t.Errorf("expected every entry replayed once, got %q", primary.buf.String())
+	}
+}
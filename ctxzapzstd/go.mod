@@ -0,0 +1,11 @@
+module github.com/algobardo/ctxzap/ctxzapzstd
+
+go 1.24.5
+
+require (
+	github.com/algobardo/ctxzap v0.1.0
+	github.com/klauspost/compress v1.19.2
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
@@ -0,0 +1,95 @@
+// Package ctxzapzstd provides a zstd-compressed NDJSON sink for very high
+// volume local logging, such as soak tests, and the matching reader used by
+// the ctxzap-cat command.
+package ctxzapzstd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap/zapcore"
+)
+
+// magic is the zstd frame magic number.
+var magic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Writer is a zapcore.WriteSyncer that compresses everything written to it
+// with zstd. Entries are buffered by the encoder and made durable by Sync, so
+// it should be paired with a JSON encoder and synced periodically or on
+// shutdown.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *zstd.Encoder
+}
+
+var _ zapcore.WriteSyncer = (*Writer)(nil)
+
+// Open creates or appends to the file at path. Appending is safe: each
+// session starts a new zstd frame and concatenated frames decode as one
+// stream.
+func Open(path string, opts ...zstd.EOption) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644) //nolint:gosec // log files are meant to be readable
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := zstd.NewWriter(file, opts...)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &Writer{file: file, enc: enc}, nil
+}
+
+// Write compresses p into the current frame.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Write(p)
+}
+
+// Sync flushes buffered data to the file and syncs it to disk.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close finishes the current frame and closes the file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Close(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// NewReader returns a reader that transparently decompresses r if it starts
+// with a zstd frame, and passes it through unchanged otherwise.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(magic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(head, magic) {
+		return io.NopCloser(br), nil
+	}
+
+	dec, err := zstd.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	return dec.IOReadCloser(), nil
+}
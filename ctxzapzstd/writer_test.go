@@ -0,0 +1,71 @@
+package ctxzapzstd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/algobardo/ctxzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soak.ndjson.zst")
+
+	for session := 0; session < 2; session++ {
+		w, err := Open(path)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), w, zapcore.InfoLevel)
+		logger := ctxzap.New(zap.New(core))
+		ctx := ctxzap.WithFields(context.Background(), zap.Int("session", session))
+		logger.Info(ctx, "entry")
+
+		if err := w.Close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open for read: %v", err)
+	}
+	defer f.Close()
+
+	r, err := NewReader(f)
+	if err != nil {
+		t.Fatalf("reader: %v", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), data)
+	}
+	if !strings.Contains(lines[1], `"session":1`) {
+		t.Errorf("expected second session entry, got %q", lines[1])
+	}
+}
+
+func TestNewReaderPassthrough(t *testing.T) {
+	r, err := NewReader(strings.NewReader(`{"msg":"plain"}` + "\n"))
+	if err != nil {
+		t.Fatalf("reader: %v", err)
+	}
+
+	data, _ := io.ReadAll(r)
+	if string(data) != `{"msg":"plain"}`+"\n" {
+		t.Errorf("expected plain input to pass through, got %q", data)
+	}
+}
@@ -0,0 +1,74 @@
+package ctxzap
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys of the fields added by Deprecation.
+const (
+	DeprecatedFeatureKey = "deprecated_feature"
+	RemovalKey           = "removal"
+)
+
+// DeprecationStat reports how often a deprecated feature was used.
+type DeprecationStat struct {
+	Feature string
+	Removal string
+	// Count is the number of uses, including the logged one.
+	Count uint64
+}
+
+type deprecation struct {
+	removal string
+	count   atomic.Uint64
+}
+
+// deprecations holds the deprecated features used in the process.
+var deprecations sync.Map
+
+// Deprecation records a use of a deprecated feature, such as an API or a
+// configuration option, due for removal in removal (a version or a date). The
+// first use of each feature in the process is logged at WarnLevel with
+// "deprecated_feature" and "removal" fields, so that platform teams can query
+// deprecated usage uniformly across services; later uses are only counted,
+// and DeprecationStats reports the counts.
+func (l *Logger) Deprecation(ctx context.Context, feature, removal string, fields ...zap.Field) {
+	value, ok := deprecations.Load(feature)
+	if !ok {
+		value, _ = deprecations.LoadOrStore(feature, &deprecation{removal: removal})
+	}
+	if value.(*deprecation).count.Add(1) != 1 {
+		return
+	}
+
+	fields = append([]zap.Field{
+		zap.String(DeprecatedFeatureKey, feature),
+		zap.String(RemovalKey, removal),
+	}, fields...)
+	l.Logger.Warn("deprecated feature used", l.fields(ctx, zapcore.WarnLevel, fields, nil)...)
+}
+
+// DeprecationStats returns the use counts of the deprecated features recorded
+// with Deprecation, ordered by feature.
+func DeprecationStats() []DeprecationStat {
+	var stats []DeprecationStat
+	deprecations.Range(func(key, value any) bool {
+		d := value.(*deprecation)
+		stats = append(stats, DeprecationStat{
+			Feature: key.(string),
+			Removal: d.removal,
+			Count:   d.count.Load(),
+		})
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Feature < stats[j].Feature
+	})
+	return stats
+}
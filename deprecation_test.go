@@ -0,0 +1,44 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDeprecation(t *testing.T) {
+	deprecations.Clear()
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithFields(context.Background(), zap.String("request_id", "req-1"))
+
+	for range 3 {
+		logger.Deprecation(ctx, "v1 search API", "v3.0.0", zap.String("endpoint", "/v1/search"))
+	}
+	logger.Deprecation(ctx, "legacy config", "2027-01-01")
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected one entry per feature, got %d", len(entries))
+	}
+	if entries[0].Level != zapcore.WarnLevel || entries[0].Message != "deprecated feature used" {
+		t.Errorf("unexpected entry: %v", entries[0].Entry)
+	}
+	m := entries[0].ContextMap()
+	if m[DeprecatedFeatureKey] != "v1 search API" || m[RemovalKey] != "v3.0.0" ||
+		m["endpoint"] != "/v1/search" || m["request_id"] != "req-1" {
+		t.Errorf("unexpected fields: %v", m)
+	}
+
+	stats := DeprecationStats()
+	want := []DeprecationStat{
+		{Feature: "legacy config", Removal: "2027-01-01", Count: 1},
+		{Feature: "v1 search API", Removal: "v3.0.0", Count: 3},
+	}
+	if len(stats) != len(want) || stats[0] != want[0] || stats[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, stats)
+	}
+}
@@ -0,0 +1,32 @@
+package ctxzap
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// coreDependencies are the only modules the packages of this module may
+// depend on, so that it stays dependency-light for library authors;
+// integrations with heavier dependencies belong in their own modules.
+var coreDependencies = []string{
+	"github.com/algobardo/ctxzap",
+	"go.uber.org/multierr",
+	"go.uber.org/zap",
+}
+
+func TestCoreDependencies(t *testing.T) {
+	// List every package the packages of this module depend on, with the
+	// module providing it; standard library packages have no module.
+	out, err := exec.Command("go", "list", "-deps", "-f", "{{with .Module}}{{.Path}} {{$.ImportPath}}{{end}}", "./...").Output()
+	if err != nil {
+		t.Fatalf("go list: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		module, pkg, _ := strings.Cut(line, " ")
+		if !slices.Contains(coreDependencies, module) {
+			t.Errorf("this module depends on %s from %s; move the integration to its own module", pkg, module)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package ctxzap
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedValue replaces the old and new values of redacted paths.
+const redactedValue = "[REDACTED]"
+
+// DiffValueKey is the path under which Diff reports a change of before and
+// after themselves, e.g. when they are numbers or strings.
+const DiffValueKey = "value"
+
+// DiffOption configures the behavior of Diff.
+type DiffOption func(*diffConfig)
+
+type diffConfig struct {
+	redact map[string]struct{}
+}
+
+// DiffRedact marks paths whose values must not appear in the output. A path
+// matches either by its full dotted form ("card.number") or by its last
+// segment ("password"). Redacted paths are still reported as changed.
+func DiffRedact(paths ...string) DiffOption {
+	return func(c *diffConfig) {
+		for _, p := range paths {
+			c.redact[p] = struct{}{}
+		}
+	}
+}
+
+// Diff returns a field describing only the paths whose values differ between
+// before and after. Structs, maps, slices and pointers are walked recursively;
+// struct fields use their json tag name when present. Each changed path is
+// logged as an object with "old" and "new" values; values without paths,
+// such as numbers, are compared as a whole under DiffValueKey. The comparison
+// is deferred until the entry is actually encoded.
+func Diff(key string, before, after any, opts ...DiffOption) zap.Field {
+	cfg := diffConfig{redact: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return zap.Object(key, &diffMarshaler{before: before, after: after, cfg: cfg})
+}
+
+type diffMarshaler struct {
+	before, after any
+	cfg           diffConfig
+}
+
+type change struct {
+	path     string
+	old, new any
+}
+
+func (d *diffMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var changes []change
+	d.cfg.collect("", reflect.ValueOf(d.before), reflect.ValueOf(d.after), make(map[visit]struct{}), &changes)
+
+	for _, c := range changes {
+		path := c.path
+		if path == "" {
+			path = DiffValueKey
+		}
+		if err := enc.AddObject(path, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			if err := enc.AddReflected("old", c.old); err != nil {
+				return err
+			}
+			return enc.AddReflected("new", c.new)
+		})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c diffConfig) redacted(path string) bool {
+	if len(c.redact) == 0 {
+		return false
+	}
+	if _, ok := c.redact[path]; ok {
+		return true
+	}
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		_, ok := c.redact[path[i+1:]]
+		return ok
+	}
+	return false
+}
+
+// visit identifies a pair of values of type typ stored at addresses a and b.
+type visit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// collect walks a and b in parallel, appending every differing leaf. A
+// redacted path is reported as a single change without descending further.
+// visiting holds the pairs of values being walked, so that cyclic values are
+// only walked once.
+func (c diffConfig) collect(path string, a, b reflect.Value, visiting map[visit]struct{}, changes *[]change) {
+	a, b = indirect(a), indirect(b)
+
+	if c.redacted(path) {
+		if !valuesEqual(a, b) {
+			*changes = append(*changes, change{path: path, old: redactedValue, new: redactedValue})
+		}
+		return
+	}
+
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		if !valuesEqual(a, b) {
+			*changes = append(*changes, change{path: path, old: interfaceOf(a), new: interfaceOf(b)})
+		}
+		return
+	}
+
+	if pa, pb := address(a), address(b); pa != 0 && pb != 0 {
+		v := visit{a: pa, b: pb, typ: a.Type()}
+		if _, ok := visiting[v]; ok {
+			// The pair is already being compared higher up the path.
+			return
+		}
+		visiting[v] = struct{}{}
+		defer delete(visiting, v)
+	}
+
+	switch a.Kind() {
+	case reflect.Struct:
+		if !hasExportedFields(a.Type()) {
+			// Opaque values such as time.Time are compared as a whole.
+			if !valuesEqual(a, b) {
+				*changes = append(*changes, change{path: path, old: interfaceOf(a), new: interfaceOf(b)})
+			}
+			return
+		}
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := fieldName(f)
+			if name == "-" {
+				continue
+			}
+			c.collect(joinPath(path, name), a.Field(i), b.Field(i), visiting, changes)
+		}
+	case reflect.Map:
+		for _, k := range mapKeys(a, b) {
+			c.collect(joinPath(path, k.name), a.MapIndex(k.value), b.MapIndex(k.value), visiting, changes)
+		}
+	case reflect.Slice, reflect.Array:
+		n := a.Len()
+		if b.Len() > n {
+			n = b.Len()
+		}
+		for i := 0; i < n; i++ {
+			var av, bv reflect.Value
+			if i < a.Len() {
+				av = a.Index(i)
+			}
+			if i < b.Len() {
+				bv = b.Index(i)
+			}
+			c.collect(joinPath(path, strconv.Itoa(i)), av, bv, visiting, changes)
+		}
+	default:
+		if !valuesEqual(a, b) {
+			*changes = append(*changes, change{path: path, old: interfaceOf(a), new: interfaceOf(b)})
+		}
+	}
+}
+
+// address returns the address of the storage of v, which can be part of a
+// cycle, or 0 if it has none.
+func address(v reflect.Value) uintptr {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Pointer()
+	case reflect.Struct, reflect.Array:
+		if v.CanAddr() {
+			return v.UnsafeAddr()
+		}
+	}
+	return 0
+}
+
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+type mapKey struct {
+	name  string
+	value reflect.Value
+}
+
+// mapKeys returns the union of the keys of a and b in a stable order.
+func mapKeys(a, b reflect.Value) []mapKey {
+	seen := make(map[string]struct{}, a.Len())
+	keys := make([]mapKey, 0, a.Len())
+	for _, m := range []reflect.Value{a, b} {
+		for _, k := range m.MapKeys() {
+			name := keyString(k)
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			keys = append(keys, mapKey{name: name, value: k})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].name < keys[j].name })
+	return keys
+}
+
+// keyString formats a map key as a path segment: strings as they are, and
+// other keys as fmt's %v verb does, using their String method if any.
+func keyString(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	return fmt.Sprintf("%v", k)
+}
+
+func fieldName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	if tag == "" {
+		return f.Name
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// indirect dereferences pointers and interfaces until a concrete value or an
+// invalid (nil) value is reached.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func valuesEqual(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	return reflect.DeepEqual(interfaceOf(a), interfaceOf(b))
+}
+
+func interfaceOf(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if !v.CanInterface() {
+		return v.String()
+	}
+	return v.Interface()
+}
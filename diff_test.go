@@ -0,0 +1,148 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type diffAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type diffUser struct {
+	Name     string            `json:"name"`
+	Password string            `json:"password"`
+	Address  *diffAddress      `json:"address"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	internal int
+}
+
+func TestDiff(t *testing.T) {
+	before := diffUser{
+		Name:     "alice",
+		Password: "old-secret",
+		Address:  &diffAddress{City: "Rome", Zip: "00100"},
+		Tags:     []string{"a", "b"},
+		Labels:   map[string]string{"tier": "free", "region": "eu"},
+		internal: 1,
+	}
+	after := diffUser{
+		Name:     "alice",
+		Password: "new-secret",
+		Address:  &diffAddress{City: "Milan", Zip: "00100"},
+		Tags:     []string{"a", "b", "c"},
+		Labels:   map[string]string{"tier": "pro", "region": "eu"},
+		internal: 2,
+	}
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	logger.Info(context.Background(), "user updated", Diff("user", before, after, DiffRedact("password")))
+
+	entries := observed.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+
+	diff, ok := entries[0].ContextMap()["user"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected user diff object, got %T", entries[0].ContextMap()["user"])
+	}
+
+	expected := map[string][2]interface{}{
+		"password":     {redactedValue, redactedValue},
+		"address.city": {"Rome", "Milan"},
+		"tags.2":       {nil, "c"},
+		"labels.tier":  {"free", "pro"},
+	}
+
+	if len(diff) != len(expected) {
+		t.Errorf("expected %d changed paths, got %d: %v", len(expected), len(diff), diff)
+	}
+
+	for path, values := range expected {
+		got, ok := diff[path].(map[string]interface{})
+		if !ok {
+			t.Errorf("expected changed path %q not found", path)
+			continue
+		}
+		if got["old"] != values[0] || got["new"] != values[1] {
+			t.Errorf("path %q: expected %v -> %v, got %v -> %v", path, values[0], values[1], got["old"], got["new"])
+		}
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	v := diffUser{Name: "bob", Tags: []string{"x"}}
+	logger.Info(context.Background(), "noop", Diff("user", v, v))
+
+	diff, ok := observed.All()[0].ContextMap()["user"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected user diff object")
+	}
+	if len(diff) != 0 {
+		t.Errorf("expected empty diff, got %v", diff)
+	}
+}
+
+func TestDiffScalars(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	logger.Info(context.Background(), "scalars", Diff("count", 1, 2))
+
+	diff := observed.All()[0].ContextMap()["count"].(map[string]interface{})
+	value, ok := diff[DiffValueKey].(map[string]interface{})
+	if !ok || len(diff) != 1 {
+		t.Fatalf("expected the change under %q, got %v", DiffValueKey, diff)
+	}
+	if value["old"] != 1 || value["new"] != 2 {
+		t.Errorf("expected old 1 and new 2, got %v", value)
+	}
+}
+
+type diffNode struct {
+	Value int       `json:"value"`
+	Next  *diffNode `json:"next"`
+}
+
+func TestDiffCycles(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	before := &diffNode{Value: 1}
+	before.Next = before
+	after := &diffNode{Value: 2}
+	after.Next = after
+	logger.Info(context.Background(), "cyclic", Diff("node", before, after))
+
+	diff := observed.All()[0].ContextMap()["node"].(map[string]interface{})
+	if len(diff) != 1 || diff["value"] == nil {
+		t.Errorf("expected only value to change, got %v", diff)
+	}
+}
+
+type diffPoint struct{ X, Y int }
+
+func TestDiffMapKeys(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	before := map[diffPoint]string{{1, 2}: "a", {3, 4}: "b"}
+	after := map[diffPoint]string{{1, 2}: "a", {3, 4}: "c"}
+	logger.Info(context.Background(), "struct keys", Diff("grid", before, after))
+
+	diff := observed.All()[0].ContextMap()["grid"].(map[string]interface{})
+	if _, ok := diff["{3 4}"]; !ok || len(diff) != 1 {
+		t.Errorf("expected the key formatted with %%v, got %v", diff)
+	}
+}
@@ -0,0 +1,33 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDryRunCore(t *testing.T) {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	dry := NewDryRunCore(enc, zapcore.InfoLevel)
+	logger := New(zap.New(dry))
+	ctx := WithFields(context.Background(), zap.String("request_id", "abc"))
+
+	logger.With(zap.String("service", "api")).Info(ctx, "hello")
+	logger.Warn(ctx, "careful")
+	logger.Debug(ctx, "disabled")
+
+	stats := dry.Stats()
+	if stats.Entries != 2 || stats.Errors != 0 {
+		t.Errorf("expected 2 entries without errors, got %+v", stats)
+	}
+	want := len(`{"msg":"hello","service":"api","request_id":"abc"}`+"\n") +
+		len(`{"msg":"careful","request_id":"abc"}`+"\n")
+	if stats.Bytes != uint64(want) {
+		t.Errorf("expected %d bytes, got %d", want, stats.Bytes)
+	}
+	if stats.Levels[zapcore.InfoLevel] != 1 || stats.Levels[zapcore.WarnLevel] != 1 || len(stats.Levels) != 2 {
+		t.Errorf("unexpected level counts: %v", stats.Levels)
+	}
+}
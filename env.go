@@ -0,0 +1,76 @@
+package ctxzap
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/algobardo/ctxzap/internal/fieldcodec"
+)
+
+// EnvFieldsKey is the environment variable carrying context fields to child
+// processes.
+const EnvFieldsKey = "CTXZAP_FIELDS"
+
+// EncodeEnv returns environment entries carrying the fields of ctx, for
+// child processes launched by the service (workers, plugins) to restore with
+// DecodeEnv, keeping log correlation across fork/exec boundaries:
+//
+//	cmd := exec.CommandContext(ctx, "worker")
+//	cmd.Env = append(os.Environ(), ctxzap.EncodeEnv(ctx)...)
+//
+// Field types are preserved. Fields that cannot be encoded, such as
+// reflected values that fail to marshal to JSON, are omitted. EncodeEnv
+// returns nil when ctx has no fields.
+func EncodeEnv(ctx context.Context) []string {
+	fields := entryFields(ctx)
+	wire := make([]fieldcodec.Field, 0, len(fields))
+	for i := range fields {
+		w, err := fieldcodec.ToWire(fields[i : i+1])
+		if err != nil {
+			continue
+		}
+		wire = append(wire, w...)
+	}
+	if len(wire) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(wire)
+	if err != nil {
+		return nil
+	}
+	return []string{EnvFieldsKey + "=" + base64.RawURLEncoding.EncodeToString(data)}
+}
+
+// DecodeEnv returns ctx with the fields encoded by EncodeEnv in environ,
+// typically os.Environ() in the child process, added to it:
+//
+//	ctx, err := ctxzap.DecodeEnv(context.Background(), os.Environ())
+//
+// If environ carries no fields, ctx is returned unchanged.
+func DecodeEnv(ctx context.Context, environ []string) (context.Context, error) {
+	var value string
+	found := false
+	// The last entry wins, as with duplicate keys in exec.Cmd.Env.
+	for _, kv := range environ {
+		if v, ok := strings.CutPrefix(kv, EnvFieldsKey+"="); ok {
+			value, found = v, true
+		}
+	}
+	if !found || value == "" {
+		return ctx, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return ctx, fmt.Errorf("ctxzap: decoding %s: %w", EnvFieldsKey, err)
+	}
+	decoded, err := UnmarshalFields(ctx, data)
+	if err != nil {
+		return ctx, fmt.Errorf("ctxzap: decoding %s: %w", EnvFieldsKey, err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,70 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEnvRoundTrip(t *testing.T) {
+	parent := WithFields(context.Background(),
+		zap.String("request_id", "req-1"),
+		zap.Int("attempt", 3),
+		zap.Duration("timeout", 2*time.Second),
+		zap.Error(errors.New("upstream failed")),
+		zap.Reflect("bad", func() {}),
+	)
+
+	env := EncodeEnv(parent)
+	if len(env) != 1 || !strings.HasPrefix(env[0], EnvFieldsKey+"=") {
+		t.Fatalf("unexpected environment: %v", env)
+	}
+
+	environ := append([]string{"PATH=/bin", EnvFieldsKey + "=stale"}, env...)
+	ctx, err := DecodeEnv(context.Background(), environ)
+	if err != nil {
+		t.Fatalf("DecodeEnv failed: %v", err)
+	}
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	New(zap.New(core)).Info(ctx, "child started")
+
+	fields := observed.All()[0].ContextMap()
+	expected := map[string]interface{}{
+		"request_id": "req-1",
+		"attempt":    int64(3),
+		"timeout":    2 * time.Second,
+		"error":      "upstream failed",
+	}
+	if len(fields) != len(expected) {
+		t.Errorf("expected %d fields, got %v", len(expected), fields)
+	}
+	for key, want := range expected {
+		if fields[key] != want {
+			t.Errorf("field %s: expected %v, got %v", key, want, fields[key])
+		}
+	}
+}
+
+func TestDecodeEnv(t *testing.T) {
+	ctx := context.Background()
+
+	got, err := DecodeEnv(ctx, []string{"HOME=/root"})
+	if err != nil || got != ctx {
+		t.Errorf("expected unchanged context without fields, got err %v", err)
+	}
+
+	if EncodeEnv(ctx) != nil {
+		t.Error("expected no environment for a context without fields")
+	}
+
+	if _, err := DecodeEnv(ctx, []string{EnvFieldsKey + "=!!"}); err == nil {
+		t.Error("expected an error for a malformed value")
+	}
+}
@@ -0,0 +1,190 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys of the fields added by ErrorErr and WarnErr, next to the "error" field
+// of zap.Error.
+const (
+	ErrorChainKey = "error_chain"
+	ErrorStackKey = "error_stack"
+)
+
+// StackTracer is implemented by errors that record the stack where they were
+// created, as program counters like those returned by runtime.Callers.
+// Errors from github.com/pkg/errors and compatible packages, whose
+// StackTrace method returns frames formatting themselves with %+v, are
+// supported as well.
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+// ErrorErr logs a message at ErrorLevel with standard fields describing err:
+// the "error" field of zap.Error, an "error_chain" field with the message of
+// every error in its Unwrap tree when it wraps other errors, and an
+// "error_stack" field with the stack of the innermost error that recorded
+// one (see StackTracer). A nil err adds no fields.
+func (l *Logger) ErrorErr(ctx context.Context, err error, msg string, fields ...zap.Field) {
+	l.Logger.Error(msg, l.fields(ctx, zapcore.ErrorLevel, append(errorFields(err), fields...), nil)...)
+}
+
+// WarnErr logs a message at WarnLevel with the error fields of ErrorErr.
+func (l *Logger) WarnErr(ctx context.Context, err error, msg string, fields ...zap.Field) {
+	if !l.withinBudget(ctx, zapcore.WarnLevel) {
+		return
+	}
+	l.Logger.Warn(msg, l.fields(ctx, zapcore.WarnLevel, append(errorFields(err), fields...), nil)...)
+}
+
+// errorsContextKey is used as a key for storing an error recorder in context
+type errorsContextKey struct{}
+
+var errorsKey = errorsContextKey{}
+
+// errorRecorder collects the errors passed to WithError below a context
+// returned by CaptureErrors.
+type errorRecorder struct {
+	parent *errorRecorder
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// CaptureErrors returns a context recording the errors passed to WithError
+// with it or any context derived from it, so that code logging the outcome
+// of a request, typically an HTTP middleware, can include errors handled deep
+// in the stack without threading them back up:
+//
+//	ctx = ctxzap.CaptureErrors(ctx)
+//	next.ServeHTTP(w, r.WithContext(ctx))
+//	logger.Info(ctx, "request completed", zap.Error(ctxzap.CapturedError(ctx)))
+//
+// Captures nest: errors are recorded by every enclosing capture.
+func CaptureErrors(ctx context.Context) context.Context {
+	parent, _ := ctx.Value(errorsKey).(*errorRecorder)
+	return context.WithValue(ctx, errorsKey, &errorRecorder{parent: parent})
+}
+
+// WithError returns ctx with err added as an "error" field, so that entries
+// logged with it carry the error, and records err for CapturedError when ctx
+// is derived from a context returned by CaptureErrors. A nil err returns ctx
+// unchanged.
+func WithError(ctx context.Context, err error) context.Context {
+	if err == nil {
+		return ctx
+	}
+	for rec, _ := ctx.Value(errorsKey).(*errorRecorder); rec != nil; rec = rec.parent {
+		rec.mu.Lock()
+		rec.errs = append(rec.errs, err)
+		rec.mu.Unlock()
+	}
+	return WithFields(ctx, zap.Error(err))
+}
+
+// CapturedError returns the error recorded with WithError below the
+// innermost CaptureErrors of ctx, several errors joined with errors.Join in
+// the order they were recorded, or nil.
+func CapturedError(ctx context.Context) error {
+	rec, _ := ctx.Value(errorsKey).(*errorRecorder)
+	if rec == nil {
+		return nil
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.errs) == 1 {
+		return rec.errs[0]
+	}
+	return errors.Join(rec.errs...)
+}
+
+// errorFields returns the fields describing err.
+func errorFields(err error) []zap.Field {
+	if err == nil {
+		return nil
+	}
+
+	fields := []zap.Field{zap.Error(err)}
+	var chain []string
+	var stack string
+	walkErrors(err, func(e error) {
+		chain = append(chain, e.Error())
+		if s := errorStack(e); s != "" {
+			stack = s
+		}
+	})
+	if len(chain) > 1 {
+		fields = append(fields, zap.Strings(ErrorChainKey, chain))
+	}
+	if stack != "" {
+		fields = append(fields, zap.String(ErrorStackKey, stack))
+	}
+	return fields
+}
+
+// walkErrors calls fn for err and every error in its Unwrap tree, depth
+// first.
+func walkErrors(err error, fn func(error)) {
+	fn(err)
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			walkErrors(inner, fn)
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if inner != nil {
+				walkErrors(inner, fn)
+			}
+		}
+	}
+}
+
+// errorStack returns the stack recorded by err itself, or "".
+func errorStack(err error) string {
+	if tracer, ok := err.(StackTracer); ok {
+		return formatStack(tracer.StackTrace())
+	}
+
+	// github.com/pkg/errors: StackTrace() errors.StackTrace, where
+	// errors.StackTrace implements fmt.Formatter.
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return ""
+	}
+	trace, ok := method.Call(nil)[0].Interface().(fmt.Formatter)
+	if !ok {
+		return ""
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%+v", trace), "\n")
+}
+
+// formatStack formats program counters like zap's stacktrace field.
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type stackError struct {
+	msg string
+	pcs []uintptr
+}
+
+func newStackError(msg string) *stackError {
+	pcs := make([]uintptr, 32)
+	return &stackError{msg: msg, pcs: pcs[:runtime.Callers(1, pcs)]}
+}
+
+func (e *stackError) Error() string         { return e.msg }
+func (e *stackError) StackTrace() []uintptr { return e.pcs }
+
+// formattedTrace mimics github.com/pkg/errors.StackTrace.
+type formattedTrace []string
+
+func (t formattedTrace) Format(s fmt.State, verb rune) {
+	for _, frame := range t {
+		fmt.Fprintf(s, "\n%s", frame)
+	}
+}
+
+type pkgError struct{ msg string }
+
+func (e pkgError) Error() string              { return e.msg }
+func (e pkgError) StackTrace() formattedTrace { return formattedTrace{"main.run", "main.main"} }
+
+func TestErrorErr(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithFields(context.Background(), zap.String("request_id", "abc"))
+
+	root := newStackError("connection refused")
+	err := fmt.Errorf("query users: %w", errors.Join(root, errors.New("retry budget exhausted")))
+	logger.ErrorErr(ctx, err, "request failed", zap.Int("attempt", 3))
+
+	entry := observed.All()[0]
+	if entry.Level != zapcore.ErrorLevel {
+		t.Errorf("expected ErrorLevel, got %v", entry.Level)
+	}
+	m := entry.ContextMap()
+	if m["error"] != err.Error() || m["request_id"] != "abc" || m["attempt"] != int64(3) {
+		t.Errorf("unexpected fields: %v", m)
+	}
+	chain, _ := m[ErrorChainKey].([]any)
+	if len(chain) != 4 || chain[0] != err.Error() || chain[2] != "connection refused" || chain[3] != "retry budget exhausted" {
+		t.Errorf("unexpected chain: %q", chain)
+	}
+	if stack, _ := m[ErrorStackKey].(string); !strings.HasPrefix(stack, "github.com/algobardo/ctxzap.newStackError\n\t") {
+		t.Errorf("expected the stack of the root error, got %q", stack)
+	}
+}
+
+func TestWarnErr(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	logger.WarnErr(context.Background(), pkgError{msg: "timeout"}, "slow dependency")
+	logger.WarnErr(context.Background(), nil, "no error")
+
+	entries := observed.All()
+	m := entries[0].ContextMap()
+	if entries[0].Level != zapcore.WarnLevel || m["error"] != "timeout" {
+		t.Errorf("unexpected entry: %v %v", entries[0].Level, m)
+	}
+	if _, ok := m[ErrorChainKey]; ok {
+		t.Errorf("expected no chain for an unwrapped error, got %v", m)
+	}
+	if m[ErrorStackKey] != "main.run\nmain.main" {
+		t.Errorf("expected the pkg/errors-style stack, got %q", m[ErrorStackKey])
+	}
+	if len(entries[1].Context) != 0 {
+		t.Errorf("expected no fields for a nil error, got %v", entries[1].ContextMap())
+	}
+}
+
+func TestWithError(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	outer := CaptureErrors(context.Background())
+	ctx := CaptureErrors(WithFields(outer, zap.String("request_id", "abc")))
+
+	errNotFound := errors.New("user not found")
+	errCache := errors.New("cache unavailable")
+	func(ctx context.Context) {
+		ctx = WithError(ctx, errNotFound)
+		logger.Info(ctx, "lookup failed")
+		_ = WithError(ctx, errCache)
+		_ = WithError(ctx, nil)
+	}(ctx)
+
+	if m := observed.All()[0].ContextMap(); m["error"] != "user not found" || m["request_id"] != "abc" {
+		t.Errorf("expected the error as a context field, got %v", m)
+	}
+	err := CapturedError(ctx)
+	if !errors.Is(err, errNotFound) || !errors.Is(err, errCache) {
+		t.Errorf("expected both errors captured, got %v", err)
+	}
+	if err := CapturedError(outer); !errors.Is(err, errNotFound) {
+		t.Errorf("expected enclosing captures to record errors, got %v", err)
+	}
+
+	single := CaptureErrors(context.Background())
+	_ = WithError(single, errCache)
+	if err := CapturedError(single); err != errCache {
+		t.Errorf("expected a single error unchanged, got %v", err)
+	}
+	if CapturedError(context.Background()) != nil {
+		t.Error("expected nil without CaptureErrors")
+	}
+}
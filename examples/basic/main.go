@@ -95,6 +95,8 @@ func loggingMiddleware(logger *ctxzap.Logger) func(http.Handler) http.Handler {
 				zap.String("path", r.URL.Path),
 				zap.String("remote_addr", r.RemoteAddr),
 			)
+			// Collect errors recorded with ctxzap.WithError by handlers
+			ctx = ctxzap.CaptureErrors(ctx)
 
 			// Log request start
 			logger.Info(ctx, "Request started")
@@ -107,6 +109,7 @@ func loggingMiddleware(logger *ctxzap.Logger) func(http.Handler) http.Handler {
 			logger.Info(ctx, "Request completed",
 				zap.Duration("duration", time.Since(start)),
 				zap.Int("status", 200), // In real code, capture actual status
+				zap.Error(ctxzap.CapturedError(ctx)),
 			)
 		})
 	}
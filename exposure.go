@@ -0,0 +1,92 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrNoExposureSink is returned by Logger.Exposure when no sink is configured.
+var ErrNoExposureSink = errors.New("ctxzap: no exposure sink configured")
+
+// ExposureEvent records that a subject was exposed to a variant of an
+// experiment.
+type ExposureEvent struct {
+	Time       time.Time
+	Experiment string
+	Variant    string
+	// Fields holds the context fields and any fields passed to Exposure.
+	Fields []zap.Field
+}
+
+// ExposureSink receives exposure events. Unlike diagnostic logging, delivery
+// is synchronous: WriteExposure must only return nil once the event has been
+// durably accepted, and any error is reported back to the caller.
+type ExposureSink interface {
+	WriteExposure(ctx context.Context, event ExposureEvent) error
+}
+
+// WithExposureSink makes the logger route exposure events to sink.
+func WithExposureSink(sink ExposureSink) Option {
+	return func(l *Logger) {
+		l.exposure = sink
+	}
+}
+
+// Exposure records an experiment exposure. The event carries the context
+// fields, is never sampled or filtered by level, and is written to the
+// logger's exposure sink rather than to the diagnostic log. The returned error
+// reports whether the sink accepted the event.
+func (l *Logger) Exposure(ctx context.Context, experiment, variant string, fields ...zap.Field) error {
+	if l.exposure == nil {
+		return ErrNoExposureSink
+	}
+
+	event := ExposureEvent{
+		Time:       time.Now(),
+		Experiment: experiment,
+		Variant:    variant,
+		Fields:     MergeFields(entryFields(ctx), fields),
+	}
+	return l.exposure.WriteExposure(ctx, event)
+}
+
+// CoreExposureSink writes exposure events as entries to a dedicated
+// zapcore.Core and syncs it after each write.
+type CoreExposureSink struct {
+	core zapcore.Core
+}
+
+var _ ExposureSink = (*CoreExposureSink)(nil)
+
+// NewCoreExposureSink creates an exposure sink writing to core. The core
+// should be separate from the diagnostic one, typically backed by a file or
+// WriteSyncer whose Sync guarantees durability.
+func NewCoreExposureSink(core zapcore.Core) *CoreExposureSink {
+	return &CoreExposureSink{core: core}
+}
+
+// WriteExposure writes event as an Info-level "exposure" entry and syncs the
+// core, bypassing the core's level check.
+func (s *CoreExposureSink) WriteExposure(_ context.Context, event ExposureEvent) error {
+	entry := zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    event.Time,
+		Message: "exposure",
+	}
+
+	fields := make([]zap.Field, 0, len(event.Fields)+2)
+	fields = append(fields,
+		zap.String("experiment", event.Experiment),
+		zap.String("variant", event.Variant),
+	)
+	fields = append(fields, event.Fields...)
+
+	if err := s.core.Write(entry, fields); err != nil {
+		return err
+	}
+	return s.core.Sync()
+}
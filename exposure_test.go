@@ -0,0 +1,53 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestExposure(t *testing.T) {
+	diagnosticCore, diagnostic := observer.New(zapcore.ErrorLevel)
+	exposureCore, exposures := observer.New(zapcore.ErrorLevel)
+
+	logger := New(zap.New(diagnosticCore), WithExposureSink(NewCoreExposureSink(exposureCore)))
+
+	ctx := WithFields(context.Background(), zap.String("user_id", "u1"))
+	if err := logger.Exposure(ctx, "checkout", "treatment"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diagnostic.Len() != 0 {
+		t.Errorf("expected no diagnostic entries, got %d", diagnostic.Len())
+	}
+
+	entries := exposures.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 exposure entry despite level, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	expected := map[string]interface{}{
+		"experiment": "checkout",
+		"variant":    "treatment",
+		"user_id":    "u1",
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("field %q: expected %v, got %v", k, v, fields[k])
+		}
+	}
+}
+
+func TestExposureWithoutSink(t *testing.T) {
+	logger := New(zap.NewNop())
+
+	err := logger.Exposure(context.Background(), "checkout", "control")
+	if !errors.Is(err, ErrNoExposureSink) {
+		t.Errorf("expected ErrNoExposureSink, got %v", err)
+	}
+}
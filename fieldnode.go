@@ -0,0 +1,108 @@
+package ctxzap
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// fieldNode stores the fields added to a context by one WithFields call,
+// linked to the node of the parent context, so that adding fields costs
+// O(len(fields)) however many fields the context already holds. The merged
+// fields are computed in a single pass the first time they are needed, and
+// cached. Nodes are never modified after creation, apart from the cache.
+type fieldNode struct {
+	// parent is nil for the first node of a context, and for nodes holding
+	// the complete fields of a context, as stored by WithoutFields.
+	parent *fieldNode
+	fields []zap.Field
+
+	merged atomic.Pointer[[]zap.Field]
+	// loggers caches zap loggers with the merged fields, most recently
+	// created first, see WithLoggerCache.
+	loggers atomic.Pointer[[]cachedLogger]
+}
+
+// newFieldNode returns a node adding fields, which it takes ownership of, to
+// parent.
+func newFieldNode(parent *fieldNode, fields []zap.Field) *fieldNode {
+	return &fieldNode{parent: parent, fields: fields}
+}
+
+// resolvedFieldNode returns a node holding the complete, already merged
+// fields of a context, which it takes ownership of.
+func resolvedFieldNode(fields []zap.Field) *fieldNode {
+	n := &fieldNode{fields: fields}
+	n.merged.Store(&n.fields)
+	return n
+}
+
+// resolve returns the fields of the context holding n, with the semantics of
+// successive MergeFields calls: a field overriding another with an equal
+// value keeps its position, one with a different value moves to the end,
+// and within one node the last field with a key wins. The result must not be
+// modified.
+func (n *fieldNode) resolve() []zap.Field {
+	if n == nil {
+		return nil
+	}
+	if merged := n.merged.Load(); merged != nil {
+		return *merged
+	}
+
+	// Collect the nodes added since the nearest resolved or complete one.
+	var pending []*fieldNode
+	var base []zap.Field
+	for node := n; node != nil; node = node.parent {
+		if merged := node.merged.Load(); merged != nil {
+			base = *merged
+			break
+		}
+		pending = append(pending, node)
+	}
+
+	size := len(base)
+	for _, node := range pending {
+		size += len(node.fields)
+	}
+	fields := make([]zap.Field, 0, size)
+	fields = append(fields, base...)
+	live := make([]bool, len(fields), size)
+	index := make(map[string]int, size)
+	for i := range fields {
+		live[i] = true
+		index[fields[i].Key] = i
+	}
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		start := len(fields)
+		for _, f := range pending[i].fields {
+			if j, ok := index[f.Key]; ok {
+				switch {
+				case j >= start:
+					fields[j] = f
+					continue
+				case fields[j].Equals(f):
+					continue
+				}
+				live[j] = false
+			}
+			index[f.Key] = len(fields)
+			fields = append(fields, f)
+			live = append(live, true)
+		}
+	}
+
+	merged := fields[:0]
+	for i := range fields {
+		if live[i] {
+			merged = append(merged, fields[i])
+		}
+	}
+	if len(merged) == 0 {
+		merged = nil
+	}
+	merged = merged[:len(merged):len(merged)]
+	n.merged.Store(&merged)
+	return merged
+}
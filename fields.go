@@ -2,6 +2,12 @@ package ctxzap
 
 import "go.uber.org/zap"
 
+// linearMergeThreshold is the number of fields up to which merging compares
+// keys pairwise rather than building a map. Below about 64 fields the
+// pairwise comparison is faster, and it allocates nothing besides the result
+// (see BenchmarkMergeFieldsSizes).
+const linearMergeThreshold = 32
+
 // MergeFields merges two slices of zap fields. If fields with the same key
 // exist in both slices, the fields from the second slice take precedence.
 // This ensures that newer fields can override older ones.
@@ -13,8 +19,26 @@ func MergeFields(existingFields, newFields []zap.Field) []zap.Field {
 		return existingFields
 	}
 
+	return appendMerged(make([]zap.Field, 0, len(existingFields)+len(newFields)), nil, existingFields, newFields)
+}
+
+// appendMerged appends the fields of MergeFields(existingFields, newFields)
+// to dst, which must not share its backing array with either input. fieldMap,
+// if not nil, must be empty, and is left empty.
+func appendMerged(dst []zap.Field, fieldMap map[string]zap.Field, existingFields, newFields []zap.Field) []zap.Field {
+	if len(existingFields)+len(newFields) <= linearMergeThreshold {
+		return appendMergedLinear(dst, existingFields, newFields)
+	}
+	return appendMergedMap(dst, fieldMap, existingFields, newFields)
+}
+
+// appendMergedMap is appendMerged for large field sets, deduplicating keys
+// with fieldMap.
+func appendMergedMap(dst []zap.Field, fieldMap map[string]zap.Field, existingFields, newFields []zap.Field) []zap.Field {
 	// Create a map to track field keys for deduplication
-	fieldMap := make(map[string]zap.Field, len(existingFields)+len(newFields))
+	if fieldMap == nil {
+		fieldMap = make(map[string]zap.Field, len(existingFields)+len(newFields))
+	}
 
 	// Add existing fields first
 	for _, field := range existingFields {
@@ -27,7 +51,7 @@ func MergeFields(existingFields, newFields []zap.Field) []zap.Field {
 	}
 
 	// Convert map back to slice
-	result := make([]zap.Field, 0, len(fieldMap))
+	result := dst
 	// First, add fields from existingFields that weren't overridden
 	for _, field := range existingFields {
 		if f, exists := fieldMap[field.Key]; exists && f.Equals(field) {
@@ -45,3 +69,70 @@ func MergeFields(existingFields, newFields []zap.Field) []zap.Field {
 
 	return result
 }
+
+// appendMergedLinear is appendMerged for small field sets: it compares keys
+// pairwise instead of building a map, and simply appends both slices if no
+// key occurs twice.
+func appendMergedLinear(dst, existingFields, newFields []zap.Field) []zap.Field {
+	if !hasDuplicateKeys(existingFields, newFields) {
+		dst = append(dst, existingFields...)
+		return append(dst, newFields...)
+	}
+
+	// Same passes as with the map: a key is removed from the map once
+	// appended, so it is present while it is absent from dst[start:].
+	start := len(dst)
+	for _, field := range existingFields {
+		if !containsKey(dst[start:], field.Key) && lastWithKey(existingFields, newFields, field.Key).Equals(field) {
+			dst = append(dst, field)
+		}
+	}
+	for _, field := range newFields {
+		if !containsKey(dst[start:], field.Key) {
+			dst = append(dst, field)
+		}
+	}
+	return dst
+}
+
+// hasDuplicateKeys reports whether a key occurs more than once in
+// existingFields and newFields together.
+func hasDuplicateKeys(existingFields, newFields []zap.Field) bool {
+	for i := range existingFields {
+		key := existingFields[i].Key
+		if containsKey(existingFields[i+1:], key) || containsKey(newFields, key) {
+			return true
+		}
+	}
+	for i := range newFields {
+		if containsKey(newFields[i+1:], newFields[i].Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastWithKey returns the last field with key in existingFields and
+// newFields, which the map of appendMerged ends up holding for key.
+func lastWithKey(existingFields, newFields []zap.Field, key string) zap.Field {
+	for i := len(newFields) - 1; i >= 0; i-- {
+		if newFields[i].Key == key {
+			return newFields[i]
+		}
+	}
+	for i := len(existingFields) - 1; i >= 0; i-- {
+		if existingFields[i].Key == key {
+			return existingFields[i]
+		}
+	}
+	return zap.Field{}
+}
+
+func containsKey(fields []zap.Field, key string) bool {
+	for i := range fields {
+		if fields[i].Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package ctxzap
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FlagsKey is the key of the field written by WithFlags.
+const FlagsKey = "feature_flags"
+
+// FlagEvaluator reports the variant a feature flag resolves to for a context.
+// Implementations adapt a feature-flag SDK; see the ctxzapopenfeature package
+// for an OpenFeature implementation.
+type FlagEvaluator interface {
+	// FlagVariant returns the variant of flag for ctx. ok is false when the
+	// flag could not be evaluated, in which case it is omitted from the logs.
+	FlagVariant(ctx context.Context, flag string) (variant string, ok bool)
+}
+
+// FlagEvaluatorFunc adapts an ordinary function to the FlagEvaluator interface.
+type FlagEvaluatorFunc func(ctx context.Context, flag string) (string, bool)
+
+// FlagVariant calls f(ctx, flag).
+func (f FlagEvaluatorFunc) FlagVariant(ctx context.Context, flag string) (string, bool) {
+	return f(ctx, flag)
+}
+
+// WithFlags evaluates flags once with evaluator and adds their variants to the
+// context as a "feature_flags" object field, so every subsequent entry records
+// which cohort the request was in. It is typically called once per request,
+// after the evaluation context (user, tenant) is known.
+func WithFlags(ctx context.Context, evaluator FlagEvaluator, flags ...string) context.Context {
+	if evaluator == nil || len(flags) == 0 {
+		return ctx
+	}
+
+	variants := make(flagVariants, 0, len(flags))
+	for _, flag := range flags {
+		if variant, ok := evaluator.FlagVariant(ctx, flag); ok {
+			variants = append(variants, flagVariant{flag: flag, variant: variant})
+		}
+	}
+	if len(variants) == 0 {
+		return ctx
+	}
+
+	return WithFields(ctx, zap.Object(FlagsKey, variants))
+}
+
+type flagVariant struct {
+	flag    string
+	variant string
+}
+
+type flagVariants []flagVariant
+
+func (v flagVariants) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, fv := range v {
+		enc.AddString(fv.flag, fv.variant)
+	}
+	return nil
+}
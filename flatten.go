@@ -0,0 +1,111 @@
+package ctxzap
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FlattenObjects returns a Transformer that replaces object fields with
+// top-level fields under dotted keys, for sinks that cannot handle nested
+// JSON. See FlattenFields.
+func FlattenObjects() Transformer {
+	return FlattenFields
+}
+
+// FlattenFields returns fields with every zapcore.ObjectMarshaler expanded
+// into top-level fields keyed by its dotted path: an "http" object with a
+// "method" key becomes an "http.method" field. Inline objects are expanded
+// without a prefix, and fields following a zap.Namespace are prefixed with
+// the namespace, including namespaces added with With when FlattenFields runs
+// as a transformer. Leaf values keep their types; arrays are kept whole.
+// UnflattenFields reverses the transformation.
+func FlattenFields(fields []zap.Field) []zap.Field {
+	flat := false
+	for i := range fields {
+		if flattens(fields[i].Type) {
+			flat = true
+			break
+		}
+	}
+	if !flat {
+		return fields
+	}
+
+	enc := &flattenEncoder{fields: make([]zap.Field, 0, len(fields))}
+	for _, f := range fields {
+		if flattens(f.Type) {
+			f.AddTo(enc)
+			continue
+		}
+		f.Key = enc.prefix + f.Key
+		enc.fields = append(enc.fields, f)
+	}
+	return enc.fields
+}
+
+func flattens(t zapcore.FieldType) bool {
+	switch t {
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType, zapcore.NamespaceType:
+		return true
+	}
+	return false
+}
+
+// flattenEncoder is an ObjectEncoder collecting the encoded values as fields
+// keyed by their dotted path.
+type flattenEncoder struct {
+	fields []zap.Field
+	prefix string
+}
+
+func (e *flattenEncoder) add(f zap.Field) {
+	f.Key = e.prefix + f.Key
+	e.fields = append(e.fields, f)
+}
+
+func (e *flattenEncoder) AddObject(key string, m zapcore.ObjectMarshaler) error {
+	// Namespaces opened inside the object end with it.
+	prefix := e.prefix
+	e.prefix += key + "."
+	err := m.MarshalLogObject(e)
+	e.prefix = prefix
+	return err
+}
+
+func (e *flattenEncoder) OpenNamespace(key string) {
+	e.prefix += key + "."
+}
+
+func (e *flattenEncoder) AddArray(key string, m zapcore.ArrayMarshaler) error {
+	e.add(zap.Array(key, m))
+	return nil
+}
+
+func (e *flattenEncoder) AddReflected(key string, v interface{}) error {
+	e.add(zap.Reflect(key, v))
+	return nil
+}
+
+func (e *flattenEncoder) AddBinary(key string, v []byte)          { e.add(zap.Binary(key, v)) }
+func (e *flattenEncoder) AddByteString(key string, v []byte)      { e.add(zap.ByteString(key, v)) }
+func (e *flattenEncoder) AddBool(key string, v bool)              { e.add(zap.Bool(key, v)) }
+func (e *flattenEncoder) AddComplex128(key string, v complex128)  { e.add(zap.Complex128(key, v)) }
+func (e *flattenEncoder) AddComplex64(key string, v complex64)    { e.add(zap.Complex64(key, v)) }
+func (e *flattenEncoder) AddDuration(key string, v time.Duration) { e.add(zap.Duration(key, v)) }
+func (e *flattenEncoder) AddFloat64(key string, v float64)        { e.add(zap.Float64(key, v)) }
+func (e *flattenEncoder) AddFloat32(key string, v float32)        { e.add(zap.Float32(key, v)) }
+func (e *flattenEncoder) AddInt(key string, v int)                { e.add(zap.Int(key, v)) }
+func (e *flattenEncoder) AddInt64(key string, v int64)            { e.add(zap.Int64(key, v)) }
+func (e *flattenEncoder) AddInt32(key string, v int32)            { e.add(zap.Int32(key, v)) }
+func (e *flattenEncoder) AddInt16(key string, v int16)            { e.add(zap.Int16(key, v)) }
+func (e *flattenEncoder) AddInt8(key string, v int8)              { e.add(zap.Int8(key, v)) }
+func (e *flattenEncoder) AddString(key, v string)                 { e.add(zap.String(key, v)) }
+func (e *flattenEncoder) AddTime(key string, v time.Time)         { e.add(zap.Time(key, v)) }
+func (e *flattenEncoder) AddUint(key string, v uint)              { e.add(zap.Uint(key, v)) }
+func (e *flattenEncoder) AddUint64(key string, v uint64)          { e.add(zap.Uint64(key, v)) }
+func (e *flattenEncoder) AddUint32(key string, v uint32)          { e.add(zap.Uint32(key, v)) }
+func (e *flattenEncoder) AddUint16(key string, v uint16)          { e.add(zap.Uint16(key, v)) }
+func (e *flattenEncoder) AddUint8(key string, v uint8)            { e.add(zap.Uint8(key, v)) }
+func (e *flattenEncoder) AddUintptr(key string, v uintptr)        { e.add(zap.Uintptr(key, v)) }
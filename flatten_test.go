@@ -0,0 +1,105 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFlattenFields(t *testing.T) {
+	request := zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("method", "GET")
+		enc.AddDuration("elapsed", time.Second)
+		return enc.AddObject("response", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddInt("status", 200)
+			return nil
+		}))
+	})
+
+	tests := []struct {
+		name   string
+		fields []zap.Field
+		want   []string
+	}{
+		{
+			name:   "no objects",
+			fields: []zap.Field{zap.String("a", "1"), zap.Int("b", 2)},
+			want:   []string{"a", "b"},
+		},
+		{
+			name:   "nested objects",
+			fields: []zap.Field{zap.String("user_id", "u1"), zap.Object("http", request)},
+			want:   []string{"user_id", "http.method", "http.elapsed", "http.response.status"},
+		},
+		{
+			name:   "inline object",
+			fields: []zap.Field{zap.Inline(request)},
+			want:   []string{"method", "elapsed", "response.status"},
+		},
+		{
+			name:   "namespace",
+			fields: []zap.Field{zap.String("a", "1"), zap.Namespace("db"), zap.String("table", "users")},
+			want:   []string{"a", "db.table"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FlattenFields(tt.fields)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d fields, got %d: %v", len(tt.want), len(got), got)
+			}
+			for i, key := range tt.want {
+				if got[i].Key != key {
+					t.Errorf("field %d: expected key %q, got %q", i, key, got[i].Key)
+				}
+			}
+		})
+	}
+
+	flat := FlattenFields([]zap.Field{zap.Object("http", request)})
+	if flat[1].Type != zapcore.DurationType {
+		t.Errorf("expected leaf types to be kept, got %v", flat[1].Type)
+	}
+}
+
+func TestFlattenObjectsKeepsNamespacesOfWith(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithTransformers(FlattenObjects())).With(zap.Namespace("a"))
+
+	logger.Info(context.Background(), "namespaced", zap.Int("b", 1))
+	logger.With(zap.Namespace("c")).Info(context.Background(), "nested", zap.Int("d", 2))
+
+	entries := observed.All()
+	if got := entries[0].ContextMap(); len(got) != 1 || got["a.b"] != int64(1) {
+		t.Errorf("expected {a.b:1}, got %v", got)
+	}
+	if got := entries[1].ContextMap(); len(got) != 1 || got["a.c.d"] != int64(2) {
+		t.Errorf("expected {a.c.d:2}, got %v", got)
+	}
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithTransformers(FlattenObjects(), NestDottedKeys()))
+
+	logger.Info(context.Background(), "test", zap.Object("http", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("method", "GET")
+		enc.OpenNamespace("response")
+		enc.AddInt("status", 200)
+		return nil
+	})))
+
+	http, ok := observed.All()[0].ContextMap()["http"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected http object, got %v", observed.All()[0].ContextMap())
+	}
+	response, ok := http["response"].(map[string]interface{})
+	if http["method"] != "GET" || !ok || response["status"] != int64(200) {
+		t.Errorf("unexpected round trip result: %v", http)
+	}
+}
@@ -0,0 +1,95 @@
+package ctxzap
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// global is the logger returned by L when the context carries none.
+var global atomic.Pointer[Logger]
+
+// SetGlobal replaces the global logger, used by L, and therefore by the
+// package-level logging functions, for contexts without a logger of their
+// own. It returns a function restoring the previous global logger. A nil
+// logger restores the default no-op logger.
+//
+// Like zap.ReplaceGlobals, it suits small services without dependency
+// injection:
+//
+//	ctxzap.SetGlobal(ctxzap.New(zapLogger))
+//	ctxzap.Info(ctx, "started")
+func SetGlobal(logger *Logger) func() {
+	if logger == nil {
+		logger = nopLogger
+	}
+	prev := global.Swap(logger)
+	return func() { SetGlobal(prev) }
+}
+
+// globalLogger returns the logger set with SetGlobal, or a no-op logger.
+func globalLogger() *Logger {
+	if l := global.Load(); l != nil {
+		return l
+	}
+	return nopLogger
+}
+
+// The package-level logging functions log with L(ctx). They call the
+// underlying zap.Logger directly, like the Logger methods, so that caller
+// reporting points at their callers.
+
+// Debug logs a message at DebugLevel with the logger from L(ctx).
+func Debug(ctx context.Context, msg string, fields ...zap.Field) {
+	l := L(ctx)
+	if !l.withinBudget(ctx, zapcore.DebugLevel) {
+		return
+	}
+	l.Logger.Debug(msg, l.fields(ctx, zapcore.DebugLevel, fields, nil)...)
+}
+
+// Info logs a message at InfoLevel with the logger from L(ctx).
+func Info(ctx context.Context, msg string, fields ...zap.Field) {
+	l := L(ctx)
+	if !l.withinBudget(ctx, zapcore.InfoLevel) {
+		return
+	}
+	l.Logger.Info(msg, l.fields(ctx, zapcore.InfoLevel, fields, nil)...)
+}
+
+// Warn logs a message at WarnLevel with the logger from L(ctx).
+func Warn(ctx context.Context, msg string, fields ...zap.Field) {
+	l := L(ctx)
+	if !l.withinBudget(ctx, zapcore.WarnLevel) {
+		return
+	}
+	l.Logger.Warn(msg, l.fields(ctx, zapcore.WarnLevel, fields, nil)...)
+}
+
+// Error logs a message at ErrorLevel with the logger from L(ctx).
+func Error(ctx context.Context, msg string, fields ...zap.Field) {
+	l := L(ctx)
+	l.Logger.Error(msg, l.fields(ctx, zapcore.ErrorLevel, fields, nil)...)
+}
+
+// DPanic logs a message at DPanicLevel with the logger from L(ctx).
+func DPanic(ctx context.Context, msg string, fields ...zap.Field) {
+	l := L(ctx)
+	l.Logger.DPanic(msg, l.fields(ctx, zapcore.DPanicLevel, fields, nil)...)
+}
+
+// Panic logs a message at PanicLevel with the logger from L(ctx), then
+// panics.
+func Panic(ctx context.Context, msg string, fields ...zap.Field) {
+	l := L(ctx)
+	l.Logger.Panic(msg, l.fields(ctx, zapcore.PanicLevel, fields, nil)...)
+}
+
+// Fatal logs a message at FatalLevel with the logger from L(ctx), then
+// calls os.Exit(1).
+func Fatal(ctx context.Context, msg string, fields ...zap.Field) {
+	l := L(ctx)
+	l.Logger.Fatal(msg, l.fields(ctx, zapcore.FatalLevel, fields, nil)...)
+}
@@ -0,0 +1,60 @@
+package ctxzap
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSetGlobal(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	restore := SetGlobal(New(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))))
+	defer restore()
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+	Info(ctx, "global", zap.Int("n", 1))
+	Warn(ctx, "warned")
+	Debug(ctx, "disabled")
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if entries[0].Message != "global" || fields["request_id"] != "123" || fields["n"] != int64(1) {
+		t.Errorf("unexpected entry: %v %v", entries[0].Message, fields)
+	}
+	if file := filepath.Base(entries[0].Caller.File); file != "global_test.go" {
+		t.Errorf("expected caller in global_test.go, got %s", file)
+	}
+
+	// A logger in the context takes precedence over the global one.
+	scopedCore, scoped := observer.New(zapcore.InfoLevel)
+	Error(WithLogger(ctx, New(zap.New(scopedCore))), "scoped")
+	if scoped.Len() != 1 || observed.Len() != 2 {
+		t.Errorf("expected the context logger to be used, got %d and %d entries", scoped.Len(), observed.Len())
+	}
+}
+
+func TestSetGlobalRestore(t *testing.T) {
+	core, _ := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	restore := SetGlobal(logger)
+	if L(context.Background()) != logger || L(nil) != logger {
+		t.Error("expected L to fall back to the global logger")
+	}
+	restore()
+	if L(context.Background()) != nopLogger {
+		t.Error("expected the no-op logger after restoring")
+	}
+
+	defer SetGlobal(nil)()
+	if L(context.Background()) != nopLogger {
+		t.Error("expected SetGlobal(nil) to set the no-op logger")
+	}
+}
@@ -3,12 +3,8 @@ module github.com/algobardo/ctxzap
 go 1.24.5
 
 require (
-	go.uber.org/fx v1.24.0
+	go.uber.org/multierr v1.10.0
 	go.uber.org/zap v1.27.0
 )
 
-require (
-	go.uber.org/dig v1.19.0 // indirect
-	go.uber.org/multierr v1.10.0 // indirect
-	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
-)
+require github.com/stretchr/testify v1.9.0 // indirect
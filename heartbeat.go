@@ -0,0 +1,69 @@
+package ctxzap
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ElapsedKey is the key of the field reporting the time since a heartbeat
+// was started.
+const ElapsedKey = "elapsed"
+
+// DefaultHeartbeatInterval is the interval StartHeartbeat uses when given a
+// non-positive one.
+const DefaultHeartbeatInterval = time.Minute
+
+// StartHeartbeat logs a "still running" entry every interval until the
+// returned function is called or ctx is done, so operators can tell a hung
+// job, which stops emitting heartbeats, from a slow one:
+//
+//	stop := ctxzap.StartHeartbeat(ctx, time.Minute)
+//	defer stop()
+//
+// Entries are logged at InfoLevel with the logger from L(ctx), so they
+// carry the context fields, with the time elapsed since the call and, within
+// an operation started by BeginOperation, the operation name. The returned
+// function waits for the heartbeat to stop; no entry is logged after it
+// returns. A non-positive interval is replaced by DefaultHeartbeatInterval.
+func StartHeartbeat(ctx context.Context, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	start := time.Now()
+	logger := L(ctx)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	fields := []zap.Field{zap.Duration(ElapsedKey, 0)}
+	if op := operationFrom(ctx); op != nil {
+		fields = append(fields, zap.String(OperationKey, op.name))
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				fields[0] = zap.Duration(ElapsedKey, now.Sub(start))
+				logger.Info(ctx, "still running", fields...)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		wg.Wait()
+	}
+}
@@ -0,0 +1,69 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHeartbeat(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	ctx := WithLogger(context.Background(), New(zap.New(core)))
+	ctx, _ = BeginOperation(ctx, "import_users")
+
+	stop := StartHeartbeat(ctx, 5*time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for observed.FilterMessage("still running").Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+	beats := observed.FilterMessage("still running").All()
+	if len(beats) < 2 {
+		t.Fatalf("expected at least 2 heartbeats, got %d", len(beats))
+	}
+
+	fields := beats[1].ContextMap()
+	if fields[OperationKey] != "import_users" || fields[OperationIDKey] == nil {
+		t.Errorf("expected operation and context fields, got %v", fields)
+	}
+	if elapsed, _ := fields[ElapsedKey].(time.Duration); elapsed < 10*time.Millisecond {
+		t.Errorf("expected elapsed time of at least 10ms, got %v", fields[ElapsedKey])
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if n := observed.FilterMessage("still running").Len(); n != len(beats) {
+		t.Errorf("expected no heartbeat after stop, got %d more", n-len(beats))
+	}
+}
+
+func TestHeartbeatStopsWithContext(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	ctx, cancel := context.WithCancel(WithLogger(context.Background(), New(zap.New(core))))
+	stop := StartHeartbeat(ctx, time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	n := observed.Len()
+
+	time.Sleep(10 * time.Millisecond)
+	if observed.Len() != n {
+		t.Error("expected heartbeats to stop when the context is done")
+	}
+	stop()
+}
+
+func TestHeartbeatDefaultInterval(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	ctx := WithLogger(context.Background(), New(zap.New(core)))
+
+	// A zero interval would make time.NewTicker panic.
+	stop := StartHeartbeat(ctx, 0)
+	stop()
+	if n := observed.Len(); n != 0 {
+		t.Errorf("expected no heartbeat within the default interval, got %d", n)
+	}
+}
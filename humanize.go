@@ -0,0 +1,102 @@
+package ctxzap
+
+import (
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// humanSuffix is appended to the key of the humanized companion field.
+const humanSuffix = "_human"
+
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// Bytes returns a field logging a byte count twice: the raw number under key
+// and a human-readable form (e.g. "1.5 MiB") under key + "_human". The raw
+// value stays queryable by dashboards while the companion is easy to read.
+func Bytes(key string, n int64) zap.Field {
+	return humanized(key, byteCount{key: key, n: n})
+}
+
+// Rate returns a field logging the per-second rate of n events observed over
+// window: the raw rate under key and a human-readable form (e.g. "1.2k/s")
+// under key + "_human". A non-positive window yields a rate of zero.
+func Rate(key string, n float64, window time.Duration) zap.Field {
+	var perSecond float64
+	if window > 0 {
+		perSecond = n / window.Seconds()
+	}
+	return humanized(key, rate{key: key, perSecond: perSecond})
+}
+
+// humanized returns an inline field adding the fields of m, under key so
+// that it is merged and deduplicated like the raw field it stands for.
+func humanized(key string, m zapcore.ObjectMarshaler) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.InlineMarshalerType, Interface: m}
+}
+
+type byteCount struct {
+	key string
+	n   int64
+}
+
+func (b byteCount) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt64(b.key, b.n)
+	enc.AddString(b.key+humanSuffix, HumanizeBytes(b.n))
+	return nil
+}
+
+type rate struct {
+	key       string
+	perSecond float64
+}
+
+func (r rate) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddFloat64(r.key, r.perSecond)
+	enc.AddString(r.key+humanSuffix, HumanizeRate(r.perSecond))
+	return nil
+}
+
+// HumanizeBytes formats n using binary units, e.g. 1536 becomes "1.5 KiB".
+func HumanizeBytes(n int64) string {
+	neg := n < 0
+	abs := uint64(n)
+	if neg {
+		// -n overflows for math.MinInt64; negate in unsigned arithmetic.
+		abs = -abs
+	}
+
+	value := float64(abs)
+	unit := 0
+	for value >= 1024 && unit < len(byteUnits)-1 {
+		value /= 1024
+		unit++
+	}
+
+	var s string
+	if unit == 0 {
+		s = strconv.FormatUint(abs, 10) + " " + byteUnits[0]
+	} else {
+		s = strconv.FormatFloat(value, 'f', 1, 64) + " " + byteUnits[unit]
+	}
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// HumanizeRate formats a per-second rate using SI suffixes, e.g. 1500 becomes
+// "1.5k/s".
+func HumanizeRate(perSecond float64) string {
+	suffixes := []string{"", "k", "M", "G", "T"}
+
+	value := perSecond
+	unit := 0
+	for (value >= 1000 || value <= -1000) && unit < len(suffixes)-1 {
+		value /= 1000
+		unit++
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + suffixes[unit] + "/s"
+}
@@ -0,0 +1,69 @@
+package ctxzap
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHumanizeBytes(t *testing.T) {
+	tests := []struct {
+		n        int64
+		expected string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{-2048, "-2.0 KiB"},
+		{math.MinInt64, "-8.0 EiB"},
+	}
+
+	for _, tt := range tests {
+		if got := HumanizeBytes(tt.n); got != tt.expected {
+			t.Errorf("HumanizeBytes(%d): expected %q, got %q", tt.n, tt.expected, got)
+		}
+	}
+}
+
+func TestBytesAndRateFields(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	Bytes("resp_size", 1536).AddTo(enc)
+	Rate("throughput", 3000, 2*time.Second).AddTo(enc)
+
+	expected := map[string]interface{}{
+		"resp_size":        int64(1536),
+		"resp_size_human":  "1.5 KiB",
+		"throughput":       float64(1500),
+		"throughput_human": "1.5k/s",
+	}
+
+	for k, v := range expected {
+		if enc.Fields[k] != v {
+			t.Errorf("field %q: expected %v, got %v", k, v, enc.Fields[k])
+		}
+	}
+}
+
+func TestBytesFieldsKeepTheirKeys(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithFields(context.Background(), zap.String("request_id", "abc"), Bytes("limit", 1024), Bytes("quota", 2048))
+
+	logger.Info(ctx, "transfer", Bytes("req", 1), Bytes("resp", 2), Rate("throughput", 10, time.Second))
+
+	fields := observed.All()[0].ContextMap()
+	for _, key := range []string{"request_id", "limit", "quota", "req", "resp", "throughput"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("expected %q, got %v", key, fields)
+		}
+	}
+	if fields["resp_human"] != "2 B" {
+		t.Errorf("unexpected humanized field: %v", fields)
+	}
+}
@@ -0,0 +1,53 @@
+package ctxzap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithIntegrity(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithIntegrity())
+	child := logger.With(zap.String("component", "billing"))
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+	logger.Info(ctx, "first")
+	child.Info(ctx, "second")
+	logger.Debug(ctx, "disabled")
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+
+	enc := zapcore.NewJSONEncoder(integrityEncoderConfig)
+	var prev [sha256.Size]byte
+	for i, entry := range entries {
+		fields := entry.ContextMap()
+		if fields[SequenceKey] != uint64(i+1) {
+			t.Errorf("entry %d: expected seq %d, got %v", i, i+1, fields[SequenceKey])
+		}
+
+		// Recompute the checksum from the entry's original fields.
+		original := entry.Context[:len(entry.Context)-2]
+		hashed := zapcore.Entry{Level: entry.Level, Time: entry.Time, Message: entry.Message}
+		buf, err := enc.EncodeEntry(hashed, original)
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		h := sha256.New()
+		h.Write(prev[:])
+		h.Write(buf.Bytes())
+		h.Sum(prev[:0])
+
+		if fields[ChecksumKey] != hex.EncodeToString(prev[:]) {
+			t.Errorf("entry %d: checksum does not match the chain", i)
+		}
+	}
+}
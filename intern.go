@@ -0,0 +1,14 @@
+package ctxzap
+
+import "github.com/algobardo/ctxzap/internal/fieldcodec"
+
+// InternStrings registers high-repetition field keys and string values, such
+// as service names, routes or tenant IDs, that are decoded without
+// allocating when fields are restored by UnmarshalFields, DecodeEnv or
+// Propagator.Extract: every decoded copy shares the registered string, which
+// reduces allocation and retained memory in services restoring fields on
+// every request. Nothing is interned unless registered, so decoded input
+// cannot grow the table. It is safe for concurrent use.
+func InternStrings(values ...string) {
+	fieldcodec.Intern(values...)
+}
@@ -0,0 +1,303 @@
+// Package fieldcodec serializes zap fields to JSON while preserving their
+// types, so they can be stored or transported and later restored as fields
+// that encode the same way as the originals.
+package fieldcodec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Wire type names.
+const (
+	typeBool      = "bool"
+	typeInt       = "int"
+	typeUint      = "uint"
+	typeUintptr   = "uintptr"
+	typeFloat     = "float"
+	typeComplex   = "complex"
+	typeString    = "string"
+	typeBinary    = "binary"
+	typeByteStr   = "bytestring"
+	typeDuration  = "duration"
+	typeTime      = "time"
+	typeError     = "error"
+	typeAny       = "any"
+	typeInline    = "inline"
+	typeNamespace = "namespace"
+)
+
+// Field is the wire representation of a zap.Field.
+type Field struct {
+	Key   string          `json:"k"`
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+// Marshal encodes fields as a JSON array. Skip fields are dropped. Values
+// without a dedicated wire type (objects, arrays, reflected values) are
+// stored in their JSON form and restored with zap.Any.
+func Marshal(fields []zap.Field) ([]byte, error) {
+	wire, err := ToWire(fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(wire)
+}
+
+// Unmarshal decodes a JSON array produced by Marshal.
+func Unmarshal(data []byte) ([]zap.Field, error) {
+	var wire []internedField
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, err
+	}
+
+	fields := make([]zap.Field, 0, len(wire))
+	for _, w := range wire {
+		f, err := fromWire(Field{Key: string(w.Key), Type: string(w.Type), Value: w.Value})
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", w.Key, err)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// ToWire converts fields to their wire representation.
+func ToWire(fields []zap.Field) ([]Field, error) {
+	wire := make([]Field, 0, len(fields))
+	for i := range fields {
+		w, ok, err := toWire(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", fields[i].Key, err)
+		}
+		if ok {
+			wire = append(wire, w)
+		}
+	}
+	return wire, nil
+}
+
+// FromWire converts wire fields back to zap fields.
+func FromWire(wire []Field) ([]zap.Field, error) {
+	fields := make([]zap.Field, 0, len(wire))
+	for _, w := range wire {
+		f, err := fromWire(w)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", w.Key, err)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+//nolint:gocyclo // one case per zap field type
+func toWire(f zap.Field) (Field, bool, error) {
+	var (
+		typ   string
+		value any
+	)
+
+	switch f.Type {
+	case zapcore.BoolType:
+		typ, value = typeBool, f.Integer == 1
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		typ, value = typeInt, f.Integer
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
+		typ, value = typeUint, uint64(f.Integer)
+	case zapcore.UintptrType:
+		typ, value = typeUintptr, uint64(f.Integer)
+	case zapcore.Float64Type:
+		typ, value = typeFloat, formatFloat(math.Float64frombits(uint64(f.Integer)), 64)
+	case zapcore.Float32Type:
+		typ, value = typeFloat, formatFloat(float64(math.Float32frombits(uint32(f.Integer))), 32)
+	case zapcore.Complex128Type:
+		c := f.Interface.(complex128)
+		typ, value = typeComplex, [2]string{formatFloat(real(c), 64), formatFloat(imag(c), 64)}
+	case zapcore.Complex64Type:
+		c := f.Interface.(complex64)
+		typ, value = typeComplex, [2]string{formatFloat(float64(real(c)), 32), formatFloat(float64(imag(c)), 32)}
+	case zapcore.StringType:
+		typ, value = typeString, f.String
+	case zapcore.BinaryType:
+		typ, value = typeBinary, f.Interface.([]byte)
+	case zapcore.ByteStringType:
+		typ, value = typeByteStr, f.Interface.([]byte)
+	case zapcore.DurationType:
+		typ, value = typeDuration, f.Integer
+	case zapcore.TimeType:
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Interface.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		typ, value = typeTime, t.Format(time.RFC3339Nano)
+	case zapcore.TimeFullType:
+		typ, value = typeTime, f.Interface.(time.Time).Format(time.RFC3339Nano)
+	case zapcore.ErrorType:
+		err, ok := f.Interface.(error)
+		if !ok || err == nil {
+			return Field{}, false, nil
+		}
+		typ, value = typeError, err.Error()
+	case zapcore.StringerType:
+		s, ok := f.Interface.(fmt.Stringer)
+		if !ok || s == nil {
+			return Field{}, false, nil
+		}
+		typ, value = typeString, s.String()
+	case zapcore.NamespaceType:
+		return Field{Key: f.Key, Type: typeNamespace}, true, nil
+	case zapcore.InlineMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		typ, value = typeInline, enc.Fields
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		typ, value = typeAny, enc.Fields[f.Key]
+	default:
+		// SkipType and UnknownType carry nothing to encode.
+		return Field{}, false, nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return Field{}, false, err
+	}
+	return Field{Key: f.Key, Type: typ, Value: raw}, true, nil
+}
+
+//nolint:gocyclo // one case per wire type
+func fromWire(w Field) (zap.Field, error) {
+	switch w.Type {
+	case typeBool:
+		var v bool
+		err := json.Unmarshal(w.Value, &v)
+		return zap.Bool(w.Key, v), err
+	case typeInt:
+		var v int64
+		err := json.Unmarshal(w.Value, &v)
+		return zap.Int64(w.Key, v), err
+	case typeUint:
+		var v uint64
+		err := json.Unmarshal(w.Value, &v)
+		return zap.Uint64(w.Key, v), err
+	case typeUintptr:
+		var v uint64
+		err := json.Unmarshal(w.Value, &v)
+		return zap.Uintptr(w.Key, uintptr(v)), err
+	case typeFloat:
+		v, err := parseFloat(w.Value)
+		return zap.Float64(w.Key, v), err
+	case typeComplex:
+		var parts [2]json.RawMessage
+		if err := json.Unmarshal(w.Value, &parts); err != nil {
+			return zap.Field{}, err
+		}
+		re, err := parseFloat(parts[0])
+		if err != nil {
+			return zap.Field{}, err
+		}
+		im, err := parseFloat(parts[1])
+		return zap.Complex128(w.Key, complex(re, im)), err
+	case typeString:
+		var v internedString
+		err := v.UnmarshalJSON(w.Value)
+		return zap.String(w.Key, string(v)), err
+	case typeBinary:
+		v, err := decodeBytes(w.Value)
+		return zap.Binary(w.Key, v), err
+	case typeByteStr:
+		v, err := decodeBytes(w.Value)
+		return zap.ByteString(w.Key, v), err
+	case typeDuration:
+		var v int64
+		err := json.Unmarshal(w.Value, &v)
+		return zap.Duration(w.Key, time.Duration(v)), err
+	case typeTime:
+		var s string
+		if err := json.Unmarshal(w.Value, &s); err != nil {
+			return zap.Field{}, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return zap.Time(w.Key, t), err
+	case typeError:
+		var s string
+		err := json.Unmarshal(w.Value, &s)
+		return zap.NamedError(w.Key, errors.New(s)), err
+	case typeNamespace:
+		return zap.Namespace(w.Key), nil
+	case typeInline:
+		var m map[string]any
+		if err := unmarshalAny(w.Value, &m); err != nil {
+			return zap.Field{}, err
+		}
+		// Keep the key, if any, so that restored fields still deduplicate.
+		f := zap.Inline(mapObject(m))
+		f.Key = w.Key
+		return f, nil
+	case typeAny:
+		var v any
+		err := unmarshalAny(w.Value, &v)
+		return zap.Any(w.Key, v), err
+	default:
+		return zap.Field{}, fmt.Errorf("unknown wire type %q", w.Type)
+	}
+}
+
+// formatFloat encodes floats as strings so NaN and infinities survive JSON.
+func formatFloat(f float64, bitSize int) string {
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+func parseFloat(raw json.RawMessage) (float64, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func decodeBytes(raw json.RawMessage) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// unmarshalAny decodes JSON keeping numbers as json.Number, which re-encodes
+// without loss of precision.
+func unmarshalAny(raw json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// mapObject restores the fields of an inline marshaler.
+type mapObject map[string]any
+
+func (m mapObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := enc.AddReflected(k, m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package fieldcodec
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type point struct {
+	X, Y int
+}
+
+func (p point) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("x", p.X)
+	enc.AddInt("y", p.Y)
+	return nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 123, time.UTC)
+
+	fields := []zap.Field{
+		zap.Bool("bool", true),
+		zap.Int("int", -42),
+		zap.Uint64("uint", 7),
+		zap.Float64("float", 1.5),
+		zap.Float64("nan", math.NaN()),
+		zap.Complex128("complex", complex(1, -2)),
+		zap.String("string", "value"),
+		zap.Binary("binary", []byte{0, 1, 2}),
+		zap.Duration("duration", 1500*time.Millisecond),
+		zap.Time("time", ts),
+		zap.Error(errors.New("boom")),
+		zap.Stringer("stringer", time.Second),
+		zap.Object("object", point{X: 1, Y: 2}),
+		zap.Any("reflect", map[string]int{"a": 1}),
+		{Key: "inline", Type: zapcore.InlineMarshalerType, Interface: point{X: 3, Y: 4}},
+		zap.Skip(),
+	}
+
+	data, err := Marshal(fields)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	restored, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if len(restored) != len(fields)-1 {
+		t.Fatalf("expected %d fields, got %d", len(fields)-1, len(restored))
+	}
+
+	original := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(original)
+	}
+	decoded := zapcore.NewMapObjectEncoder()
+	for _, f := range restored {
+		f.AddTo(decoded)
+	}
+
+	for _, key := range []string{"bool", "int", "uint", "float", "complex", "string", "duration", "error", "stringer"} {
+		if original.Fields[key] != decoded.Fields[key] {
+			t.Errorf("field %q: expected %v (%T), got %v (%T)",
+				key, original.Fields[key], original.Fields[key], decoded.Fields[key], decoded.Fields[key])
+		}
+	}
+
+	if got := decoded.Fields["time"].(time.Time); !got.Equal(ts) {
+		t.Errorf("time: expected %v, got %v", ts, got)
+	}
+	if got := decoded.Fields["nan"].(float64); !math.IsNaN(got) {
+		t.Errorf("nan: expected NaN, got %v", got)
+	}
+	if inline := restored[len(restored)-1]; inline.Key != "inline" || fmt.Sprint(decoded.Fields["x"]) != "3" {
+		t.Errorf("expected the inline field restored with its key, got %v and %v", inline, decoded.Fields)
+	}
+	if restored[1].Type != zapcore.Int64Type || restored[8].Type != zapcore.DurationType {
+		t.Error("expected integer and duration types to be preserved")
+	}
+}
@@ -0,0 +1,72 @@
+package fieldcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// interned maps strings to a canonical copy. Lookups with a []byte key
+// converted in the index expression do not allocate. The wire type names
+// are always interned; other strings only once registered with Intern, so
+// that decoded input, which may be untrusted, never grows the table.
+var interned = struct {
+	sync.RWMutex
+	m map[string]string
+}{m: internTable(typeBool, typeInt, typeUint, typeUintptr, typeFloat, typeComplex, typeString,
+	typeBinary, typeByteStr, typeDuration, typeTime, typeError, typeAny, typeInline, typeNamespace)}
+
+func internTable(values ...string) map[string]string {
+	m := make(map[string]string, len(values))
+	for _, v := range values {
+		m[v] = v
+	}
+	return m
+}
+
+// Intern registers field keys and values, such as service names or routes,
+// whose decoded copies are replaced by a shared one, without allocating.
+func Intern(values ...string) {
+	interned.Lock()
+	defer interned.Unlock()
+	for _, v := range values {
+		interned.m[v] = v
+	}
+}
+
+// lookup returns the interned copy of the JSON string literal raw, if raw
+// has no escape sequences and is interned.
+func lookup(raw []byte) (string, bool) {
+	if len(raw) < 2 || raw[0] != '"' || raw[len(raw)-1] != '"' || bytes.IndexByte(raw, '\\') >= 0 {
+		return "", false
+	}
+	interned.RLock()
+	s, ok := interned.m[string(raw[1:len(raw)-1])]
+	interned.RUnlock()
+	return s, ok
+}
+
+// internedString is a JSON string decoded to its interned copy when there
+// is one.
+type internedString string
+
+func (s *internedString) UnmarshalJSON(raw []byte) error {
+	if v, ok := lookup(raw); ok {
+		*s = internedString(v)
+		return nil
+	}
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	*s = internedString(v)
+	return nil
+}
+
+// internedField is the form in which Unmarshal decodes wire fields, to
+// intern their keys and types.
+type internedField struct {
+	Key   internedString  `json:"k"`
+	Type  internedString  `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
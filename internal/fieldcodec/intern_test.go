@@ -0,0 +1,73 @@
+package fieldcodec
+
+import (
+	"testing"
+	"unsafe"
+
+	"go.uber.org/zap"
+)
+
+func TestInternedValues(t *testing.T) {
+	Intern("service", "checkout-service")
+	data, err := Marshal([]zap.Field{
+		zap.String("service", "checkout-service"),
+		zap.String("escaped", "a\"b"),
+		zap.String("other", "not interned"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first[0].String != "checkout-service" || first[1].String != "a\"b" || first[2].String != "not interned" {
+		t.Fatalf("unexpected values: %v", first)
+	}
+	if unsafe.StringData(first[0].String) != unsafe.StringData(second[0].String) {
+		t.Error("expected interned values to share their data")
+	}
+	if unsafe.StringData(first[0].Key) != unsafe.StringData(second[0].Key) {
+		t.Error("expected registered keys to be interned")
+	}
+	if unsafe.StringData(first[2].Key) == unsafe.StringData(second[2].Key) {
+		t.Error("expected keys not to be interned unless registered")
+	}
+	interned.RLock()
+	_, learned := interned.m["other"]
+	interned.RUnlock()
+	if learned {
+		t.Error("expected decoded keys not to be added to the table")
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	Intern("service", "route", "checkout-service", "/api/v1/orders")
+	interned, _ := Marshal([]zap.Field{
+		zap.String("service", "checkout-service"),
+		zap.String("route", "/api/v1/orders"),
+	})
+	other, _ := Marshal([]zap.Field{
+		zap.String("service", "payment-service"),
+		zap.String("route", "/api/v1/payments"),
+	})
+
+	b.Run("interned values", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = Unmarshal(interned)
+		}
+	})
+	b.Run("other values", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = Unmarshal(other)
+		}
+	})
+}
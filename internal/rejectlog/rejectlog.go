@@ -0,0 +1,83 @@
+// Package rejectlog summarizes frequent rejections, such as calls refused by
+// a circuit breaker or a rate limiter, into periodic log entries.
+package rejectlog
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/algobardo/ctxzap"
+)
+
+// RejectedKey is the key of the field counting the rejections summarized by
+// an entry.
+const RejectedKey = "rejected"
+
+// Summarizer logs the first rejection immediately, then at most one entry
+// per interval counting the rejections since the previous entry. Each entry
+// carries the context fields of the rejection that triggered it; the entry
+// logged by Sync carries those of the last pending rejection.
+type Summarizer struct {
+	logger   *ctxzap.Logger
+	msg      string
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	last    time.Time
+	pending int
+	// ctx and fields are those of the last pending rejection.
+	ctx    context.Context
+	fields []zap.Field
+}
+
+// New creates a Summarizer logging msg at Warn level with logger.
+func New(logger *ctxzap.Logger, msg string, interval time.Duration) *Summarizer {
+	return &Summarizer{logger: logger, msg: msg, interval: interval, now: time.Now}
+}
+
+// Reject records a rejection, logging a summary if one is due.
+func (s *Summarizer) Reject(ctx context.Context, fields ...zap.Field) {
+	s.mu.Lock()
+	s.pending++
+	now := s.now()
+	if !s.last.IsZero() && now.Sub(s.last) < s.interval {
+		s.ctx, s.fields = ctx, append(s.fields[:0], fields...)
+		s.mu.Unlock()
+		return
+	}
+	rejected := s.pending
+	s.pending = 0
+	s.last = now
+	s.ctx = nil
+	clear(s.fields)
+	s.fields = s.fields[:0]
+	s.mu.Unlock()
+
+	s.log(ctx, fields, rejected)
+}
+
+// Sync logs the rejections not reported yet, if any, then flushes the
+// logger. Call it before shutting down, so that the last interval's
+// rejections are not lost.
+func (s *Summarizer) Sync() error {
+	s.mu.Lock()
+	rejected, ctx, fields := s.pending, s.ctx, s.fields
+	s.pending = 0
+	s.ctx, s.fields = nil, nil
+	s.mu.Unlock()
+
+	if rejected > 0 {
+		s.log(ctx, fields, rejected)
+	}
+	return s.logger.Sync()
+}
+
+// log logs the summary of a number of rejections. fields is not modified.
+func (s *Summarizer) log(ctx context.Context, fields []zap.Field, rejected int) {
+	s.logger.Warn(ctx, s.msg, append(slices.Clip(fields), zap.Int(RejectedKey, rejected))...)
+}
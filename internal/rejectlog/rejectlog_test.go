@@ -0,0 +1,76 @@
+package rejectlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/algobardo/ctxzap"
+)
+
+func TestSummarizer(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	s := New(ctxzap.New(zap.New(core)), "rejected", time.Minute)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	ctx := ctxzap.WithFields(context.Background(), zap.String("request_id", "req-1"))
+	s.Reject(ctx)
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Second)
+		s.Reject(ctx)
+	}
+	now = now.Add(time.Minute)
+	s.Reject(ctx)
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for i, want := range []int64{1, 6} {
+		fields := entries[i].ContextMap()
+		if fields[RejectedKey] != want {
+			t.Errorf("entry %d: expected %d rejections, got %v", i, want, fields[RejectedKey])
+		}
+		if fields["request_id"] != "req-1" {
+			t.Errorf("entry %d: expected context fields, got %v", i, fields)
+		}
+	}
+}
+
+func TestSummarizerSync(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	s := New(ctxzap.New(zap.New(core)), "rejected", time.Minute)
+
+	fields := make([]zap.Field, 1, 2)
+	fields[0] = zap.String("limiter", "api")
+	s.Reject(context.Background(), fields...)
+	for i := 0; i < 3; i++ {
+		ctx := ctxzap.WithFields(context.Background(), zap.Int("attempt", i))
+		s.Reject(ctx, fields...)
+	}
+	if spare := fields[:2][1]; spare.Key != "" {
+		t.Errorf("expected the caller's array to be left alone, got %v", spare)
+	}
+
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected the pending rejections to be logged once by Sync, got %d entries", len(entries))
+	}
+	got := entries[1].ContextMap()
+	if got[RejectedKey] != int64(3) || got["limiter"] != "api" || got["attempt"] != int64(2) {
+		t.Errorf("expected the last rejection's fields and 3 rejections, got %v", got)
+	}
+}
@@ -0,0 +1,99 @@
+package ctxzap
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RenameKeys returns a Transformer applying rename to the key of every field.
+// DotsToUnderscores and UnderscoresToDots are ready-made rename functions.
+func RenameKeys(rename func(key string) string) Transformer {
+	return func(fields []zap.Field) []zap.Field {
+		for i := range fields {
+			fields[i].Key = rename(fields[i].Key)
+		}
+		return fields
+	}
+}
+
+// DotsToUnderscores converts dotted keys to underscores, e.g. "http.method"
+// becomes "http_method".
+func DotsToUnderscores(key string) string {
+	return strings.ReplaceAll(key, ".", "_")
+}
+
+// UnderscoresToDots converts underscores to dots, e.g. "http_method" becomes
+// "http.method". It applies to every underscore, so it suits key sets that
+// use underscores only as namespace separators.
+func UnderscoresToDots(key string) string {
+	return strings.ReplaceAll(key, "_", ".")
+}
+
+// NestDottedKeys returns a Transformer grouping fields with dotted keys into
+// nested objects, for sinks that expect nested JSON: "http.method" and
+// "http.status" become an "http" object with "method" and "status" keys.
+// Groups appear at the position of their first field. Fields added with
+// With are transformed separately from those of each entry, so a prefix
+// shared between them produces two objects.
+func NestDottedKeys() Transformer {
+	return UnflattenFields
+}
+
+// UnflattenFields returns fields with dotted keys grouped into nested
+// objects, as described in NestDottedKeys. It is the reverse of FlattenFields.
+func UnflattenFields(fields []zap.Field) []zap.Field {
+	for i := range fields {
+		if strings.Contains(fields[i].Key, ".") {
+			return nestFields(fields)
+		}
+	}
+	return fields
+}
+
+// nestFields groups fields by the first segment of dotted keys, recursively.
+func nestFields(fields []zap.Field) []zap.Field {
+	type group struct {
+		index  int
+		prefix string
+		fields []zap.Field
+	}
+	var groups []*group
+	byPrefix := make(map[string]*group)
+
+	result := make([]zap.Field, 0, len(fields))
+	for _, f := range fields {
+		prefix, rest, ok := strings.Cut(f.Key, ".")
+		if !ok || prefix == "" || rest == "" {
+			result = append(result, f)
+			continue
+		}
+
+		f.Key = rest
+		g, exists := byPrefix[prefix]
+		if !exists {
+			// Reserve the group's position; filled in once all members are known.
+			g = &group{index: len(result), prefix: prefix}
+			byPrefix[prefix] = g
+			groups = append(groups, g)
+			result = append(result, zap.Skip())
+		}
+		g.fields = append(g.fields, f)
+	}
+
+	for _, g := range groups {
+		result[g.index] = zap.Object(g.prefix, fieldGroup(nestFields(g.fields)))
+	}
+	return result
+}
+
+// fieldGroup encodes a list of fields as an object.
+type fieldGroup []zap.Field
+
+func (g fieldGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for i := range g {
+		g[i].AddTo(enc)
+	}
+	return nil
+}
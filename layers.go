@@ -0,0 +1,58 @@
+package ctxzap
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// connectionContextKey is used as a key for storing the fields of the
+// connection layer in context
+type connectionContextKey struct{}
+
+var connectionKey = connectionContextKey{}
+
+// WithConnectionFields adds fields to the context and marks all of its fields,
+// including those added before, as the connection layer of a streaming
+// server (a gRPC stream, a WebSocket, a queue consumer): fields added
+// afterwards form the message layer, which ResetMessageFields and
+// WithMessageFields discard, so that per-message fields cannot leak into the
+// logs of later messages:
+//
+//	ctx = ctxzap.WithConnectionFields(ctx, zap.String("conn_id", conn.ID()))
+//	for msg := range conn.Messages() {
+//		ctx = ctxzap.WithMessageFields(ctx, zap.String("message_id", msg.ID))
+//		handle(ctx, msg)
+//	}
+//
+// Calling it again extends the connection layer.
+func WithConnectionFields(ctx context.Context, fields ...zap.Field) context.Context {
+	layer := &connectionLayer{}
+	ctx = context.WithValue(WithFields(ctx, fields...), connectionKey, layer)
+	layer.ctx = ctx
+	return ctx
+}
+
+// connectionLayer holds the context returned by WithConnectionFields.
+type connectionLayer struct {
+	ctx context.Context
+}
+
+// ResetMessageFields returns the context returned when WithConnectionFields
+// was last called on ctx or its parents, discarding the message layer along
+// with any other value added to ctx since, so that a loop over messages does
+// not grow the context. It returns ctx unchanged if WithConnectionFields was
+// never called.
+func ResetMessageFields(ctx context.Context) context.Context {
+	layer, ok := ctx.Value(connectionKey).(*connectionLayer)
+	if !ok {
+		return ctx
+	}
+	return layer.ctx
+}
+
+// WithMessageFields starts a new message layer: it discards the fields of the
+// previous message with ResetMessageFields, then adds fields.
+func WithMessageFields(ctx context.Context, fields ...zap.Field) context.Context {
+	return WithFields(ResetMessageFields(ctx), fields...)
+}
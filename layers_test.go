@@ -0,0 +1,79 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestConnectionAndMessageLayers(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	ctx := WithFields(context.Background(), zap.String("service", "chat"))
+	if got := ResetMessageFields(ctx); got != ctx {
+		t.Error("expected no change without a connection layer")
+	}
+
+	ctx = WithConnectionFields(ctx, zap.String("conn_id", "c1"))
+	for _, id := range []string{"m1", "m2"} {
+		ctx = WithMessageFields(ctx, zap.String("message_id", id))
+		if id == "m1" {
+			ctx = WithFields(ctx, zap.String("user", "alice"))
+		}
+		logger.Info(ctx, "message handled")
+	}
+
+	entries := observed.All()
+	if m := entries[0].ContextMap(); m["user"] != "alice" || m["message_id"] != "m1" || len(m) != 4 {
+		t.Errorf("unexpected first message fields: %v", m)
+	}
+	if m := entries[1].ContextMap(); m["message_id"] != "m2" || m["conn_id"] != "c1" || m["service"] != "chat" || len(m) != 3 {
+		t.Errorf("expected the first message's fields to be discarded, got %v", m)
+	}
+
+	// The connection layer can be extended, and the message layer reset alone.
+	ctx = WithConnectionFields(ctx, zap.String("peer", "10.0.0.1"))
+	ctx = WithFields(ctx, zap.Int("attempt", 1))
+	ctx = ResetMessageFields(ctx)
+	if HasField(ctx, "attempt") || !HasField(ctx, "peer") || !HasField(ctx, "message_id") {
+		t.Errorf("unexpected fields after reset: %v", FieldsFromContext(ctx))
+	}
+}
+
+func TestResetMessageFieldsProvenance(t *testing.T) {
+	EnableProvenance(true)
+	defer EnableProvenance(false)
+
+	ctx := WithConnectionFields(context.Background(), zap.String("conn_id", "c1"))
+	ctx = ResetMessageFields(WithFields(ctx, zap.String("message_id", "m1")))
+	origins := FieldProvenance(ctx)
+	if len(origins) != 1 || origins[0].Key != "conn_id" {
+		t.Errorf("expected only the connection field's origin, got %v", origins)
+	}
+}
+
+type messageKey struct{}
+
+func TestMessageLayersDoNotGrowTheContext(t *testing.T) {
+	conn := WithConnectionFields(context.Background(), zap.String("conn_id", "c1"))
+
+	ctx := conn
+	for i := 0; i < 10000; i++ {
+		ctx = WithMessageFields(ctx, zap.Int("message", i))
+		ctx = context.WithValue(ctx, messageKey{}, i)
+		ctx = WithFields(ctx, zap.String("user", "alice"))
+	}
+
+	// Each message starts again from the connection context, so the chain
+	// of contexts holds one message at most.
+	if ResetMessageFields(ctx) != conn {
+		t.Error("expected the reset to return the connection context")
+	}
+	if m := FieldsMapFromContext(ctx); m["message"] != int64(9999) || len(m) != 3 {
+		t.Errorf("unexpected fields: %v", m)
+	}
+}
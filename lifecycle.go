@@ -0,0 +1,45 @@
+package ctxzap
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LifecycleEventKey is the key of the field identifying lifecycle events, so
+// that runbooks and alerts can match them without relying on message text.
+const LifecycleEventKey = "lifecycle_event"
+
+// Values of the lifecycle_event field.
+const (
+	EventBecameLeader    = "became_leader"
+	EventLostLeadership  = "lost_leadership"
+	EventConfigReloaded  = "config_reloaded"
+	EventDrainingStarted = "draining_started"
+)
+
+// BecameLeader logs at InfoLevel that this instance acquired leadership.
+func (l *Logger) BecameLeader(ctx context.Context, fields ...zap.Field) {
+	l.Logger.Info("became leader", l.fields(ctx, zapcore.InfoLevel, lifecycleFields(EventBecameLeader, fields), nil)...)
+}
+
+// LostLeadership logs at WarnLevel that this instance lost leadership.
+func (l *Logger) LostLeadership(ctx context.Context, fields ...zap.Field) {
+	l.Logger.Warn("lost leadership", l.fields(ctx, zapcore.WarnLevel, lifecycleFields(EventLostLeadership, fields), nil)...)
+}
+
+// ConfigReloaded logs at InfoLevel that the configuration was reloaded.
+func (l *Logger) ConfigReloaded(ctx context.Context, fields ...zap.Field) {
+	l.Logger.Info("config reloaded", l.fields(ctx, zapcore.InfoLevel, lifecycleFields(EventConfigReloaded, fields), nil)...)
+}
+
+// DrainingStarted logs at InfoLevel that this instance stopped accepting new
+// work and is draining in-flight work before shutting down.
+func (l *Logger) DrainingStarted(ctx context.Context, fields ...zap.Field) {
+	l.Logger.Info("draining started", l.fields(ctx, zapcore.InfoLevel, lifecycleFields(EventDrainingStarted, fields), nil)...)
+}
+
+func lifecycleFields(event string, fields []zap.Field) []zap.Field {
+	return append([]zap.Field{zap.String(LifecycleEventKey, event)}, fields...)
+}
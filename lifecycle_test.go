@@ -0,0 +1,48 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLifecycleEvents(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithFields(context.Background(), zap.String("instance", "pod-1"))
+
+	logger.BecameLeader(ctx, zap.Int64("term", 7))
+	logger.LostLeadership(ctx)
+	logger.ConfigReloaded(ctx)
+	logger.DrainingStarted(ctx)
+
+	expected := []struct {
+		event string
+		level zapcore.Level
+	}{
+		{EventBecameLeader, zapcore.InfoLevel},
+		{EventLostLeadership, zapcore.WarnLevel},
+		{EventConfigReloaded, zapcore.InfoLevel},
+		{EventDrainingStarted, zapcore.InfoLevel},
+	}
+
+	entries := observed.All()
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, want := range expected {
+		fields := entries[i].ContextMap()
+		if fields[LifecycleEventKey] != want.event || entries[i].Level != want.level {
+			t.Errorf("entry %d: expected %s at %v, got %v at %v", i, want.event, want.level, fields[LifecycleEventKey], entries[i].Level)
+		}
+		if fields["instance"] != "pod-1" {
+			t.Errorf("entry %d: expected context fields, got %v", i, fields)
+		}
+	}
+	if entries[0].ContextMap()["term"] != int64(7) {
+		t.Errorf("expected call-site fields, got %v", entries[0].ContextMap())
+	}
+}
@@ -0,0 +1,114 @@
+package ctxzap
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// TrimmedFieldsKey is the key of the field listing the keys removed by a
+// FieldLimit.
+const TrimmedFieldsKey = "trimmed_fields"
+
+// DefaultKeepKeys are never trimmed when FieldLimit.Keep is nil.
+var DefaultKeepKeys = []string{"request_id", "trace_id"}
+
+// FieldLimit bounds the number of fields written per entry.
+type FieldLimit struct {
+	// MaxFields is the maximum number of fields in an entry, including the
+	// trimmed_fields report. Zero or negative disables the limit.
+	MaxFields int
+	// Priority lists keys from most to least important. Fields whose key is
+	// not listed are trimmed before any listed field, last-added first.
+	Priority []string
+	// Keep lists keys that are never trimmed. If nil, DefaultKeepKeys is used.
+	Keep []string
+}
+
+// WithFieldLimit makes the logger enforce limit on every entry. When an
+// entry has too many fields, the lowest-priority ones are removed and their
+// keys are reported in a "trimmed_fields" field.
+func WithFieldLimit(limit FieldLimit) Option {
+	return func(l *Logger) {
+		l.limit = newFieldLimit(limit)
+	}
+}
+
+// newFieldLimit returns the fieldLimit enforcing limit, or nil if limit
+// does not bound the number of fields.
+func newFieldLimit(limit FieldLimit) *fieldLimit {
+	if limit.MaxFields <= 0 {
+		return nil
+	}
+
+	keep := limit.Keep
+	if keep == nil {
+		keep = DefaultKeepKeys
+	}
+
+	ranks := make(map[string]int, len(limit.Priority)+len(keep))
+	for i, key := range limit.Priority {
+		ranks[key] = i
+	}
+	for _, key := range keep {
+		ranks[key] = -1
+	}
+
+	return &fieldLimit{max: limit.MaxFields, ranks: ranks, unlisted: len(limit.Priority)}
+}
+
+// fieldLimit is the compiled form of a FieldLimit. Lower ranks are more
+// important; a negative rank is never trimmed.
+type fieldLimit struct {
+	max      int
+	ranks    map[string]int
+	unlisted int
+}
+
+func (fl *fieldLimit) apply(fields []zap.Field) []zap.Field {
+	if len(fields) <= fl.max {
+		return fields
+	}
+
+	// Leave room for the report field.
+	excess := len(fields) - fl.max + 1
+
+	type candidate struct {
+		index int
+		rank  int
+	}
+	candidates := make([]candidate, 0, len(fields))
+	for i, f := range fields {
+		rank, ok := fl.ranks[f.Key]
+		if !ok {
+			rank = fl.unlisted + i
+		}
+		if rank >= 0 {
+			candidates = append(candidates, candidate{index: i, rank: rank})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].rank > candidates[j].rank })
+
+	if excess > len(candidates) {
+		excess = len(candidates)
+	}
+	if excess == 0 {
+		// Only fields that are never trimmed: there is nothing to report.
+		return fields
+	}
+	removed := make(map[int]struct{}, excess)
+	for _, c := range candidates[:excess] {
+		removed[c.index] = struct{}{}
+	}
+
+	result := make([]zap.Field, 0, len(fields)-excess+1)
+	trimmed := make([]string, 0, excess)
+	for i, f := range fields {
+		if _, ok := removed[i]; ok {
+			trimmed = append(trimmed, f.Key)
+			continue
+		}
+		result = append(result, f)
+	}
+	return append(result, zap.Strings(TrimmedFieldsKey, trimmed))
+}
@@ -0,0 +1,71 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFieldLimit(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithFieldLimit(FieldLimit{
+		MaxFields: 4,
+		Priority:  []string{"user_id", "tenant"},
+	}))
+
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "r1"),
+		zap.String("tenant", "acme"),
+		zap.String("user_id", "u1"),
+	)
+	logger.Info(ctx, "too many", zap.String("debug_a", "a"), zap.String("debug_b", "b"))
+
+	fields := observed.All()[0].ContextMap()
+	if len(fields) != 4 {
+		t.Fatalf("expected 4 fields, got %d: %v", len(fields), fields)
+	}
+
+	for _, key := range []string{"request_id", "user_id", "tenant"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("expected %q to be kept", key)
+		}
+	}
+
+	trimmed, ok := fields[TrimmedFieldsKey].([]interface{})
+	if !ok || len(trimmed) != 2 {
+		t.Fatalf("expected 2 trimmed keys, got %v", fields[TrimmedFieldsKey])
+	}
+	if trimmed[0] != "debug_a" || trimmed[1] != "debug_b" {
+		t.Errorf("expected unlisted fields to be trimmed, got %v", trimmed)
+	}
+}
+
+func TestFieldLimitUnderBudget(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithFieldLimit(FieldLimit{MaxFields: 4}))
+
+	logger.Info(context.Background(), "fits", zap.String("a", "1"), zap.String("b", "2"))
+
+	fields := observed.All()[0].ContextMap()
+	if _, ok := fields[TrimmedFieldsKey]; ok {
+		t.Error("expected no trimming report")
+	}
+	if len(fields) != 2 {
+		t.Errorf("expected 2 fields, got %d", len(fields))
+	}
+}
+
+func TestFieldLimitNothingToTrim(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithFieldLimit(FieldLimit{MaxFields: 1, Keep: []string{"a", "b"}}))
+
+	logger.Info(context.Background(), "kept", zap.String("a", "1"), zap.String("b", "2"))
+
+	fields := observed.All()[0].ContextMap()
+	if _, ok := fields[TrimmedFieldsKey]; ok || len(fields) != 2 {
+		t.Errorf("expected the kept fields without a trimming report, got %v", fields)
+	}
+}
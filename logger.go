@@ -4,117 +4,246 @@ import (
 	"context"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Logger wraps a zap.Logger to provide context-aware logging methods.
 type Logger struct {
 	*zap.Logger
+
+	exposure ExposureSink
+	bare     bool
+	strict   bool
+	limit    *fieldLimit
+	cost     *costAccounting
+	report   *shutdownReport
+
+	// closeOutputs closes the outputs opened by the constructor, such as the
+	// file of NewDevelopment, once.
+	closeOutputs func()
+
+	promotion *promotionAdvisor
+
+	cacheLoggers bool
+	pooled       bool
+	noDedup      bool
+
+	detectMisuse   bool
+	missingContext MissingContextPolicy
+	merge          MergeStrategy
+	enrichers      []Enricher
 }
 
-// New creates a new context-aware logger from an existing zap.Logger.
-func New(zapLogger *zap.Logger) *Logger {
-	return &Logger{Logger: zapLogger}
+// New creates a new context-aware logger from an existing zap.Logger,
+// configured by opts.
+func New(zapLogger *zap.Logger, opts ...Option) *Logger {
+	l := &Logger{Logger: zapLogger}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
 // Debug logs a message at DebugLevel. The message includes fields from
 // both the context and any additional fields provided.
 func (l *Logger) Debug(ctx context.Context, msg string, fields ...zap.Field) {
-	contextFields := FieldsFromContext(ctx)
-	if len(contextFields) == 0 {
-		l.Logger.Debug(msg, fields...)
+	if !l.withinBudget(ctx, zapcore.DebugLevel) {
 		return
 	}
-
-	allFields := MergeFields(contextFields, fields)
-	l.Logger.Debug(msg, allFields...)
+	if logger, contextFields := l.cachedLogger(ctx, fields); logger != nil {
+		logger.Debug(msg, l.cachedFields(ctx, zapcore.DebugLevel, contextFields, fields)...)
+		return
+	}
+	buf := l.fieldBuffer()
+	l.Logger.Debug(msg, l.fields(ctx, zapcore.DebugLevel, fields, buf)...)
+	buf.release()
 }
 
 // Info logs a message at InfoLevel. The message includes fields from
 // both the context and any additional fields provided.
 func (l *Logger) Info(ctx context.Context, msg string, fields ...zap.Field) {
-	contextFields := FieldsFromContext(ctx)
-	if len(contextFields) == 0 {
-		l.Logger.Info(msg, fields...)
+	if !l.withinBudget(ctx, zapcore.InfoLevel) {
 		return
 	}
-
-	allFields := MergeFields(contextFields, fields)
-	l.Logger.Info(msg, allFields...)
+	if logger, contextFields := l.cachedLogger(ctx, fields); logger != nil {
+		logger.Info(msg, l.cachedFields(ctx, zapcore.InfoLevel, contextFields, fields)...)
+		return
+	}
+	buf := l.fieldBuffer()
+	l.Logger.Info(msg, l.fields(ctx, zapcore.InfoLevel, fields, buf)...)
+	buf.release()
 }
 
 // Warn logs a message at WarnLevel. The message includes fields from
 // both the context and any additional fields provided.
 func (l *Logger) Warn(ctx context.Context, msg string, fields ...zap.Field) {
-	contextFields := FieldsFromContext(ctx)
-	if len(contextFields) == 0 {
-		l.Logger.Warn(msg, fields...)
+	if !l.withinBudget(ctx, zapcore.WarnLevel) {
 		return
 	}
-
-	allFields := MergeFields(contextFields, fields)
-	l.Logger.Warn(msg, allFields...)
+	if logger, contextFields := l.cachedLogger(ctx, fields); logger != nil {
+		logger.Warn(msg, l.cachedFields(ctx, zapcore.WarnLevel, contextFields, fields)...)
+		return
+	}
+	buf := l.fieldBuffer()
+	l.Logger.Warn(msg, l.fields(ctx, zapcore.WarnLevel, fields, buf)...)
+	buf.release()
 }
 
 // Error logs a message at ErrorLevel. The message includes fields from
 // both the context and any additional fields provided.
 func (l *Logger) Error(ctx context.Context, msg string, fields ...zap.Field) {
-	contextFields := FieldsFromContext(ctx)
-	if len(contextFields) == 0 {
-		l.Logger.Error(msg, fields...)
+	if logger, contextFields := l.cachedLogger(ctx, fields); logger != nil {
+		logger.Error(msg, l.cachedFields(ctx, zapcore.ErrorLevel, contextFields, fields)...)
 		return
 	}
-
-	allFields := MergeFields(contextFields, fields)
-	l.Logger.Error(msg, allFields...)
+	buf := l.fieldBuffer()
+	l.Logger.Error(msg, l.fields(ctx, zapcore.ErrorLevel, fields, buf)...)
+	buf.release()
 }
 
 // DPanic logs a message at DPanicLevel. The message includes fields from
 // both the context and any additional fields provided.
 func (l *Logger) DPanic(ctx context.Context, msg string, fields ...zap.Field) {
-	contextFields := FieldsFromContext(ctx)
-	if len(contextFields) == 0 {
-		l.Logger.DPanic(msg, fields...)
+	if logger, contextFields := l.cachedLogger(ctx, fields); logger != nil {
+		logger.DPanic(msg, l.cachedFields(ctx, zapcore.DPanicLevel, contextFields, fields)...)
 		return
 	}
-
-	allFields := MergeFields(contextFields, fields)
-	l.Logger.DPanic(msg, allFields...)
+	l.Logger.DPanic(msg, l.fields(ctx, zapcore.DPanicLevel, fields, nil)...)
 }
 
 // Panic logs a message at PanicLevel. The message includes fields from
 // both the context and any additional fields provided.
 func (l *Logger) Panic(ctx context.Context, msg string, fields ...zap.Field) {
-	contextFields := FieldsFromContext(ctx)
-	if len(contextFields) == 0 {
-		l.Logger.Panic(msg, fields...)
+	if logger, contextFields := l.cachedLogger(ctx, fields); logger != nil {
+		logger.Panic(msg, l.cachedFields(ctx, zapcore.PanicLevel, contextFields, fields)...)
 		return
 	}
-
-	allFields := MergeFields(contextFields, fields)
-	l.Logger.Panic(msg, allFields...)
+	l.Logger.Panic(msg, l.fields(ctx, zapcore.PanicLevel, fields, nil)...)
 }
 
 // Fatal logs a message at FatalLevel. The message includes fields from
 // both the context and any additional fields provided.
 func (l *Logger) Fatal(ctx context.Context, msg string, fields ...zap.Field) {
-	contextFields := FieldsFromContext(ctx)
-	if len(contextFields) == 0 {
-		l.Logger.Fatal(msg, fields...)
+	if logger, contextFields := l.cachedLogger(ctx, fields); logger != nil {
+		logger.Fatal(msg, l.cachedFields(ctx, zapcore.FatalLevel, contextFields, fields)...)
 		return
 	}
-
-	allFields := MergeFields(contextFields, fields)
-	l.Logger.Fatal(msg, allFields...)
+	l.Logger.Fatal(msg, l.fields(ctx, zapcore.FatalLevel, fields, nil)...)
 }
 
 // With creates a child logger and adds structured context to it. Fields added
 // to the child don't affect the parent, and vice versa.
 func (l *Logger) With(fields ...zap.Field) *Logger {
-	return &Logger{Logger: l.Logger.With(fields...)}
+	clone := l.clone()
+	clone.Logger = l.Logger.With(fields...)
+	return clone
+}
+
+// WithContext returns a plain zap.Logger with the context fields of ctx
+// added with With, to hand to third-party libraries (gRPC, database
+// drivers) that only accept a *zap.Logger. Logger-level behavior, such as
+// field limits and misuse detection, does not apply to the returned logger;
+// cores installed with WithTransformers and the like do. A Bare logger adds
+// no fields.
+func (l *Logger) WithContext(ctx context.Context) *zap.Logger {
+	if l.bare {
+		return l.Logger
+	}
+	fields := l.contextFields(ctx)
+	if len(fields) == 0 {
+		return l.Logger
+	}
+	return l.Logger.With(fields...)
 }
 
 // WithOptions clones the current Logger, applies the supplied Options,
 // and returns the resulting Logger. It's safe to use concurrently.
 func (l *Logger) WithOptions(opts ...zap.Option) *Logger {
-	return &Logger{Logger: l.Logger.WithOptions(opts...)}
+	clone := l.clone()
+	clone.Logger = l.Logger.WithOptions(opts...)
+	return clone
+}
+
+// Bare returns a child logger that never includes context fields, as if
+// NoContextFields were passed to every call. Use it for entries that must not
+// carry request metadata, such as periodic aggregate statistics.
+func (l *Logger) Bare() *Logger {
+	clone := l.clone()
+	clone.bare = true
+	return clone
+}
+
+// fields returns the fields to log for an entry: the context fields merged
+// with the call-site fields, after applying any per-call options. It also
+// counts the entry towards the operation in ctx, if any. It must be
+// called directly from the level methods so that WithStack skips the right
+// number of frames.
+func (l *Logger) fields(ctx context.Context, level zapcore.Level, fields []zap.Field, buf *fieldBuffer) []zap.Field {
+	if op := operationFrom(ctx); op != nil && l.Core().Enabled(level) {
+		op.count()
+	}
+
+	opts, fields := extractCallOptions(fields)
+	if isMissingContext(ctx) {
+		fields = l.onMissingContext(ctx, fields)
+	}
+
+	var contextFields []zap.Field
+	if !l.bare && opts.flags&optNoContextFields == 0 {
+		contextFields = l.contextFields(ctx)
+	}
+	if l.promotion != nil && len(contextFields) > 0 && l.Core().Enabled(level) {
+		l.promotion.observe(ctx)
+	}
+	if l.detectMisuse || l.strict {
+		l.checkFields(contextFields, fields)
+	}
+
+	if opts.flags&optStack != 0 {
+		fields = append(fields, zap.StackSkip("stacktrace", 2))
+	}
+	if opts.flags&optNoSampling != 0 {
+		fields = append(fields, samplingExemptionField())
+	}
+	if len(contextFields) > 0 {
+		merge := l.merge
+		if opts.merge != nil {
+			merge = opts.merge
+		}
+		switch {
+		case merge != nil:
+			fields = l.reportConflicts(merge(contextFields, fields))
+		case len(fields) == 0:
+			fields = contextFields
+		case l.noDedup && buf != nil:
+			fields = buf.concat(contextFields, fields)
+		case l.noDedup:
+			fields = AppendFields(contextFields, fields)
+		case buf != nil:
+			fields = buf.merge(contextFields, fields)
+		default:
+			fields = MergeFields(contextFields, fields)
+		}
+	}
+
+	if l.limit != nil {
+		fields = l.limit.apply(fields)
+	}
+	return fields
+}
+
+// contextFields returns the context fields of an entry: the fields stored
+// in ctx, overridden by the fields of the enrichers.
+func (l *Logger) contextFields(ctx context.Context) []zap.Field {
+	fields := sharedEntryFields(ctx)
+	for _, enrich := range l.enrichers {
+		fields = MergeFields(fields, enrich(ctx))
+	}
+	return fields
+}
+
+// clone returns a shallow copy of the logger, sharing the underlying zap.Logger.
+func (l *Logger) clone() *Logger {
+	clone := *l
+	return &clone
 }
@@ -0,0 +1,103 @@
+package ctxzap
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithLoggerCache makes the level methods cache, in the context, a child of
+// the underlying zap.Logger with the context fields already added with
+// With, the first time an entry is logged with a context. Later entries
+// logged with the same context reuse it: the context fields are neither
+// merged nor encoded again, which brings logging with context fields close
+// to the cost of logging with a plain zap child logger. A context caches a
+// logger for each of the last few loggers used with it, so loggers of
+// different components can alternate on the same context.
+//
+// Call-site fields are then written after the context fields without
+// replacing those with the same key, as with AppendFields, so the output may
+// contain duplicate keys; most JSON consumers keep the last one. The first
+// entry logged with each context pays for With, so the cache only helps
+// contexts logged with several times.
+//
+// Entries are logged without the cache, as usual, when the logger uses a
+// merge strategy, enrichers, a field limit or WithPromotionAdvice, when
+// context values are mirrored with RegisterContextValue, when the call has
+// per-call options, or when the context holds fields added with
+// WithFieldsTTL.
+func WithLoggerCache() Option {
+	return func(l *Logger) {
+		l.cacheLoggers = true
+	}
+}
+
+// maxCachedLoggers bounds the number of loggers cached per context, for the
+// few loggers (e.g. one per component) that may log with the same context.
+const maxCachedLoggers = 4
+
+// cachedLogger is the zap logger cached in a fieldNode for base.
+type cachedLogger struct {
+	base   *zap.Logger
+	logger *zap.Logger
+}
+
+// cachedLogger returns the logger with the context fields of ctx cached for
+// l, creating it if needed, and the context fields, or nil if the entry
+// cannot be logged through the cache.
+func (l *Logger) cachedLogger(ctx context.Context, fields []zap.Field) (*zap.Logger, []zap.Field) {
+	if !l.cacheLoggers || l.bare || l.merge != nil || l.limit != nil || l.promotion != nil || len(l.enrichers) > 0 {
+		return nil, nil
+	}
+	if p := registry.Load(); p != nil && len(*p) > 0 {
+		return nil, nil
+	}
+	for i := range fields {
+		if isCallOption(fields[i]) {
+			return nil, nil
+		}
+	}
+
+	n := fieldNodeFrom(ctx)
+	contextFields := n.resolve()
+	if len(contextFields) == 0 {
+		return nil, nil
+	}
+	cached := n.loggers.Load()
+	if cached != nil {
+		for _, c := range *cached {
+			if c.base == l.Logger {
+				return c.logger, contextFields
+			}
+		}
+	}
+	for i := range contextFields {
+		if contextFields[i].Type == zapcore.SkipType {
+			// Fields added with WithFieldsTTL must be resolved per entry.
+			return nil, nil
+		}
+	}
+
+	logger := l.Logger.With(contextFields...)
+	// The cached loggers are never modified: store a new list, evicting the
+	// least recently created logger if the list is full. If another logger
+	// was stored concurrently, this one is not cached.
+	loggers := []cachedLogger{{base: l.Logger, logger: logger}}
+	if cached != nil {
+		loggers = append(loggers, (*cached)[:min(len(*cached), maxCachedLoggers-1)]...)
+	}
+	n.loggers.CompareAndSwap(cached, &loggers)
+	return logger, contextFields
+}
+
+// cachedFields is the counterpart of fields for entries logged through a
+// cached logger, which already holds contextFields. It must be called
+// directly from the level methods.
+func (l *Logger) cachedFields(ctx context.Context, level zapcore.Level, contextFields, fields []zap.Field) []zap.Field {
+	if op := operationFrom(ctx); op != nil && l.Core().Enabled(level) {
+		op.count()
+	}
+	l.checkFields(contextFields, fields)
+	return fields
+}
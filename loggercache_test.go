@@ -0,0 +1,108 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type withCountingCore struct {
+	zapcore.Core
+
+	withs *int
+}
+
+func (c withCountingCore) With(fields []zap.Field) zapcore.Core {
+	*c.withs++
+	return withCountingCore{Core: c.Core.With(fields), withs: c.withs}
+}
+
+func (c withCountingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Core.Check(entry, nil) != nil {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func TestWithLoggerCache(t *testing.T) {
+	observed, logs := observer.New(zapcore.InfoLevel)
+	var withs int
+	logger := New(zap.New(withCountingCore{Core: observed, withs: &withs}), WithLoggerCache())
+	ctx := WithFields(context.Background(), zap.String("request_id", "abc"), zap.String("user", "alice"))
+
+	logger.Info(ctx, "first", zap.Int("n", 1))
+	logger.Warn(ctx, "second", zap.String("user", "bob"))
+	logger.Debug(ctx, "disabled")
+	if withs != 1 {
+		t.Errorf("expected the context logger to be built once, got %d With calls", withs)
+	}
+
+	entries := logs.All()
+	if m := entries[0].ContextMap(); m["request_id"] != "abc" || m["n"] != int64(1) || len(m) != 3 {
+		t.Errorf("unexpected fields: %v", m)
+	}
+	if len(entries[1].Context) != 3 || entries[1].Context[2].String != "bob" {
+		t.Errorf("expected call-site fields appended after context fields, got %v", entries[1].Context)
+	}
+
+	// A derived context gets its own cached logger.
+	child := WithFields(ctx, zap.Int("attempt", 2))
+	logger.Info(child, "third")
+	logger.Info(child, "fourth")
+	if withs != 2 {
+		t.Errorf("expected one more With for the derived context, got %d", withs)
+	}
+
+	// Per-call options and expiring fields bypass the cache.
+	logger.Info(ctx, "stats", NoContextFields())
+	logger.Info(WithFieldsTTL(ctx, 0, zap.Int("message", 1)), "ttl")
+	if withs != 2 {
+		t.Errorf("expected the cache to be bypassed, got %d With calls", withs)
+	}
+	entries = logs.All()
+	if len(entries[4].Context) != 0 || entries[5].ContextMap()["message"] != int64(1) {
+		t.Errorf("unexpected uncached entries: %v, %v", entries[4].Context, entries[5].ContextMap())
+	}
+
+	// Child loggers are cached separately.
+	service := logger.With(zap.String("service", "api"))
+	service.Info(ctx, "fifth")
+	if m := logs.All()[6].ContextMap(); m["service"] != "api" || m["request_id"] != "abc" {
+		t.Errorf("expected the child's fields, got %v", m)
+	}
+}
+
+func TestWithLoggerCacheAlternatingLoggers(t *testing.T) {
+	observed, _ := observer.New(zapcore.InfoLevel)
+	var withs int
+	root := New(zap.New(withCountingCore{Core: observed, withs: &withs}), WithLoggerCache())
+	loggers := []*Logger{
+		root.With(zap.String("component", "db")),
+		root.With(zap.String("component", "cache")),
+		root.With(zap.String("component", "http")),
+	}
+	ctx := WithFields(context.Background(), zap.String("request_id", "abc"))
+
+	withs = 0
+	for i := 0; i < 10; i++ {
+		for _, l := range loggers {
+			l.Info(ctx, "entry")
+		}
+	}
+	if withs != len(loggers) {
+		t.Errorf("expected one cached logger per logger, got %d With calls", withs)
+	}
+
+	// Beyond maxCachedLoggers, the oldest cached logger is evicted.
+	for i := 0; i < maxCachedLoggers; i++ {
+		root.With(zap.Int("extra", i)).Info(ctx, "entry")
+	}
+	withs = 0
+	loggers[0].Info(ctx, "entry")
+	if withs != 1 {
+		t.Errorf("expected the evicted logger to be rebuilt, got %d With calls", withs)
+	}
+}
@@ -0,0 +1,114 @@
+package ctxzap
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogIDKey is the key of the field added by WithLogID.
+const LogIDKey = "log_id"
+
+// WithLogID makes the logger stamp every entry with a unique "log_id" field,
+// so that support tooling can reference a specific entry. IDs are UUIDv7
+// strings: their timestamp is the entry time, and the IDs generated by the
+// logger and loggers derived from it sort in generation order, even within a
+// millisecond, so they can serve as pagination cursors.
+func WithLogID() Option {
+	return func(l *Logger) {
+		l.Logger = l.Logger.WithOptions(zap.WrapCore(NewLogIDCore))
+	}
+}
+
+// NewLogIDCore wraps core with the log_id stamping described in WithLogID.
+func NewLogIDCore(core zapcore.Core) zapcore.Core {
+	return decorate(core, logIDDecorator{gen: &uuidV7Generator{}})
+}
+
+type logIDDecorator struct {
+	gen *uuidV7Generator
+}
+
+func (d logIDDecorator) with(fields []zap.Field) (decoration, []zap.Field) {
+	return d, fields
+}
+
+func (d logIDDecorator) write(core zapcore.Core, entry zapcore.Entry, fields []zap.Field) error {
+	stamped := make([]zap.Field, 0, len(fields)+1)
+	stamped = append(stamped, zap.String(LogIDKey, d.gen.next(entry.Time)))
+	stamped = append(stamped, fields...)
+	return core.Write(entry, stamped)
+}
+
+// uuidV7Generator generates monotonic UUIDv7s, using the 12-bit rand_a field
+// as a counter within a millisecond (RFC 9562, section 6.2, method 1).
+type uuidV7Generator struct {
+	mu     sync.Mutex
+	lastMS int64
+	seq    uint16
+}
+
+func (g *uuidV7Generator) next(t time.Time) string {
+	ms := t.UnixMilli()
+
+	g.mu.Lock()
+	if ms <= g.lastMS {
+		// Same millisecond, or the clock went backwards: keep counting from
+		// the last timestamp, moving to the next one on overflow.
+		ms = g.lastMS
+		g.seq++
+		if g.seq > 0xfff {
+			ms++
+			g.seq = 0
+		}
+	} else {
+		g.seq = 0
+	}
+	g.lastMS = ms
+	seq := g.seq
+	g.mu.Unlock()
+
+	var u [16]byte
+	binary.BigEndian.PutUint64(u[0:8], uint64(ms)<<16)
+	binary.BigEndian.PutUint16(u[6:8], 0x7000|seq)
+	_, _ = rand.Read(u[8:])
+	u[8] = u[8]&0x3f | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// ErrInvalidLogID is returned by LogIDTime for strings that are not UUIDv7s.
+var ErrInvalidLogID = errors.New("ctxzap: invalid log ID")
+
+// LogIDTime returns the millisecond timestamp embedded in a log ID, e.g. to
+// turn a cursor into a time range query.
+func LogIDTime(id string) (time.Time, error) {
+	if len(id) != 36 || id[8] != '-' || id[13] != '-' || id[14] != '7' {
+		return time.Time{}, ErrInvalidLogID
+	}
+	var b [6]byte
+	if _, err := hex.Decode(b[0:4], []byte(id[0:8])); err != nil {
+		return time.Time{}, ErrInvalidLogID
+	}
+	if _, err := hex.Decode(b[4:6], []byte(id[9:13])); err != nil {
+		return time.Time{}, ErrInvalidLogID
+	}
+	ms := int64(b[0])<<40 | int64(b[1])<<32 | int64(b[2])<<24 | int64(b[3])<<16 | int64(b[4])<<8 | int64(b[5])
+	return time.UnixMilli(ms), nil
+}
@@ -0,0 +1,67 @@
+package ctxzap
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestWithLogID(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithLogID())
+	child := logger.With(zap.String("component", "worker"))
+
+	for i := 0; i < 1000; i++ {
+		child.Info(context.Background(), "entry")
+	}
+
+	entries := observed.All()
+	ids := make([]string, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		id, _ := entry.ContextMap()[LogIDKey].(string)
+		if !uuidV7Pattern.MatchString(id) {
+			t.Fatalf("entry %d: invalid log ID %q", i, id)
+		}
+		if seen[id] {
+			t.Fatalf("entry %d: duplicate log ID %q", i, id)
+		}
+		seen[id] = true
+		ids[i] = id
+	}
+
+	if !sort.StringsAreSorted(ids) {
+		t.Error("expected log IDs to sort in write order")
+	}
+
+	ts, err := LogIDTime(ids[0])
+	if err != nil {
+		t.Fatalf("LogIDTime failed: %v", err)
+	}
+	if diff := entries[0].Time.Sub(ts); diff < 0 || diff >= time.Millisecond {
+		t.Errorf("expected the entry time %v, got %v", entries[0].Time, ts)
+	}
+
+	if _, err := LogIDTime("not-a-log-id"); err != ErrInvalidLogID {
+		t.Errorf("expected ErrInvalidLogID, got %v", err)
+	}
+}
+
+func TestLogIDClockSkew(t *testing.T) {
+	var g uuidV7Generator
+	now := time.Now()
+
+	first := g.next(now)
+	second := g.next(now.Add(-time.Second))
+	if second <= first {
+		t.Errorf("expected IDs to stay ordered when the clock goes back: %s <= %s", second, first)
+	}
+}
@@ -0,0 +1,158 @@
+package ctxzap
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MultiLineMode selects how messages spanning several lines are written.
+type MultiLineMode int
+
+const (
+	// MultiLineKeep writes messages unchanged.
+	MultiLineKeep MultiLineMode = iota
+	// MultiLineEscape replaces line breaks with a literal "\n".
+	MultiLineEscape
+	// MultiLineFold joins the lines with single spaces, trimming the
+	// indentation of continuation lines.
+	MultiLineFold
+	// MultiLineSplit writes one entry per non-empty line, with the same
+	// fields plus "line" and "lines" fields numbering the parts.
+	MultiLineSplit
+)
+
+// MessageOptions configures the handling of unstructured messages, such as
+// those of third-party libraries routed through zap.NewStdLog.
+type MessageOptions struct {
+	// MultiLine selects how multi-line messages are written.
+	MultiLine MultiLineMode
+	// ParseJSON extracts a JSON object embedded in a message into fields,
+	// one per top-level key. The object is removed from the message; if
+	// nothing else remains, its "msg" or "message" key becomes the message.
+	ParseJSON bool
+}
+
+// WithMessageOptions makes the logger handle messages according to opts.
+func WithMessageOptions(opts MessageOptions) Option {
+	return func(l *Logger) {
+		l.Logger = l.Logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewMessageCore(core, opts)
+		}))
+	}
+}
+
+// NewMessageCore wraps core with the message handling described by opts.
+func NewMessageCore(core zapcore.Core, opts MessageOptions) zapcore.Core {
+	return decorate(core, messageDecorator{opts: opts})
+}
+
+type messageDecorator struct {
+	opts MessageOptions
+}
+
+func (d messageDecorator) with(fields []zap.Field) (decoration, []zap.Field) {
+	return d, fields
+}
+
+func (d messageDecorator) write(core zapcore.Core, entry zapcore.Entry, fields []zap.Field) error {
+	if d.opts.ParseJSON {
+		var parsed []zap.Field
+		entry.Message, parsed = extractJSON(entry.Message)
+		if len(parsed) > 0 {
+			fields = append(append(make([]zap.Field, 0, len(fields)+len(parsed)), fields...), parsed...)
+		}
+	}
+
+	if !strings.ContainsAny(entry.Message, "\r\n") {
+		return core.Write(entry, fields)
+	}
+
+	switch d.opts.MultiLine {
+	case MultiLineEscape:
+		entry.Message = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(entry.Message)
+	case MultiLineFold:
+		entry.Message = strings.Join(messageLines(entry.Message), " ")
+	case MultiLineSplit:
+		lines := messageLines(entry.Message)
+		var err error
+		for i, line := range lines {
+			part := entry
+			part.Message = line
+			partFields := append(fields[:len(fields):len(fields)], zap.Int("line", i+1), zap.Int("lines", len(lines)))
+			err = multierr.Append(err, core.Write(part, partFields))
+		}
+		return err
+	}
+	return core.Write(entry, fields)
+}
+
+// messageLines returns the non-empty lines of msg, without surrounding
+// whitespace.
+func messageLines(msg string) []string {
+	lines := strings.FieldsFunc(msg, func(r rune) bool { return r == '\n' || r == '\r' })
+	result := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// extractJSON removes the first JSON object embedded in msg and returns its
+// keys as fields, in key order. msg is returned unchanged if it has none.
+func extractJSON(msg string) (string, []zap.Field) {
+	start := strings.IndexByte(msg, '{')
+	if start < 0 {
+		return msg, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(msg[start:]))
+	dec.UseNumber()
+	var object map[string]any
+	if err := dec.Decode(&object); err != nil || len(object) == 0 {
+		return msg, nil
+	}
+	rest := strings.TrimSpace(msg[:start] + " " + msg[start+int(dec.InputOffset()):])
+
+	if rest == "" {
+		for _, key := range []string{"msg", "message"} {
+			if s, ok := object[key].(string); ok {
+				rest = s
+				delete(object, key)
+				break
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zap.Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, jsonField(key, object[key]))
+	}
+	return rest, fields
+}
+
+// jsonField converts a decoded JSON value to a field, keeping numbers numeric.
+func jsonField(key string, v any) zap.Field {
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return zap.Int64(key, i)
+		}
+		if f, err := n.Float64(); err == nil {
+			return zap.Float64(key, f)
+		}
+		return zap.String(key, n.String())
+	}
+	return zap.Any(key, v)
+}
@@ -0,0 +1,112 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMultiLineMessages(t *testing.T) {
+	const msg = "query failed:\n  SELECT *\r\n  FROM users\n"
+
+	tests := []struct {
+		name     string
+		mode     MultiLineMode
+		expected []string
+	}{
+		{name: "keep", mode: MultiLineKeep, expected: []string{msg}},
+		{name: "escape", mode: MultiLineEscape, expected: []string{`query failed:\n  SELECT *\n  FROM users\n`}},
+		{name: "fold", mode: MultiLineFold, expected: []string{"query failed: SELECT * FROM users"}},
+		{name: "split", mode: MultiLineSplit, expected: []string{"query failed:", "SELECT *", "FROM users"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, observed := observer.New(zapcore.InfoLevel)
+			logger := New(zap.New(core), WithMessageOptions(MessageOptions{MultiLine: tt.mode}))
+			ctx := WithFields(context.Background(), zap.String("request_id", "req-1"))
+
+			logger.Info(ctx, msg)
+
+			entries := observed.All()
+			if len(entries) != len(tt.expected) {
+				t.Fatalf("expected %d entries, got %d", len(tt.expected), len(entries))
+			}
+			for i, want := range tt.expected {
+				if entries[i].Message != want {
+					t.Errorf("entry %d: expected %q, got %q", i, want, entries[i].Message)
+				}
+				fields := entries[i].ContextMap()
+				if fields["request_id"] != "req-1" {
+					t.Errorf("entry %d: expected context fields, got %v", i, fields)
+				}
+				if tt.mode == MultiLineSplit && (fields["line"] != int64(i+1) || fields["lines"] != int64(3)) {
+					t.Errorf("entry %d: unexpected line numbering %v", i, fields)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitMessagesKeepTeeLevels(t *testing.T) {
+	debug, all := observer.New(zapcore.DebugLevel)
+	errs, errors := observer.New(zapcore.ErrorLevel)
+	logger := New(zap.New(zapcore.NewTee(debug, errs)), WithMessageOptions(MessageOptions{MultiLine: MultiLineSplit}))
+
+	logger.Debug(context.Background(), "first\nsecond")
+	logger.Error(context.Background(), "third\nfourth")
+	if all.Len() != 4 || errors.Len() != 2 || errors.All()[1].Message != "fourth" {
+		t.Errorf("expected every line at debug and the error lines at error, got %v and %v", all.All(), errors.All())
+	}
+}
+
+func TestEmbeddedJSONMessages(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    string
+		want   string
+		fields map[string]interface{}
+	}{
+		{
+			name:   "prefix and object",
+			msg:    `upstream response {"status": 503, "retry_after": 1.5, "reason": "overloaded"}`,
+			want:   "upstream response",
+			fields: map[string]interface{}{"status": int64(503), "retry_after": 1.5, "reason": "overloaded"},
+		},
+		{
+			name:   "object with message key",
+			msg:    `{"level":"warn","msg":"disk almost full","free_pct":4}`,
+			want:   "disk almost full",
+			fields: map[string]interface{}{"level": "warn", "free_pct": int64(4)},
+		},
+		{
+			name: "invalid JSON",
+			msg:  "map literal {not json}",
+			want: "map literal {not json}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, observed := observer.New(zapcore.InfoLevel)
+			New(zap.New(core), WithMessageOptions(MessageOptions{ParseJSON: true})).Info(context.Background(), tt.msg)
+
+			entry := observed.All()[0]
+			if entry.Message != tt.want {
+				t.Errorf("expected message %q, got %q", tt.want, entry.Message)
+			}
+			fields := entry.ContextMap()
+			if len(fields) != len(tt.fields) {
+				t.Errorf("expected %d fields, got %v", len(tt.fields), fields)
+			}
+			for key, want := range tt.fields {
+				if fields[key] != want {
+					t.Errorf("field %s: expected %v, got %v", key, want, fields[key])
+				}
+			}
+		})
+	}
+}
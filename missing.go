@@ -0,0 +1,63 @@
+package ctxzap
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// MissingContextPolicy selects how a logger handles a nil or context.TODO()
+// context, which usually reveals a caller that did not propagate its
+// context.
+type MissingContextPolicy int
+
+const (
+	// MissingContextIgnore logs the entry without further action. This is
+	// the default.
+	MissingContextIgnore MissingContextPolicy = iota
+	// MissingContextWarn logs a warning the first time each call site logs
+	// with a missing context, like other misuses.
+	MissingContextWarn
+	// MissingContextField adds a "ctx":"missing" field to the entry.
+	MissingContextField
+	// MissingContextDPanic logs the misuse at DPanicLevel, so that a
+	// development logger panics.
+	MissingContextDPanic
+)
+
+// MissingContextKey is the key of the field added by MissingContextField.
+const MissingContextKey = "ctx"
+
+// WithMissingContextPolicy makes the logger handle nil and context.TODO()
+// contexts according to policy.
+func WithMissingContextPolicy(policy MissingContextPolicy) Option {
+	return func(l *Logger) {
+		l.missingContext = policy
+	}
+}
+
+// isMissingContext reports whether ctx is nil or context.TODO().
+func isMissingContext(ctx context.Context) bool {
+	return ctx == nil || ctx == context.TODO()
+}
+
+// onMissingContext applies the missing context policy to an entry and
+// returns its fields. It must be called directly from fields, so that misuse
+// finds the call site of the logging method.
+func (l *Logger) onMissingContext(ctx context.Context, fields []zap.Field) []zap.Field {
+	kind := "todo"
+	if ctx == nil {
+		kind = "nil"
+	}
+
+	switch l.missingContext {
+	case MissingContextIgnore:
+	case MissingContextField:
+		fields = append(fields, zap.String(MissingContextKey, "missing"))
+	case MissingContextDPanic:
+		l.Logger.DPanic("ctxzap: missing context passed to logger", zap.String("context", kind))
+	default:
+		l.misuse("ctxzap: missing context passed to logger", zap.String("context", kind))
+	}
+	return fields
+}
@@ -0,0 +1,83 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMissingContextPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  MissingContextPolicy
+		entries int
+		level   zapcore.Level
+		field   bool
+	}{
+		{name: "warn once per call site", policy: MissingContextWarn, entries: 5, level: zapcore.WarnLevel},
+		{name: "ignore", policy: MissingContextIgnore, entries: 3},
+		{name: "field", policy: MissingContextField, entries: 3, field: true},
+		{name: "dpanic", policy: MissingContextDPanic, entries: 6, level: zapcore.DPanicLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			onceSites.Clear()
+			core, observed := observer.New(zapcore.InfoLevel)
+			logger := New(zap.New(core), WithMissingContextPolicy(tt.policy))
+
+			for i := 0; i < 2; i++ {
+				logger.Info(nil, "nil context") //nolint:staticcheck // testing nil context
+			}
+			logger.Info(context.TODO(), "todo context")
+
+			entries := observed.All()
+			if len(entries) != tt.entries {
+				t.Fatalf("expected %d entries, got %d", tt.entries, len(entries))
+			}
+
+			var logged int
+			for _, entry := range entries {
+				if entry.Message == "ctxzap: missing context passed to logger" {
+					if entry.Level != tt.level {
+						t.Errorf("expected misuse at %v, got %v", tt.level, entry.Level)
+					}
+					continue
+				}
+				logged++
+				if _, ok := entry.ContextMap()[MissingContextKey]; ok != tt.field {
+					t.Errorf("%q: expected field present=%v, got %v", entry.Message, tt.field, entry.ContextMap())
+				}
+			}
+			if logged != 3 {
+				t.Errorf("expected the 3 entries to be logged, got %d", logged)
+			}
+		})
+	}
+}
+
+func TestMissingContextIgnoredByDefault(t *testing.T) {
+	onceSites.Clear()
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	logger.Info(nil, "nil context") //nolint:staticcheck // testing nil context
+
+	if entries := observed.All(); len(entries) != 1 || len(entries[0].Context) != 0 {
+		t.Errorf("expected only the entry, without fields, got %v", entries)
+	}
+}
+
+func TestMissingContextDerived(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+
+	logger.Info(WithFields(context.TODO(), zap.String("k", "v")), "derived")
+
+	if entries := observed.All(); len(entries) != 1 {
+		t.Errorf("expected a context derived from TODO to be accepted, got %d entries", len(entries))
+	}
+}
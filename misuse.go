@@ -0,0 +1,119 @@
+package ctxzap
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// reservedKeys are the keys used by zap's standard encoders for the entry
+// itself; fields using them produce duplicate keys in the output.
+var reservedKeys = map[string]struct{}{
+	"level":      {},
+	"ts":         {},
+	"msg":        {},
+	"caller":     {},
+	"logger":     {},
+	"stacktrace": {},
+}
+
+// WithMisuseDetection makes the logger report misuses detectable from the
+// fields of an entry, once per call site of the logging method, at WarnLevel:
+// a field using a key reserved by zap's encoders (level, ts, msg, caller,
+// logger, stacktrace), and a call-site field overriding a context field of a
+// different type. Detection scans the fields of every entry, so it is off by
+// default.
+func WithMisuseDetection() Option {
+	return func(l *Logger) {
+		l.detectMisuse = true
+	}
+}
+
+// Strict returns a child logger for test and staging builds that escalates
+// misuse detections from WarnLevel to DPanicLevel, so that a development
+// logger panics on them. It implies WithMisuseDetection. Each misuse is
+// reported once per call site of the logging method. Detected misuses are a
+// field using a key reserved by zap's encoders (level, ts, msg, caller,
+// logger, stacktrace), a call-site field overriding a context field of a
+// different type and, under MissingContextWarn, a nil or context.TODO()
+// context passed to a logging method.
+func (l *Logger) Strict() *Logger {
+	clone := l.clone()
+	clone.strict = true
+	return clone
+}
+
+// misuse reports incorrect use of the logger, once per call site of the
+// logging method. It must be called from a function called directly by
+// fields.
+func (l *Logger) misuse(msg string, fields ...zap.Field) {
+	// Skip the caller, fields and the level method.
+	if !firstAtCallSite(4, msg) {
+		return
+	}
+	if l.strict {
+		l.Logger.DPanic(msg, fields...)
+		return
+	}
+	l.Logger.Warn(msg, fields...)
+}
+
+// checkFields reports the misuses detectable from the fields of an entry.
+func (l *Logger) checkFields(contextFields, fields []zap.Field) {
+	for i := range fields {
+		if _, ok := reservedKeys[fields[i].Key]; ok {
+			l.misuse("ctxzap: field uses a reserved key", zap.String("key", fields[i].Key))
+		}
+	}
+	for i := range contextFields {
+		if _, ok := reservedKeys[contextFields[i].Key]; ok {
+			l.misuse("ctxzap: context field uses a reserved key", zap.String("key", contextFields[i].Key))
+		}
+	}
+
+	for i := range fields {
+		for j := range contextFields {
+			if fields[i].Key != contextFields[j].Key {
+				continue
+			}
+			if kind, contextKind := fieldKind(fields[i].Type), fieldKind(contextFields[j].Type); kind != contextKind {
+				l.misuse("ctxzap: field type conflicts with context field",
+					zap.String("key", fields[i].Key),
+					zap.String("context_type", contextKind),
+					zap.String("type", kind),
+				)
+			}
+		}
+	}
+}
+
+// fieldKind groups field types by the kind of value they encode, so that
+// e.g. int32 and int64 fields do not conflict.
+func fieldKind(t zapcore.FieldType) string {
+	switch t {
+	case zapcore.StringType, zapcore.StringerType, zapcore.ByteStringType:
+		return "string"
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return "integer"
+	case zapcore.Float64Type, zapcore.Float32Type:
+		return "float"
+	case zapcore.Complex128Type, zapcore.Complex64Type:
+		return "complex"
+	case zapcore.BoolType:
+		return "bool"
+	case zapcore.DurationType:
+		return "duration"
+	case zapcore.TimeType, zapcore.TimeFullType:
+		return "time"
+	case zapcore.ErrorType:
+		return "error"
+	case zapcore.BinaryType:
+		return "binary"
+	case zapcore.ArrayMarshalerType:
+		return "array"
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+		return "object"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,85 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMisuseDetection(t *testing.T) {
+	ctx := WithFields(context.Background(), zap.String("user_id", "u1"), zap.Int("attempt", 1))
+
+	tests := []struct {
+		name    string
+		log     func(l *Logger)
+		misuses []string
+	}{
+		{
+			name: "valid entry",
+			log:  func(l *Logger) { l.Info(ctx, "ok", zap.Int32("attempt", 2)) },
+		},
+		{
+			name:    "reserved key",
+			log:     func(l *Logger) { l.Info(ctx, "test", zap.String("msg", "shadowed")) },
+			misuses: []string{"ctxzap: field uses a reserved key"},
+		},
+		{
+			name:    "type conflict",
+			log:     func(l *Logger) { l.Info(ctx, "test", zap.Int("user_id", 1)) },
+			misuses: []string{"ctxzap: field type conflicts with context field"},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			onceSites.Clear()
+			core, observed := observer.New(zapcore.InfoLevel)
+			logger := New(zap.New(core), WithMisuseDetection())
+			level := zapcore.WarnLevel
+			if strict {
+				logger = logger.Strict()
+				level = zapcore.DPanicLevel
+			}
+
+			tt.log(logger)
+
+			entries := observed.All()
+			if len(entries) != len(tt.misuses)+1 {
+				t.Fatalf("%s (strict=%v): expected %d entries, got %d", tt.name, strict, len(tt.misuses)+1, len(entries))
+			}
+			for i, msg := range tt.misuses {
+				if entries[i].Message != msg || entries[i].Level != level {
+					t.Errorf("%s (strict=%v): expected %q at %v, got %q at %v", tt.name, strict, msg, level, entries[i].Message, entries[i].Level)
+				}
+			}
+		}
+	}
+}
+
+func TestMisuseDetectionIsOptIn(t *testing.T) {
+	onceSites.Clear()
+	core, observed := observer.New(zapcore.InfoLevel)
+	ctx := WithFields(context.Background(), zap.String("user_id", "u1"))
+
+	New(zap.New(core)).Info(ctx, "test", zap.String("msg", "shadowed"), zap.Int("user_id", 1))
+
+	if entries := observed.All(); len(entries) != 1 {
+		t.Errorf("expected no misuse reports without WithMisuseDetection, got %d entries", len(entries))
+	}
+}
+
+func TestStrictPanicsInDevelopment(t *testing.T) {
+	onceSites.Clear()
+	core, _ := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core, zap.Development())).Strict()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic on misuse in development mode")
+		}
+	}()
+	logger.Info(context.Background(), "test", zap.String("level", "high"))
+}
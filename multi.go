@@ -0,0 +1,135 @@
+package ctxzap
+
+import (
+	"context"
+	"os"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// exit terminates the process after a fatal entry; replaced in tests.
+var exit = os.Exit
+
+// MultiLogger fans out every call, including its context, to several fully
+// independent loggers. Unlike a tee core, each destination applies its own
+// logger-level behavior (context handling, field limits, options), so
+// policies can differ per destination.
+type MultiLogger struct {
+	loggers []*Logger
+	// fatal holds the loggers with their fatal hook disabled, so that every
+	// destination is written before the process exits.
+	fatal []*Logger
+}
+
+// NewMultiLogger creates a MultiLogger writing to all of loggers, in order.
+func NewMultiLogger(loggers ...*Logger) *MultiLogger {
+	m := &MultiLogger{
+		loggers: loggers,
+		fatal:   make([]*Logger, len(loggers)),
+	}
+	for i, l := range loggers {
+		m.fatal[i] = l.WithOptions(zap.WithFatalHook(noopHook{}))
+	}
+	return m
+}
+
+// Debug logs a message at DebugLevel on every logger.
+func (m *MultiLogger) Debug(ctx context.Context, msg string, fields ...zap.Field) {
+	for _, l := range m.loggers {
+		l.Debug(ctx, msg, fields...)
+	}
+}
+
+// Info logs a message at InfoLevel on every logger.
+func (m *MultiLogger) Info(ctx context.Context, msg string, fields ...zap.Field) {
+	for _, l := range m.loggers {
+		l.Info(ctx, msg, fields...)
+	}
+}
+
+// Warn logs a message at WarnLevel on every logger.
+func (m *MultiLogger) Warn(ctx context.Context, msg string, fields ...zap.Field) {
+	for _, l := range m.loggers {
+		l.Warn(ctx, msg, fields...)
+	}
+}
+
+// Error logs a message at ErrorLevel on every logger.
+func (m *MultiLogger) Error(ctx context.Context, msg string, fields ...zap.Field) {
+	for _, l := range m.loggers {
+		l.Error(ctx, msg, fields...)
+	}
+}
+
+// DPanic logs a message at DPanicLevel on every logger. If any logger is in
+// development mode, the first panic is re-raised after all loggers have
+// written the entry.
+func (m *MultiLogger) DPanic(ctx context.Context, msg string, fields ...zap.Field) {
+	var first any
+	for _, l := range m.loggers {
+		if r := recoverPanic(l.DPanic, ctx, msg, fields); r != nil && first == nil {
+			first = r
+		}
+	}
+	if first != nil {
+		panic(first)
+	}
+}
+
+// Panic logs a message at PanicLevel on every logger, then panics.
+func (m *MultiLogger) Panic(ctx context.Context, msg string, fields ...zap.Field) {
+	var first any
+	for _, l := range m.loggers {
+		if r := recoverPanic(l.Panic, ctx, msg, fields); r != nil && first == nil {
+			first = r
+		}
+	}
+	if first == nil {
+		first = msg
+	}
+	panic(first)
+}
+
+// Fatal logs a message at FatalLevel on every logger, syncs them, then calls
+// os.Exit(1).
+func (m *MultiLogger) Fatal(ctx context.Context, msg string, fields ...zap.Field) {
+	for _, l := range m.fatal {
+		l.Fatal(ctx, msg, fields...)
+	}
+	_ = m.Sync()
+	exit(1)
+}
+
+// With creates a child MultiLogger adding fields to every logger.
+func (m *MultiLogger) With(fields ...zap.Field) *MultiLogger {
+	loggers := make([]*Logger, len(m.loggers))
+	for i, l := range m.loggers {
+		loggers[i] = l.With(fields...)
+	}
+	return NewMultiLogger(loggers...)
+}
+
+// Sync flushes every logger, returning the combined errors.
+func (m *MultiLogger) Sync() error {
+	var err error
+	for _, l := range m.loggers {
+		err = multierr.Append(err, l.Sync())
+	}
+	return err
+}
+
+func recoverPanic(log func(context.Context, string, ...zap.Field), ctx context.Context, msg string, fields []zap.Field) (r any) {
+	defer func() {
+		r = recover()
+	}()
+	log(ctx, msg, fields...)
+	return nil
+}
+
+// noopHook continues execution after a terminal entry; the caller is then
+// responsible for terminating.
+type noopHook struct{}
+
+func (noopHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
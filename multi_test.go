@@ -0,0 +1,75 @@
+package ctxzap
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMultiLogger(t *testing.T) {
+	fullCore, full := observer.New(zapcore.DebugLevel)
+	bareCore, bare := observer.New(zapcore.InfoLevel)
+
+	multi := NewMultiLogger(
+		New(zap.New(fullCore)),
+		New(zap.New(bareCore)).Bare(),
+	)
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+	multi.Debug(ctx, "debug")
+	multi.Info(ctx, "info", zap.Int("n", 1))
+
+	if full.Len() != 2 {
+		t.Errorf("expected 2 entries in full logger, got %d", full.Len())
+	}
+	if bare.Len() != 1 {
+		t.Fatalf("expected 1 entry in bare logger, got %d", bare.Len())
+	}
+
+	if full.All()[1].ContextMap()["request_id"] != "123" {
+		t.Error("expected full logger to include context fields")
+	}
+	if _, ok := bare.All()[0].ContextMap()["request_id"]; ok {
+		t.Error("expected bare logger to exclude context fields")
+	}
+}
+
+func TestMultiLoggerPanicWritesAll(t *testing.T) {
+	core1, observed1 := observer.New(zapcore.InfoLevel)
+	core2, observed2 := observer.New(zapcore.InfoLevel)
+	multi := NewMultiLogger(New(zap.New(core1)), New(zap.New(core2)))
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic")
+		}
+		if observed1.Len() != 1 || observed2.Len() != 1 {
+			t.Errorf("expected both loggers to write before panicking, got %d and %d", observed1.Len(), observed2.Len())
+		}
+	}()
+
+	multi.Panic(context.Background(), "boom")
+}
+
+func TestMultiLoggerFatalWritesAll(t *testing.T) {
+	core1, observed1 := observer.New(zapcore.InfoLevel)
+	core2, observed2 := observer.New(zapcore.InfoLevel)
+	multi := NewMultiLogger(New(zap.New(core1)), New(zap.New(core2)))
+
+	var code int
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	multi.Fatal(context.Background(), "fatal")
+
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if observed1.Len() != 1 || observed2.Len() != 1 {
+		t.Errorf("expected both loggers to write, got %d and %d", observed1.Len(), observed2.Len())
+	}
+}
@@ -0,0 +1,88 @@
+package ctxzap
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// OnceStat reports how often a message deduplicated per call site occurred.
+type OnceStat struct {
+	File    string
+	Line    int
+	Message string
+	// Count is the number of occurrences, including the logged one.
+	Count uint64
+}
+
+type onceKey struct {
+	pc  uintptr
+	msg string
+}
+
+type onceSite struct {
+	file  string
+	line  int
+	count atomic.Uint64
+}
+
+// onceSites holds the call sites of messages logged once per process.
+var onceSites sync.Map
+
+// WarnOnce logs a message at WarnLevel the first time it is logged from the
+// calling location in the process, and only counts later occurrences, which
+// OnceStats reports. It suits warnings that would otherwise repeat on every
+// request, such as deprecated usage.
+func (l *Logger) WarnOnce(ctx context.Context, msg string, fields ...zap.Field) {
+	if !firstAtCallSite(1, msg) {
+		return
+	}
+	l.Logger.Warn(msg, l.fields(ctx, zapcore.WarnLevel, fields, nil)...)
+}
+
+// OnceStats returns the occurrence counts of the messages deduplicated per
+// call site, including ctxzap's own misuse warnings, ordered by location.
+func OnceStats() []OnceStat {
+	var stats []OnceStat
+	onceSites.Range(func(key, value any) bool {
+		site := value.(*onceSite)
+		stats = append(stats, OnceStat{
+			File:    site.file,
+			Line:    site.line,
+			Message: key.(onceKey).msg,
+			Count:   site.count.Load(),
+		})
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].File != stats[j].File {
+			return stats[i].File < stats[j].File
+		}
+		if stats[i].Line != stats[j].Line {
+			return stats[i].Line < stats[j].Line
+		}
+		return stats[i].Message < stats[j].Message
+	})
+	return stats
+}
+
+// firstAtCallSite counts an occurrence of msg at the caller skip frames
+// above its own caller, and reports whether it is the first one.
+func firstAtCallSite(skip int, msg string) bool {
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return true
+	}
+
+	key := onceKey{pc: pc, msg: msg}
+	value, ok := onceSites.Load(key)
+	if !ok {
+		value, _ = onceSites.LoadOrStore(key, &onceSite{file: file, line: line})
+	}
+	return value.(*onceSite).count.Add(1) == 1
+}
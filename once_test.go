@@ -0,0 +1,59 @@
+package ctxzap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWarnOnce(t *testing.T) {
+	onceSites.Clear()
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithFields(context.Background(), zap.String("request_id", "req-1"))
+
+	for i := 0; i < 3; i++ {
+		logger.WarnOnce(ctx, "deprecated option used")
+	}
+	logger.WarnOnce(ctx, "deprecated option used")
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected one entry per call site, got %d", len(entries))
+	}
+	if entries[0].ContextMap()["request_id"] != "req-1" {
+		t.Errorf("expected context fields, got %v", entries[0].ContextMap())
+	}
+
+	stats := OnceStats()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 call sites, got %v", stats)
+	}
+	if stats[0].Count != 3 || stats[1].Count != 1 {
+		t.Errorf("expected counts 3 and 1, got %d and %d", stats[0].Count, stats[1].Count)
+	}
+	if !strings.HasSuffix(stats[0].File, "once_test.go") || stats[0].Message != "deprecated option used" {
+		t.Errorf("unexpected call site: %+v", stats[0])
+	}
+}
+
+func TestMisuseReportedOncePerCallSite(t *testing.T) {
+	onceSites.Clear()
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithMisuseDetection())
+
+	for i := 0; i < 3; i++ {
+		logger.Info(context.Background(), "test", zap.String("msg", "shadowed"))
+	}
+
+	if entries := observed.All(); len(entries) != 4 {
+		t.Errorf("expected 3 entries and 1 misuse warning, got %d entries", len(entries))
+	}
+	if stats := OnceStats(); len(stats) != 1 || stats[0].Count != 3 {
+		t.Errorf("expected 3 occurrences of the misuse, got %+v", stats)
+	}
+}
@@ -0,0 +1,114 @@
+package ctxzap
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Keys of the fields added by operations.
+const (
+	OperationIDKey       = "operation_id"
+	OperationKey         = "operation"
+	ParentOperationIDKey = "parent_operation_id"
+	OperationDepthKey    = "operation_depth"
+)
+
+// operationContextKey is used as a key for storing an operation in context
+type operationContextKey struct{}
+
+var operationKey = operationContextKey{}
+
+// operation tracks a multi-entry operation started with BeginOperation.
+type operation struct {
+	id      string
+	name    string
+	start   time.Time
+	logger  *Logger
+	parent  *operation
+	depth   int
+	entries atomic.Int64
+	ended   atomic.Bool
+}
+
+// BeginOperation starts a named operation grouping the entries logged with
+// the returned context, so that log UIs can collapse them together. Every
+// subsequent entry carries an "operation_id" field, and begin and end
+// markers are logged with the logger from L(ctx):
+//
+//	ctx, opID := ctxzap.BeginOperation(ctx, "import_users")
+//	defer ctxzap.EndOperation(ctx)
+//
+// The returned ID is the value of the operation_id field.
+//
+// Operations nest: an operation begun with the context of another one
+// records it in a "parent_operation_id" field and its nesting level in an
+// "operation_depth" field, starting at 1, which gives long pipelines a
+// lightweight hierarchical trace without a tracing backend.
+func BeginOperation(ctx context.Context, name string) (context.Context, string) {
+	op := &operation{
+		id:     newOperationID(),
+		name:   name,
+		start:  time.Now(),
+		logger: L(ctx),
+		parent: operationFrom(ctx),
+	}
+
+	fields := []zap.Field{zap.String(OperationIDKey, op.id)}
+	if op.parent != nil {
+		op.depth = op.parent.depth + 1
+		fields = append(fields,
+			zap.String(ParentOperationIDKey, op.parent.id),
+			zap.Int(OperationDepthKey, op.depth),
+		)
+	}
+
+	ctx = WithFields(ctx, fields...)
+	op.logger.Info(ctx, "operation started", zap.String(OperationKey, op.name))
+	return context.WithValue(ctx, operationKey, op), op.id
+}
+
+// EndOperation logs the end marker of the operation started by
+// BeginOperation in ctx, with its duration, the number of entries logged at
+// enabled levels within it (nested operations included), and fields, e.g.
+// the operation's error. Only the first call for an operation logs; calls on
+// a context without an operation do nothing.
+func EndOperation(ctx context.Context, fields ...zap.Field) {
+	op := operationFrom(ctx)
+	if op == nil || !op.ended.CompareAndSwap(false, true) {
+		return
+	}
+
+	marker := []zap.Field{
+		zap.String(OperationKey, op.name),
+		zap.Duration("duration", time.Since(op.start)),
+		zap.Int64("entries", op.entries.Load()),
+	}
+	op.logger.Info(ctx, "operation finished", append(marker, fields...)...)
+}
+
+// count records an entry logged within op and its ancestors.
+func (op *operation) count() {
+	for ; op != nil; op = op.parent {
+		op.entries.Add(1)
+	}
+}
+
+func operationFrom(ctx context.Context) *operation {
+	if ctx == nil {
+		return nil
+	}
+	op, _ := ctx.Value(operationKey).(*operation)
+	return op
+}
+
+// newOperationID returns a random 16-character hex ID.
+func newOperationID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,99 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestOperation(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithLogger(context.Background(), logger)
+
+	opCtx, opID := BeginOperation(ctx, "import_users")
+	logger.Info(opCtx, "imported", zap.Int("count", 10))
+	logger.Debug(opCtx, "filtered out")
+	logger.Warn(opCtx, "skipped duplicate")
+	EndOperation(opCtx, zap.Error(errors.New("partial import")))
+	EndOperation(opCtx)
+	logger.Info(ctx, "outside")
+
+	entries := observed.All()
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(entries))
+	}
+
+	for _, entry := range entries[:4] {
+		if got := entry.ContextMap()[OperationIDKey]; got != opID {
+			t.Errorf("%q: expected operation_id %s, got %v", entry.Message, opID, got)
+		}
+	}
+	if _, ok := entries[4].ContextMap()[OperationIDKey]; ok {
+		t.Error("expected no operation_id outside the operation")
+	}
+
+	begin := entries[0]
+	if begin.Message != "operation started" || begin.ContextMap()[OperationKey] != "import_users" {
+		t.Errorf("unexpected begin marker: %s %v", begin.Message, begin.ContextMap())
+	}
+
+	end := entries[3].ContextMap()
+	if entries[3].Message != "operation finished" {
+		t.Errorf("unexpected end marker: %s", entries[3].Message)
+	}
+	if end["entries"] != int64(2) {
+		t.Errorf("expected 2 entries counted, got %v", end["entries"])
+	}
+	if _, ok := end["duration"]; !ok {
+		t.Error("expected a duration on the end marker")
+	}
+	if end["error"] != "partial import" {
+		t.Errorf("expected the end fields to be logged, got %v", end)
+	}
+}
+
+func TestNestedOperation(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	ctx := WithLogger(context.Background(), logger)
+
+	rootCtx, rootID := BeginOperation(ctx, "pipeline")
+	stageCtx, stageID := BeginOperation(rootCtx, "extract")
+	logger.Info(stageCtx, "batch read")
+	EndOperation(stageCtx)
+	EndOperation(rootCtx)
+
+	entries := observed.All()
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(entries))
+	}
+
+	root := entries[0].ContextMap()
+	if _, ok := root[ParentOperationIDKey]; ok {
+		t.Errorf("expected no parent on the root operation, got %v", root)
+	}
+
+	for _, entry := range entries[1:4] {
+		fields := entry.ContextMap()
+		if fields[OperationIDKey] != stageID || fields[ParentOperationIDKey] != rootID || fields[OperationDepthKey] != int64(1) {
+			t.Errorf("%q: unexpected nested fields %v", entry.Message, fields)
+		}
+	}
+
+	if got := entries[3].ContextMap()["entries"]; got != int64(1) {
+		t.Errorf("expected 1 entry in the nested operation, got %v", got)
+	}
+	rootEnd := entries[4].ContextMap()
+	if rootEnd[OperationIDKey] != rootID {
+		t.Errorf("expected the root end marker, got %v", rootEnd)
+	}
+	// The nested operation's markers and entry count towards the root.
+	if got := rootEnd["entries"]; got != int64(3) {
+		t.Errorf("expected 3 entries in the root operation, got %v", got)
+	}
+}
@@ -0,0 +1,155 @@
+package ctxzap
+
+import (
+	"context"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Option configures a Logger created with New. Options set how a logger
+// behaves; With, WithOptions, WithCore and Bare derive loggers from an
+// existing one.
+type Option func(*Logger)
+
+// MergeStrategy combines the context fields of an entry with its call-site
+// fields. MergeFields, the default, lets call-site fields override context
+// fields with the same key.
+type MergeStrategy func(contextFields, fields []zap.Field) []zap.Field
+
+// Enricher computes fields from the context of each entry, e.g. trace IDs
+// held by a tracing library. It is called for every entry written with a
+// context, so it should be cheap.
+type Enricher func(ctx context.Context) []zap.Field
+
+// WithMergeStrategy sets how context fields and call-site fields are
+// combined, e.g. KeepContextFields or AppendFields.
+func WithMergeStrategy(merge MergeStrategy) Option {
+	return func(l *Logger) {
+		l.merge = merge
+	}
+}
+
+// WithoutDeduplication makes the logger write the context fields followed by
+// the call-site fields as they are, without looking for duplicate keys, like
+// AppendFields, for services whose keys are known not to collide. Most JSON
+// consumers keep the last of duplicate keys, so call-site fields still
+// appear to override context fields.
+//
+// Unlike WithMergeStrategy(AppendFields), it combines with WithLoggerCache
+// and WithPooledFields, so the merge costs nothing but the copy of the
+// fields. Merge strategies passed with MergeWith still apply.
+func WithoutDeduplication() Option {
+	return func(l *Logger) {
+		l.noDedup = true
+	}
+}
+
+// WithCallerSkip adds skip to the number of frames skipped when reporting
+// the caller. Pass 1 for the caller of the ctxzap logging method to be
+// reported, unless the zap logger already skips that frame (NewDevelopment
+// does).
+func WithCallerSkip(skip int) Option {
+	return func(l *Logger) {
+		l.Logger = l.Logger.WithOptions(zap.AddCallerSkip(skip))
+	}
+}
+
+// WithEnrichers adds enrichers whose fields are added to every entry logged
+// with a context. They take precedence over the fields stored in the
+// context, and call-site fields take precedence over them.
+func WithEnrichers(enrichers ...Enricher) Option {
+	return func(l *Logger) {
+		l.enrichers = append(l.enrichers[:len(l.enrichers):len(l.enrichers)], enrichers...)
+	}
+}
+
+// KeepContextFields is a keep-first MergeStrategy letting context fields win
+// over call-site fields with the same key, e.g. so that handlers cannot
+// overwrite a request_id set by middleware.
+func KeepContextFields(contextFields, fields []zap.Field) []zap.Field {
+	return MergeFields(fields, contextFields)
+}
+
+// AppendFields is a MergeStrategy writing context fields followed by all
+// call-site fields without deduplicating keys, which is the cheapest
+// strategy when keys are known not to collide.
+func AppendFields(contextFields, fields []zap.Field) []zap.Field {
+	merged := make([]zap.Field, 0, len(contextFields)+len(fields))
+	merged = append(merged, contextFields...)
+	return append(merged, fields...)
+}
+
+// RenameConflicts is a MergeStrategy keeping every value: call-site fields
+// whose key is already used are renamed with the first free numeric suffix,
+// so a user_id call-site field next to a user_id context field is written as
+// user_id_2.
+func RenameConflicts(contextFields, fields []zap.Field) []zap.Field {
+	taken := make(map[string]struct{}, len(contextFields)+len(fields))
+	merged := make([]zap.Field, 0, len(contextFields)+len(fields))
+	for _, f := range contextFields {
+		taken[f.Key] = struct{}{}
+		merged = append(merged, f)
+	}
+	for _, f := range fields {
+		if _, ok := taken[f.Key]; ok {
+			key := f.Key
+			for n := 2; ; n++ {
+				f.Key = key + "_" + strconv.Itoa(n)
+				if _, ok := taken[f.Key]; !ok {
+					break
+				}
+			}
+		}
+		taken[f.Key] = struct{}{}
+		merged = append(merged, f)
+	}
+	return merged
+}
+
+// ErrorOnConflict is a MergeStrategy treating a call-site field with the key
+// of a context field as a bug: the logger reports it at DPanicLevel, once per
+// call site, so that a development logger panics, and keeps the context
+// field as KeepContextFields does.
+func ErrorOnConflict(contextFields, fields []zap.Field) []zap.Field {
+	var conflicts mergeConflicts
+	for _, f := range fields {
+		for _, c := range contextFields {
+			if f.Key == c.Key {
+				conflicts = append(conflicts, f.Key)
+				break
+			}
+		}
+	}
+
+	merged := MergeFields(fields, contextFields)
+	if len(conflicts) > 0 {
+		merged = append(merged, zap.Field{Key: callOptionKey, Type: zapcore.SkipType, Interface: conflicts})
+	}
+	return merged
+}
+
+// mergeConflicts carries the conflicting keys found by ErrorOnConflict to
+// the logger, which reports them.
+type mergeConflicts []string
+
+// reportConflicts strips the conflicts recorded by ErrorOnConflict from
+// merged fields and reports them. It must be called directly by fields.
+func (l *Logger) reportConflicts(fields []zap.Field) []zap.Field {
+	if len(fields) == 0 {
+		return fields
+	}
+	last := fields[len(fields)-1]
+	conflicts, ok := last.Interface.(mergeConflicts)
+	if !ok || last.Key != callOptionKey || last.Type != zapcore.SkipType {
+		return fields
+	}
+
+	const msg = "ctxzap: call-site field conflicts with context field"
+	// Skip the caller, fields and the level method.
+	if firstAtCallSite(3, msg) {
+		l.Logger.DPanic(msg, zap.Strings("keys", conflicts))
+	}
+	return fields[:len(fields)-1]
+}
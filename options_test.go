@@ -0,0 +1,176 @@
+package ctxzap
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type traceKey struct{}
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		check func(t *testing.T, fields []zapcore.Field, ctxMap map[string]any)
+	}{
+		{
+			name: "default merge",
+			check: func(t *testing.T, _ []zapcore.Field, m map[string]any) {
+				if m["request_id"] != "call" {
+					t.Errorf("expected call-site field to win, got %v", m["request_id"])
+				}
+			},
+		},
+		{
+			name: "keep context fields",
+			opts: []Option{WithMergeStrategy(KeepContextFields)},
+			check: func(t *testing.T, _ []zapcore.Field, m map[string]any) {
+				if m["request_id"] != "ctx" {
+					t.Errorf("expected context field to win, got %v", m["request_id"])
+				}
+			},
+		},
+		{
+			name: "append fields",
+			opts: []Option{WithMergeStrategy(AppendFields)},
+			check: func(t *testing.T, fields []zapcore.Field, _ map[string]any) {
+				if len(fields) != 2 {
+					t.Errorf("expected both fields, got %v", fields)
+				}
+			},
+		},
+		{
+			name: "without deduplication",
+			opts: []Option{WithoutDeduplication()},
+			check: func(t *testing.T, fields []zapcore.Field, _ map[string]any) {
+				if len(fields) != 2 || fields[0].String != "ctx" || fields[1].String != "call" {
+					t.Errorf("expected context then call-site field, got %v", fields)
+				}
+			},
+		},
+		{
+			name: "rename conflicts",
+			opts: []Option{WithMergeStrategy(RenameConflicts)},
+			check: func(t *testing.T, _ []zapcore.Field, m map[string]any) {
+				if m["request_id"] != "ctx" || m["request_id_2"] != "call" {
+					t.Errorf("expected the call-site field renamed, got %v", m)
+				}
+			},
+		},
+		{
+			name: "per-call merge",
+			opts: []Option{WithMergeStrategy(KeepContextFields)},
+			check: func(t *testing.T, _ []zapcore.Field, m map[string]any) {
+				if m["request_id"] != "call" {
+					t.Errorf("expected MergeWith to override the logger's strategy, got %v", m["request_id"])
+				}
+			},
+		},
+		{
+			name: "enrichers",
+			opts: []Option{WithEnrichers(func(ctx context.Context) []zap.Field {
+				trace, _ := ctx.Value(traceKey{}).(string)
+				return []zap.Field{zap.String("trace_id", trace)}
+			})},
+			check: func(t *testing.T, _ []zapcore.Field, m map[string]any) {
+				if m["trace_id"] != "t1" || m["request_id"] != "call" {
+					t.Errorf("expected enriched fields, got %v", m)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, observed := observer.New(zapcore.InfoLevel)
+			logger := New(zap.New(core), tt.opts...)
+			ctx := context.WithValue(context.Background(), traceKey{}, "t1")
+			ctx = WithFields(ctx, zap.String("request_id", "ctx"))
+
+			fields := []zap.Field{zap.String("request_id", "call")}
+			if tt.name == "per-call merge" {
+				fields = append(fields, MergeWith(MergeFields))
+			}
+			logger.Info(ctx, "msg", fields...)
+
+			entry := observed.All()[0]
+			tt.check(t, entry.Context, entry.ContextMap())
+		})
+	}
+}
+
+func TestRenameConflictsSuffixes(t *testing.T) {
+	merged := RenameConflicts(
+		[]zap.Field{zap.String("user_id", "a"), zap.String("user_id_2", "b")},
+		[]zap.Field{zap.String("user_id", "c"), zap.String("user_id", "d"), zap.String("other", "e")},
+	)
+	var keys []string
+	for _, f := range merged {
+		keys = append(keys, f.Key+"="+f.String)
+	}
+	if got := strings.Join(keys, ","); got != "user_id=a,user_id_2=b,user_id_3=c,user_id_4=d,other=e" {
+		t.Errorf("unexpected merge: %s", got)
+	}
+}
+
+func TestErrorOnConflict(t *testing.T) {
+	onceSites.Clear()
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithMergeStrategy(ErrorOnConflict))
+	ctx := WithFields(context.Background(), zap.String("user_id", "alice"))
+
+	for range 2 {
+		logger.Info(ctx, "msg", zap.String("user_id", "bob"), zap.Int("n", 1))
+	}
+	logger.Info(ctx, "no conflict", zap.Int("n", 1))
+
+	entries := observed.All()
+	if len(entries) != 4 || entries[0].Level != zapcore.DPanicLevel {
+		t.Fatalf("expected one conflict report and 3 entries, got %v", entries)
+	}
+	if keys := entries[0].ContextMap()["keys"]; fmt.Sprint(keys) != "[user_id]" {
+		t.Errorf("unexpected conflicting keys: %v", keys)
+	}
+	for _, e := range entries[1:] {
+		if m := e.ContextMap(); m["user_id"] != "alice" || len(m) != 2 {
+			t.Errorf("expected the context field kept without markers, got %v", m)
+		}
+	}
+
+	dev := New(zap.New(core, zap.Development()), WithMergeStrategy(ErrorOnConflict))
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic on conflict in development mode")
+		}
+	}()
+	dev.Info(ctx, "msg", zap.String("user_id", "bob"))
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core, zap.AddCaller()), WithCallerSkip(1))
+
+	logger.Info(context.Background(), "msg")
+	if file := filepath.Base(observed.All()[0].Caller.File); file != "options_test.go" {
+		t.Errorf("expected caller in options_test.go, got %s", file)
+	}
+}
+
+func TestEnrichersInWithContext(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithEnrichers(func(context.Context) []zap.Field {
+		return []zap.Field{zap.String("region", "eu")}
+	}))
+
+	logger.WithContext(context.Background()).Info("msg")
+	if observed.All()[0].ContextMap()["region"] != "eu" {
+		t.Errorf("expected enriched fields, got %v", observed.All()[0].ContextMap())
+	}
+}
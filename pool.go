@@ -0,0 +1,81 @@
+package ctxzap
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// maxPooledFields bounds the capacity of the field slices returned to the
+// pool, so that one entry with many fields does not pin a large array.
+const maxPooledFields = 64
+
+// WithPooledFields makes the level methods merge the context fields and the
+// call-site fields into a slice taken from a sync.Pool, and return it to the
+// pool once the entry is written, instead of allocating the merged slice and
+// its deduplication map for every entry.
+//
+// As with FieldBuilder, this relies on the cores not retaining the fields
+// after Write returns. The cores of zap do not, but a core that buffers
+// fields for a later, asynchronous write (for example, a test observer or a
+// batching exporter) would see them change, so the option is opt-in.
+//
+// It applies to Debug, Info, Warn and Error and their FieldBuilder
+// counterparts, when the default merge or WithoutDeduplication is used.
+func WithPooledFields() Option {
+	return func(l *Logger) {
+		l.pooled = true
+	}
+}
+
+// fieldBuffer holds the storage reused to merge fields for one entry.
+type fieldBuffer struct {
+	fields []zap.Field
+	index  map[string]zap.Field
+}
+
+var fieldBuffers = sync.Pool{
+	New: func() any {
+		return &fieldBuffer{
+			fields: make([]zap.Field, 0, 16),
+			index:  make(map[string]zap.Field, 16),
+		}
+	},
+}
+
+// fieldBuffer returns a buffer from the pool, or nil unless l uses
+// WithPooledFields. The buffer must be released once the entry is written.
+func (l *Logger) fieldBuffer() *fieldBuffer {
+	if !l.pooled {
+		return nil
+	}
+	return fieldBuffers.Get().(*fieldBuffer)
+}
+
+// merge merges fields as MergeFields does, into the storage of b.
+func (b *fieldBuffer) merge(existingFields, newFields []zap.Field) []zap.Field {
+	b.fields = appendMerged(b.fields[:0], b.index, existingFields, newFields)
+	return b.fields
+}
+
+// concat appends newFields to existingFields, as AppendFields does, into the
+// storage of b.
+func (b *fieldBuffer) concat(existingFields, newFields []zap.Field) []zap.Field {
+	b.fields = append(append(b.fields[:0], existingFields...), newFields...)
+	return b.fields
+}
+
+// release returns b to the pool. It does nothing if b is nil.
+func (b *fieldBuffer) release() {
+	if b == nil {
+		return
+	}
+	if cap(b.fields) > maxPooledFields {
+		return
+	}
+	// Drop the references held by the fields so they can be collected.
+	clear(b.fields[:cap(b.fields)])
+	b.fields = b.fields[:0]
+	clear(b.index)
+	fieldBuffers.Put(b)
+}
@@ -0,0 +1,56 @@
+package ctxzap
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithPooledFields(t *testing.T) {
+	ctx := WithFields(context.Background(), zap.String("request_id", "abc"), zap.String("user", "alice"))
+	log := func(opts ...Option) string {
+		var buf bytes.Buffer
+		encoderConfig := zap.NewProductionEncoderConfig()
+		encoderConfig.TimeKey = ""
+		logger := New(zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(&buf), zapcore.InfoLevel)), opts...)
+
+		logger.Info(ctx, "first", zap.Int("n", 1), zap.String("user", "bob"))
+		logger.Warn(ctx, "second", zap.Int("n", 2))
+		logger.Error(ctx, "third")
+		logger.Debug(ctx, "disabled", zap.Int("n", 3))
+		logger.InfoB(ctx, "fourth", NewFieldBuilder(2).Int("n", 4).String("request_id", "def"))
+		logger.Info(ctx, "fifth", zap.Int("n", 5), MergeWith(AppendFields))
+		return buf.String()
+	}
+
+	want := log()
+	if got := log(WithPooledFields()); got != want {
+		t.Errorf("pooled output differs:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	want = log(WithoutDeduplication())
+	if got := log(WithoutDeduplication(), WithPooledFields()); got != want {
+		t.Errorf("pooled output without deduplication differs:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if !strings.Contains(want, `"user":"alice","n":1,"user":"bob"`) {
+		t.Errorf("expected duplicate keys without deduplication, got:\n%s", want)
+	}
+}
+
+func TestFieldBufferRelease(t *testing.T) {
+	var b *fieldBuffer
+	b.release()
+
+	b = &fieldBuffer{index: make(map[string]zap.Field)}
+	merged := b.merge([]zap.Field{zap.String("a", "1"), zap.String("b", "2")}, []zap.Field{zap.String("b", "3")})
+	if len(merged) != 2 || merged[1].String != "3" || len(b.index) != 0 {
+		t.Errorf("unexpected merge: %v, index %v", merged, b.index)
+	}
+	b.release()
+	if len(b.fields) != 0 || b.fields[:2][0].Key != "" {
+		t.Errorf("expected the released buffer to be cleared, got %v", b.fields[:2])
+	}
+}
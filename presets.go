@@ -0,0 +1,40 @@
+package ctxzap
+
+import (
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewDevelopment builds a logger for local runs that writes every entry
+// twice: as machine-readable JSON to the file at jsonPath, for tools such as
+// ctxzap-cat, and as console output to stderr, colorized unless stderr is
+// not a terminal or NO_COLOR is set (see ColorsEnabled). Both outputs record
+// Debug and above, and DPanic panics as with zap.NewDevelopment. Close the
+// logger to close the file.
+func NewDevelopment(jsonPath string, opts ...zap.Option) (*Logger, error) {
+	file, closeFile, err := zap.Open(jsonPath)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonConfig := zap.NewProductionEncoderConfig()
+	jsonConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+
+	consoleConfig := zap.NewDevelopmentEncoderConfig()
+	consoleConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	consoleOptions := ConsoleOptions{LevelWidth: 5, NoColor: !ColorsEnabled(os.Stderr)}
+
+	core := zapcore.NewTee(
+		zapcore.NewCore(zapcore.NewJSONEncoder(jsonConfig), file, zapcore.DebugLevel),
+		zapcore.NewCore(NewConsoleEncoder(consoleConfig, consoleOptions), zapcore.Lock(os.Stderr), zapcore.DebugLevel),
+	)
+
+	// Skip the ctxzap frame so callers point at application code.
+	base := []zap.Option{zap.Development(), zap.AddCaller(), zap.AddCallerSkip(1), zap.AddStacktrace(zapcore.WarnLevel)}
+	l := New(zap.New(core, append(base, opts...)...))
+	l.closeOutputs = sync.OnceFunc(closeFile)
+	return l, nil
+}
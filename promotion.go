@@ -0,0 +1,123 @@
+package ctxzap
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// maxPromotionContexts bounds the number of contexts tracked by a promotion
+// advisor; contexts first logged after the limit is reached are ignored.
+const maxPromotionContexts = 1000
+
+// PromotionAdvice describes a context logged with often enough that its
+// fields are better attached once, with Logger.WithContext, than merged into
+// every entry.
+type PromotionAdvice struct {
+	// Keys are the keys of the fields stored in the context.
+	Keys []string
+	// Entries is the number of entries logged with the context.
+	Entries uint64
+	// Function, File and Line locate the first entry logged with the context,
+	// the first place to look for the loop or handler to change.
+	Function string
+	File     string
+	Line     int
+}
+
+// WithPromotionAdvice makes the logger, for profiling and benchmark runs,
+// count the entries logged with each context carrying fields, so that
+// PromotionAdvice can point out the contexts logged with more than threshold
+// times. Each of their entries merges the same context fields
+// again; promoting them to a child logger is usually the cheapest
+// performance win in hot paths:
+//
+//	log := logger.WithContext(ctx) // a *zap.Logger with the context fields
+//	for _, item := range items {
+//		log.Info("processed", zap.String("id", item.ID))
+//	}
+//
+// Contexts are identified by the fields stored with WithFields, so contexts
+// derived without adding fields count as one. Tracking takes a lock for
+// every entry and retains the fields of up to 1000 contexts, so it is not
+// meant for production.
+func WithPromotionAdvice(threshold int) Option {
+	return func(l *Logger) {
+		l.promotion = &promotionAdvisor{
+			threshold: uint64(max(threshold, 0)),
+			contexts:  make(map[*fieldNode]*PromotionAdvice),
+		}
+	}
+}
+
+// PromotionAdvice returns the contexts logged with more than the threshold
+// of WithPromotionAdvice, most logged first. It is empty unless the logger
+// was created with WithPromotionAdvice.
+func (l *Logger) PromotionAdvice() []PromotionAdvice {
+	if l.promotion == nil {
+		return nil
+	}
+	return l.promotion.advice()
+}
+
+type promotionAdvisor struct {
+	threshold uint64
+
+	mu       sync.Mutex
+	contexts map[*fieldNode]*PromotionAdvice
+}
+
+// observe counts an entry logged with ctx.
+func (a *promotionAdvisor) observe(ctx context.Context) {
+	// Each WithFields call stores a new node, which identifies the context.
+	id := fieldNodeFrom(ctx)
+	fields := id.resolve()
+	if len(fields) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if c, ok := a.contexts[id]; ok {
+		c.Entries++
+		return
+	}
+	if len(a.contexts) >= maxPromotionContexts {
+		return
+	}
+
+	keys := make([]string, len(fields))
+	for i := range fields {
+		keys[i] = fields[i].Key
+	}
+	origin := callerOutsidePackage()
+	a.contexts[id] = &PromotionAdvice{
+		Keys:     keys,
+		Entries:  1,
+		Function: origin.Function,
+		File:     origin.File,
+		Line:     origin.Line,
+	}
+}
+
+func (a *promotionAdvisor) advice() []PromotionAdvice {
+	a.mu.Lock()
+	var advice []PromotionAdvice
+	for _, c := range a.contexts {
+		if c.Entries > a.threshold {
+			advice = append(advice, *c)
+		}
+	}
+	a.mu.Unlock()
+
+	sort.Slice(advice, func(i, j int) bool {
+		if advice[i].Entries != advice[j].Entries {
+			return advice[i].Entries > advice[j].Entries
+		}
+		if advice[i].File != advice[j].File {
+			return advice[i].File < advice[j].File
+		}
+		return advice[i].Line < advice[j].Line
+	})
+	return advice
+}
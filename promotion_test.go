@@ -0,0 +1,48 @@
+package ctxzap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPromotionAdvice(t *testing.T) {
+	core, _ := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithPromotionAdvice(3))
+
+	hot := WithFields(context.Background(), zap.String("request_id", "abc"), zap.String("tenant", "acme"))
+	for i := range 5 {
+		logger.Info(hot, "item processed", zap.Int("i", i))
+	}
+	logger.Debug(hot, "disabled level, not counted")
+
+	warm := WithFields(context.Background(), zap.String("job", "import"))
+	for range 4 {
+		logger.Warn(warm, "slow")
+	}
+	cold := WithFields(context.Background(), zap.String("job", "export"))
+	logger.Info(cold, "done")
+	logger.Info(context.Background(), "no fields")
+
+	advice := logger.PromotionAdvice()
+	if len(advice) != 2 {
+		t.Fatalf("expected 2 contexts above the threshold, got %+v", advice)
+	}
+	if advice[0].Entries != 5 || strings.Join(advice[0].Keys, ",") != "request_id,tenant" {
+		t.Errorf("unexpected first advice: %+v", advice[0])
+	}
+	if advice[1].Entries != 4 || advice[1].Keys[0] != "job" {
+		t.Errorf("unexpected second advice: %+v", advice[1])
+	}
+	if !strings.HasSuffix(advice[0].File, "promotion_test.go") || !strings.HasSuffix(advice[0].Function, "TestPromotionAdvice") {
+		t.Errorf("expected the first logging call site, got %+v", advice[0])
+	}
+
+	if New(zap.New(core)).PromotionAdvice() != nil {
+		t.Error("expected no advice without WithPromotionAdvice")
+	}
+}
@@ -0,0 +1,283 @@
+package ctxzap
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/algobardo/ctxzap/internal/fieldcodec"
+)
+
+// PropagationKey is the carrier key used by Propagator when Key is empty.
+const PropagationKey = "ctxzap-fields"
+
+// Limits applied by Propagator.Extract when MaxExtractSize and
+// MaxExtractFields are zero, as carrier data comes from other processes.
+const (
+	DefaultMaxExtractSize   = 8 << 10
+	DefaultMaxExtractFields = 64
+)
+
+// Carrier is a transport's key-value metadata, such as HTTP headers or
+// message headers.
+type Carrier interface {
+	// Get returns the value for key, or "" if there is none.
+	Get(key string) string
+	// Set stores value under key, replacing any existing value.
+	Set(key, value string)
+}
+
+// FieldPropagator carries context fields across a transport, so that every
+// transport integration shares one codec.
+type FieldPropagator interface {
+	// Inject writes the fields of ctx into carrier.
+	Inject(ctx context.Context, carrier Carrier) error
+	// Extract returns ctx with the fields found in carrier added to it. If
+	// carrier holds no fields, ctx is returned unchanged.
+	Extract(ctx context.Context, carrier Carrier) (context.Context, error)
+}
+
+// PropagationPolicy decides whether a field crosses process boundaries, in
+// either direction.
+type PropagationPolicy int
+
+const (
+	// Propagate sends the field downstream.
+	Propagate PropagationPolicy = iota
+	// LocalOnly keeps the field in the local process, and ignores it in
+	// carriers received from other processes.
+	LocalOnly
+)
+
+// Propagator is the FieldPropagator storing the fields serialized by
+// MarshalFields, base64url-encoded, under a single carrier key.
+type Propagator struct {
+	// Key is the carrier key. Defaults to PropagationKey.
+	Key string
+	// Policies sets the policy of individual keys, applied both by Inject
+	// and by Extract. Keys not listed use DefaultPolicy, so an allowlist is
+	// a DefaultPolicy of LocalOnly with the allowed keys set to Propagate;
+	// services extracting fields from untrusted callers should use one.
+	Policies map[string]PropagationPolicy
+	// DefaultPolicy applies to keys not listed in Policies.
+	DefaultPolicy PropagationPolicy
+	// MaxSize bounds the size in bytes of the injected value, e.g. to stay
+	// within downstream header limits. Fields are kept in context order
+	// while they fit; fields that would exceed the budget are dropped, so
+	// the result only depends on the fields. Zero or negative means no limit.
+	MaxSize int
+	// MaxExtractSize bounds the size in bytes of the carrier value Extract
+	// accepts; larger values are rejected before being decoded. Defaults to
+	// DefaultMaxExtractSize; negative means no limit.
+	MaxExtractSize int
+	// MaxExtractFields bounds the number of fields Extract accepts; values
+	// holding more are rejected. Defaults to DefaultMaxExtractFields;
+	// negative means no limit.
+	MaxExtractFields int
+}
+
+var _ FieldPropagator = Propagator{}
+
+func (p Propagator) key() string {
+	if p.Key == "" {
+		return PropagationKey
+	}
+	return p.Key
+}
+
+// Inject implements FieldPropagator. Nothing is written when ctx has no
+// fields.
+func (p Propagator) Inject(ctx context.Context, carrier Carrier) error {
+	fields := p.propagated(entryFields(ctx))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if p.MaxSize > 0 {
+		data, err = p.marshalWithin(fields)
+	} else {
+		data, err = fieldcodec.Marshal(fields)
+	}
+	if err != nil || data == nil {
+		return err
+	}
+	carrier.Set(p.key(), base64.RawURLEncoding.EncodeToString(data))
+	return nil
+}
+
+// propagated returns the fields whose policy is Propagate.
+func (p Propagator) propagated(fields []zap.Field) []zap.Field {
+	if len(p.Policies) == 0 && p.DefaultPolicy == Propagate {
+		return fields
+	}
+	result := fields[:0]
+	for _, f := range fields {
+		policy, ok := p.Policies[f.Key]
+		if !ok {
+			policy = p.DefaultPolicy
+		}
+		if policy == Propagate {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// marshalWithin encodes the fields that fit in MaxSize once base64-encoded,
+// in order. It returns nil if none fits.
+func (p Propagator) marshalWithin(fields []zap.Field) ([]byte, error) {
+	wire, err := fieldcodec.ToWire(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte{'['}
+	for _, w := range wire {
+		raw, err := json.Marshal(w)
+		if err != nil {
+			return nil, err
+		}
+		// The array grows by the field, a separator if needed and the
+		// closing bracket.
+		size := len(data) + len(raw) + 1
+		if len(data) > 1 {
+			size++
+		}
+		if base64.RawURLEncoding.EncodedLen(size) > p.MaxSize {
+			continue
+		}
+		if len(data) > 1 {
+			data = append(data, ',')
+		}
+		data = append(data, raw...)
+	}
+	if len(data) == 1 {
+		return nil, nil
+	}
+	return append(data, ']'), nil
+}
+
+// Extract implements FieldPropagator. Fields whose policy is LocalOnly are
+// ignored, and values exceeding MaxExtractSize or MaxExtractFields are
+// rejected with an error, leaving ctx unchanged.
+func (p Propagator) Extract(ctx context.Context, carrier Carrier) (context.Context, error) {
+	value := carrier.Get(p.key())
+	if value == "" {
+		return ctx, nil
+	}
+	if limit := extractLimit(p.MaxExtractSize, DefaultMaxExtractSize); limit >= 0 && len(value) > limit {
+		return ctx, fmt.Errorf("ctxzap: %s is %d bytes, more than the limit of %d", p.key(), len(value), limit)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return ctx, fmt.Errorf("ctxzap: decoding %s: %w", p.key(), err)
+	}
+	fields, err := fieldcodec.Unmarshal(data)
+	if err != nil {
+		return ctx, err
+	}
+	if limit := extractLimit(p.MaxExtractFields, DefaultMaxExtractFields); limit >= 0 && len(fields) > limit {
+		return ctx, fmt.Errorf("ctxzap: %s holds %d fields, more than the limit of %d", p.key(), len(fields), limit)
+	}
+	return WithFields(ctx, p.propagated(fields)...), nil
+}
+
+// extractLimit returns limit, or def if limit is zero.
+func extractLimit(limit, def int) int {
+	if limit == 0 {
+		return def
+	}
+	return limit
+}
+
+// HeaderCarrier adapts http.Header to the Carrier interface.
+type HeaderCarrier http.Header
+
+// Get returns the first value for key.
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+// Set replaces the values for key.
+func (c HeaderCarrier) Set(key, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// MetadataCarrier adapts gRPC metadata to the Carrier interface; a
+// metadata.MD converts to it directly. Keys are lowercased, as gRPC requires.
+type MetadataCarrier map[string][]string
+
+// Get returns the first value for key.
+func (c MetadataCarrier) Get(key string) string {
+	if values := c[strings.ToLower(key)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Set replaces the values for key.
+func (c MetadataCarrier) Set(key, value string) {
+	c[strings.ToLower(key)] = []string{value}
+}
+
+// KafkaHeader is a Kafka record header. The header types of the common Kafka
+// clients convert to and from it directly.
+type KafkaHeader struct {
+	Key   string
+	Value []byte
+}
+
+// KafkaHeadersCarrier adapts a list of Kafka record headers to the Carrier
+// interface. Set needs a pointer, since it may append to the list.
+type KafkaHeadersCarrier []KafkaHeader
+
+// Get returns the value of the last header with key, the one Kafka clients
+// consider current.
+func (c KafkaHeadersCarrier) Get(key string) string {
+	for i := len(c) - 1; i >= 0; i-- {
+		if c[i].Key == key {
+			return string(c[i].Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces the headers with key by a single one.
+func (c *KafkaHeadersCarrier) Set(key, value string) {
+	headers := (*c)[:0]
+	for _, h := range *c {
+		if h.Key != key {
+			headers = append(headers, h)
+		}
+	}
+	*c = append(headers, KafkaHeader{Key: key, Value: []byte(value)})
+}
+
+// AMQPTableCarrier adapts AMQP message headers to the Carrier interface; an
+// amqp.Table converts to it directly.
+type AMQPTableCarrier map[string]interface{}
+
+// Get returns the value for key if it is a string or byte slice.
+func (c AMQPTableCarrier) Get(key string) string {
+	switch v := c[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+// Set stores value under key.
+func (c AMQPTableCarrier) Set(key, value string) {
+	c[key] = value
+}
@@ -0,0 +1,235 @@
+package ctxzap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPropagator(t *testing.T) {
+	kafka := KafkaHeadersCarrier{{Key: PropagationKey, Value: []byte("stale")}, {Key: "other", Value: []byte("x")}}
+
+	tests := []struct {
+		name    string
+		carrier Carrier
+	}{
+		{name: "http headers", carrier: HeaderCarrier(http.Header{})},
+		{name: "grpc metadata", carrier: MetadataCarrier{}},
+		{name: "kafka headers", carrier: &kafka},
+		{name: "amqp table", carrier: AMQPTableCarrier{}},
+	}
+
+	ctx := WithFields(context.Background(), zap.String("request_id", "req-1"), zap.Int("attempt", 2))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Propagator
+			if err := p.Inject(ctx, tt.carrier); err != nil {
+				t.Fatalf("Inject failed: %v", err)
+			}
+
+			extracted, err := p.Extract(context.Background(), tt.carrier)
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+
+			core, observed := observer.New(zapcore.InfoLevel)
+			New(zap.New(core)).Info(extracted, "received")
+
+			fields := observed.All()[0].ContextMap()
+			if fields["request_id"] != "req-1" || fields["attempt"] != int64(2) {
+				t.Errorf("unexpected fields: %v", fields)
+			}
+		})
+	}
+
+	if len(kafka) != 2 || kafka[0].Key != "other" {
+		t.Errorf("expected the stale Kafka header to be replaced, got %v", kafka)
+	}
+}
+
+func TestPropagatorEmpty(t *testing.T) {
+	p := Propagator{Key: "x-fields"}
+	carrier := HeaderCarrier(http.Header{})
+
+	if err := p.Inject(context.Background(), carrier); err != nil {
+		t.Fatalf("Inject failed: %v", err)
+	}
+	if len(carrier) != 0 {
+		t.Errorf("expected nothing injected, got %v", carrier)
+	}
+
+	ctx := context.Background()
+	if got, err := p.Extract(ctx, carrier); err != nil || got != ctx {
+		t.Errorf("expected unchanged context, got err %v", err)
+	}
+
+	carrier.Set("x-fields", "%%")
+	if _, err := p.Extract(ctx, carrier); err == nil {
+		t.Error("expected an error for a malformed value")
+	}
+}
+
+func TestPropagatorPolicies(t *testing.T) {
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "req-1"),
+		zap.String("session_token", "secret"),
+		zap.String("tenant", "acme"),
+	)
+
+	tests := []struct {
+		name     string
+		p        Propagator
+		expected []string
+	}{
+		{
+			name:     "local-only key",
+			p:        Propagator{Policies: map[string]PropagationPolicy{"session_token": LocalOnly}},
+			expected: []string{"request_id", "tenant"},
+		},
+		{
+			name: "allowlist",
+			p: Propagator{
+				DefaultPolicy: LocalOnly,
+				Policies:      map[string]PropagationPolicy{"request_id": Propagate},
+			},
+			expected: []string{"request_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			carrier := HeaderCarrier(http.Header{})
+			if err := tt.p.Inject(ctx, carrier); err != nil {
+				t.Fatalf("Inject failed: %v", err)
+			}
+			extracted, err := tt.p.Extract(context.Background(), carrier)
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+
+			fields := FieldsFromContext(extracted)
+			if len(fields) != len(tt.expected) {
+				t.Fatalf("expected %d fields, got %v", len(tt.expected), fields)
+			}
+			for i, key := range tt.expected {
+				if fields[i].Key != key {
+					t.Errorf("field %d: expected %s, got %s", i, key, fields[i].Key)
+				}
+			}
+		})
+	}
+}
+
+func TestPropagatorMaxSize(t *testing.T) {
+	large := make([]byte, 4096)
+	for i := range large {
+		large[i] = 'x'
+	}
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "req-1"),
+		zap.String("blob", string(large)),
+		zap.String("tenant", "acme"),
+	)
+
+	p := Propagator{MaxSize: 256}
+	carrier := HeaderCarrier(http.Header{})
+	if err := p.Inject(ctx, carrier); err != nil {
+		t.Fatalf("Inject failed: %v", err)
+	}
+	if size := len(carrier.Get(PropagationKey)); size == 0 || size > p.MaxSize {
+		t.Fatalf("expected a value within %d bytes, got %d", p.MaxSize, size)
+	}
+
+	extracted, err := p.Extract(context.Background(), carrier)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	fields := FieldsFromContext(extracted)
+	if len(fields) != 2 || fields[0].Key != "request_id" || fields[1].Key != "tenant" {
+		t.Errorf("expected the oversized field to be dropped, got %v", fields)
+	}
+
+	tiny := Propagator{MaxSize: 8}
+	carrier = HeaderCarrier(http.Header{})
+	if err := tiny.Inject(ctx, carrier); err != nil {
+		t.Fatalf("Inject failed: %v", err)
+	}
+	if len(carrier) != 0 {
+		t.Errorf("expected nothing injected when no field fits, got %v", carrier)
+	}
+}
+
+func TestPropagatorExtractPolicies(t *testing.T) {
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "req-1"),
+		zap.String("user_id", "forged"),
+	)
+	carrier := HeaderCarrier(http.Header{})
+	if err := (Propagator{}).Inject(ctx, carrier); err != nil {
+		t.Fatalf("Inject failed: %v", err)
+	}
+
+	p := Propagator{
+		DefaultPolicy: LocalOnly,
+		Policies:      map[string]PropagationPolicy{"request_id": Propagate},
+	}
+	extracted, err := p.Extract(context.Background(), carrier)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	fields := FieldsFromContext(extracted)
+	if len(fields) != 1 || fields[0].Key != "request_id" {
+		t.Errorf("expected only the allowed field, got %v", fields)
+	}
+}
+
+func TestPropagatorExtractLimits(t *testing.T) {
+	fields := make([]zap.Field, 10)
+	for i := range fields {
+		fields[i] = zap.Int(fmt.Sprintf("key%d", i), i)
+	}
+	carrier := HeaderCarrier(http.Header{})
+	if err := (Propagator{}).Inject(WithFields(context.Background(), fields...), carrier); err != nil {
+		t.Fatalf("Inject failed: %v", err)
+	}
+	size := len(carrier.Get(PropagationKey))
+
+	tests := []struct {
+		name    string
+		p       Propagator
+		wantErr bool
+	}{
+		{name: "defaults", p: Propagator{}},
+		{name: "too large", p: Propagator{MaxExtractSize: size - 1}, wantErr: true},
+		{name: "too many fields", p: Propagator{MaxExtractFields: 9}, wantErr: true},
+		{name: "no limits", p: Propagator{MaxExtractSize: -1, MaxExtractFields: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			extracted, err := tt.p.Extract(ctx, carrier)
+			if tt.wantErr {
+				if err == nil || extracted != ctx {
+					t.Errorf("expected an error and ctx unchanged, got %v", err)
+				}
+				return
+			}
+			if err != nil || len(FieldsFromContext(extracted)) != len(fields) {
+				t.Errorf("expected %d fields, got %v (%v)", len(fields), FieldsFromContext(extracted), err)
+			}
+		})
+	}
+
+	large := HeaderCarrier(http.Header{})
+	large.Set(PropagationKey, strings.Repeat("A", DefaultMaxExtractSize+1))
+	if _, err := (Propagator{}).Extract(context.Background(), large); err == nil {
+		t.Error("expected values beyond DefaultMaxExtractSize to be rejected")
+	}
+}
@@ -0,0 +1,101 @@
+package ctxzap
+
+import (
+	"context"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// provenanceEnabled turns on the recording of field origins.
+var provenanceEnabled atomic.Bool
+
+// provenanceContextKey is used as a key for storing field origins in context
+type provenanceContextKey struct{}
+
+var provenanceKey = provenanceContextKey{}
+
+// FieldOrigin is where a context field was added.
+type FieldOrigin struct {
+	Key      string
+	Function string
+	File     string
+	Line     int
+}
+
+// EnableProvenance turns the recording of field origins on or off, for
+// debugging enrichment pipelines, e.g. to find out who set env=staging on a
+// production request. While enabled, every WithFields call records the first
+// caller outside ctxzap, at the cost of a stack walk per call, and
+// FieldProvenance reports the origin of each field.
+func EnableProvenance(enabled bool) {
+	provenanceEnabled.Store(enabled)
+}
+
+// FieldProvenance returns the origins of the fields of ctx added while
+// provenance was enabled, in key order. A key added several times reports
+// its last origin.
+func FieldProvenance(ctx context.Context) []FieldOrigin {
+	if ctx == nil {
+		return nil
+	}
+	origins, _ := ctx.Value(provenanceKey).(map[string]FieldOrigin)
+	result := make([]FieldOrigin, 0, len(origins))
+	for _, key := range slices.Sorted(maps.Keys(origins)) {
+		result = append(result, origins[key])
+	}
+	return result
+}
+
+// withProvenance returns ctx with the origins of keys recorded, if
+// provenance is enabled.
+func withProvenance(ctx context.Context, keys []string) context.Context {
+	if !provenanceEnabled.Load() {
+		return ctx
+	}
+
+	origin := callerOutsidePackage()
+	previous, _ := ctx.Value(provenanceKey).(map[string]FieldOrigin)
+	origins := make(map[string]FieldOrigin, len(previous)+len(keys))
+	maps.Copy(origins, previous)
+	for _, key := range keys {
+		origin.Key = key
+		origins[key] = origin
+	}
+	return context.WithValue(ctx, provenanceKey, origins)
+}
+
+// withoutProvenance returns ctx without the origins of keys.
+func withoutProvenance(ctx context.Context, keys []string) context.Context {
+	previous, _ := ctx.Value(provenanceKey).(map[string]FieldOrigin)
+	if len(previous) == 0 {
+		return ctx
+	}
+	origins := maps.Clone(previous)
+	for _, key := range keys {
+		delete(origins, key)
+	}
+	return context.WithValue(ctx, provenanceKey, origins)
+}
+
+// packagePrefix is the prefix of the function names of this package.
+const packagePrefix = "github.com/algobardo/ctxzap."
+
+// callerOutsidePackage returns the first caller outside this package. Tests
+// of this package count as outside callers.
+func callerOutsidePackage() FieldOrigin {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) || strings.HasSuffix(frame.File, "_test.go") {
+			return FieldOrigin{Function: frame.Function, File: frame.File, Line: frame.Line}
+		}
+		if !more {
+			return FieldOrigin{}
+		}
+	}
+}
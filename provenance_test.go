@@ -0,0 +1,43 @@
+package ctxzap
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestFieldProvenance(t *testing.T) {
+	ctx := WithFields(context.Background(), zap.String("before", "x"))
+
+	EnableProvenance(true)
+	defer EnableProvenance(false)
+
+	ctx = WithFields(ctx, zap.String("env", "staging"), zap.String("region", "eu"))
+	ctx = RequestID.Set(ctx, "abc")
+	ctx = WithFields(ctx, zap.String("env", "production"))
+
+	origins := FieldProvenance(ctx)
+	keys := make([]string, len(origins))
+	for i, o := range origins {
+		keys[i] = o.Key
+		if filepath.Base(o.File) != "provenance_test.go" || !strings.HasSuffix(o.Function, "TestFieldProvenance") {
+			t.Errorf("%s: expected origin in this test, got %s %s:%d", o.Key, o.Function, o.File, o.Line)
+		}
+	}
+	if strings.Join(keys, ",") != "env,region,request_id" {
+		t.Errorf("expected origins of the fields added while enabled, got %v", keys)
+	}
+	if origins[0].Line <= origins[1].Line {
+		t.Errorf("expected env to report its last origin, got line %d", origins[0].Line)
+	}
+}
+
+func TestFieldProvenanceDisabled(t *testing.T) {
+	ctx := WithFields(context.Background(), zap.String("env", "staging"))
+	if origins := FieldProvenance(ctx); len(origins) != 0 {
+		t.Errorf("expected no origins while disabled, got %v", origins)
+	}
+}
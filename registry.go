@@ -0,0 +1,101 @@
+package ctxzap
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// contextValue describes a context value mirrored as a log field.
+type contextValue struct {
+	key       any
+	fieldName string
+	convert   func(any) zap.Field
+}
+
+var (
+	// registryMu serializes writers; readers load the snapshot lock-free.
+	registryMu sync.Mutex
+	registry   atomic.Pointer[[]contextValue]
+)
+
+// RegisterContextValue declares that the value stored in a context under key
+// should be logged as a field named fieldName on every entry. This allows
+// values already carried by the context (auth principal, locale, ...) to be
+// logged without changing the code that stores them.
+//
+// convert turns the stored value into a field; if nil, zap.Any is used. The
+// key of the returned field is always replaced with fieldName. Registering the
+// same key again replaces the previous registration.
+//
+// Mirrored fields have the lowest precedence: fields added with WithFields and
+// fields passed at the call site override them.
+func RegisterContextValue(key any, fieldName string, convert func(any) zap.Field) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var current []contextValue
+	if p := registry.Load(); p != nil {
+		current = *p
+	}
+
+	updated := make([]contextValue, 0, len(current)+1)
+	for _, cv := range current {
+		if cv.key != key {
+			updated = append(updated, cv)
+		}
+	}
+	updated = append(updated, contextValue{key: key, fieldName: fieldName, convert: convert})
+	registry.Store(&updated)
+}
+
+// UnregisterContextValue removes a registration made with RegisterContextValue.
+func UnregisterContextValue(key any) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	p := registry.Load()
+	if p == nil {
+		return
+	}
+
+	updated := make([]contextValue, 0, len(*p))
+	for _, cv := range *p {
+		if cv.key != key {
+			updated = append(updated, cv)
+		}
+	}
+	registry.Store(&updated)
+}
+
+// mirroredFields returns the fields for all registered values present in ctx.
+func mirroredFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	p := registry.Load()
+	if p == nil || len(*p) == 0 {
+		return nil
+	}
+
+	var fields []zap.Field
+	for _, cv := range *p {
+		v := ctx.Value(cv.key)
+		if v == nil {
+			continue
+		}
+
+		var field zap.Field
+		if cv.convert != nil {
+			field = cv.convert(v)
+		} else {
+			field = zap.Any(cv.fieldName, v)
+		}
+		field.Key = cv.fieldName
+		fields = append(fields, field)
+	}
+	return fields
+}
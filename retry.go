@@ -0,0 +1,82 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts. Defaults to 3.
+	MaxAttempts int
+	// InitialDelay is the wait before the first retry; it doubles with each
+	// further retry. Defaults to 100ms.
+	InitialDelay time.Duration
+	// MaxDelay caps the wait between attempts. Defaults to 10s.
+	MaxDelay time.Duration
+	// LogEvery also logs every Nth failed attempt, besides the first one.
+	// Zero logs only the first failure and the final outcome.
+	LogEvery int
+	// Retryable reports whether an error is worth retrying. If nil, every
+	// error is.
+	Retryable func(error) bool
+}
+
+// Retry calls fn until it succeeds, the attempts are exhausted, fn returns a
+// non-retryable error or ctx is done, and returns the last error, joined
+// with ctx.Err() if ctx is done. Instead of
+// one line per attempt, it logs the first failure and every LogEvery-th one
+// at Warn level, then the final outcome with the number of attempts: at Info
+// level if fn eventually succeeded, at Error level otherwise. A first
+// attempt that succeeds logs nothing.
+func Retry(ctx context.Context, logger *Logger, policy RetryPolicy, fn func(context.Context) error) error {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.InitialDelay <= 0 {
+		policy.InitialDelay = 100 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 10 * time.Second
+	}
+
+	delay := policy.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				logger.Info(ctx, "succeeded after retries", zap.Int("attempts", attempt))
+			}
+			return nil
+		}
+
+		if attempt >= policy.MaxAttempts || (policy.Retryable != nil && !policy.Retryable(err)) {
+			logger.Error(ctx, "giving up after retries", zap.Int("attempts", attempt), zap.Error(err))
+			return err
+		}
+		if attempt == 1 || (policy.LogEvery > 0 && attempt%policy.LogEvery == 0) {
+			logger.Warn(ctx, "attempt failed, retrying",
+				zap.Int("attempt", attempt),
+				zap.Duration("retry_in", delay),
+				zap.Error(err),
+			)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Error(ctx, "giving up after retries", zap.Int("attempts", attempt), zap.Error(err))
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
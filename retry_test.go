@@ -0,0 +1,127 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		failures   int
+		failWith   error
+		wantErr    error
+		wantCalls  int
+		wantLevels []zapcore.Level
+	}{
+		{
+			name:      "first attempt succeeds",
+			policy:    RetryPolicy{MaxAttempts: 5},
+			wantCalls: 1,
+		},
+		{
+			name:       "succeeds after retries",
+			policy:     RetryPolicy{MaxAttempts: 10},
+			failures:   6,
+			failWith:   errTransient,
+			wantCalls:  7,
+			wantLevels: []zapcore.Level{zapcore.WarnLevel, zapcore.InfoLevel},
+		},
+		{
+			name:       "logs every Nth failure",
+			policy:     RetryPolicy{MaxAttempts: 10, LogEvery: 3},
+			failures:   7,
+			failWith:   errTransient,
+			wantCalls:  8,
+			wantLevels: []zapcore.Level{zapcore.WarnLevel, zapcore.WarnLevel, zapcore.WarnLevel, zapcore.InfoLevel},
+		},
+		{
+			name:       "attempts exhausted",
+			policy:     RetryPolicy{MaxAttempts: 4},
+			failures:   10,
+			failWith:   errTransient,
+			wantErr:    errTransient,
+			wantCalls:  4,
+			wantLevels: []zapcore.Level{zapcore.WarnLevel, zapcore.ErrorLevel},
+		},
+		{
+			name:       "non-retryable error",
+			policy:     RetryPolicy{MaxAttempts: 4, Retryable: func(err error) bool { return err != errFatal }},
+			failures:   10,
+			failWith:   errFatal,
+			wantErr:    errFatal,
+			wantCalls:  1,
+			wantLevels: []zapcore.Level{zapcore.ErrorLevel},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, observed := observer.New(zapcore.DebugLevel)
+			logger := New(zap.New(core))
+			tt.policy.InitialDelay = time.Microsecond
+
+			calls := 0
+			err := Retry(context.Background(), logger, tt.policy, func(context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.failWith
+				}
+				return nil
+			})
+
+			if err != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+
+			entries := observed.All()
+			if len(entries) != len(tt.wantLevels) {
+				t.Fatalf("expected %d entries, got %d", len(tt.wantLevels), len(entries))
+			}
+			for i, level := range tt.wantLevels {
+				if entries[i].Level != level {
+					t.Errorf("entry %d: expected level %v, got %v", i, level, entries[i].Level)
+				}
+			}
+			if n := len(entries); n > 0 && entries[n-1].Level != zapcore.WarnLevel {
+				if got := entries[n-1].ContextMap()["attempts"]; got != int64(calls) {
+					t.Errorf("expected attempts=%d on the outcome, got %v", calls, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryContextDone(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errUnavailable := errors.New("unavailable")
+	err := Retry(ctx, New(zap.New(core)), RetryPolicy{MaxAttempts: 5, InitialDelay: time.Hour}, func(context.Context) error {
+		cancel()
+		return errUnavailable
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if !errors.Is(err, errUnavailable) {
+		t.Errorf("expected the last error to be kept, got %v", err)
+	}
+	if entries := observed.All(); len(entries) != 2 || entries[1].Level != zapcore.ErrorLevel {
+		t.Errorf("expected a failure and a final error entry, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,158 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/algobardo/ctxzap/internal/fieldcodec"
+)
+
+// SelfTestKey is the key of the probe ID field on the entries written by
+// SelfTest, so that probes can be found in, or filtered out of, the sinks.
+const SelfTestKey = "selftest_probe"
+
+// SelfTestCheck is the outcome of one step of SelfTest.
+type SelfTestCheck struct {
+	Name    string
+	Err     error
+	Elapsed time.Duration
+}
+
+// SelfTestReport is the result of SelfTest.
+type SelfTestReport struct {
+	// ProbeID is the value of the SelfTestKey field on the probe entries.
+	ProbeID string
+	Checks  []SelfTestCheck
+}
+
+// OK reports whether every check passed.
+func (r *SelfTestReport) OK() bool {
+	return r.Err() == nil
+}
+
+// Err returns the errors of the failed checks, joined, or nil.
+func (r *SelfTestReport) Err() error {
+	var errs []error
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, c.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler, so that the report
+// can itself be logged.
+func (r *SelfTestReport) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("probe_id", r.ProbeID)
+	enc.AddBool("ok", r.OK())
+	return enc.AddArray("checks", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, c := range r.Checks {
+			err := arr.AppendObject(zapcore.ObjectMarshalerFunc(func(obj zapcore.ObjectEncoder) error {
+				obj.AddString("name", c.Name)
+				obj.AddDuration("elapsed", c.Elapsed)
+				if c.Err != nil {
+					obj.AddString("error", c.Err.Error())
+				}
+				return nil
+			}))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// SelfTest validates the logging pipeline of logger, at startup or from an
+// admin endpoint. It writes a probe entry, tagged with a SelfTestKey field and
+// the fields of ctx, at every enabled level from Debug to Error, so that each
+// sink of a tee receives at least one; syncs the logger, which waits for
+// acknowledgments on sinks that support them (see ctxzapdelivery); and checks
+// that the fields of ctx survive a MarshalFields round trip unchanged. Write
+// and sync errors are returned in the report rather than sent to the
+// logger's ErrorOutput.
+func SelfTest(ctx context.Context, logger *Logger) *SelfTestReport {
+	report := &SelfTestReport{ProbeID: (&uuidV7Generator{}).next(time.Now())}
+	check := func(name string, fn func() error) {
+		start := time.Now()
+		err := fn()
+		report.Checks = append(report.Checks, SelfTestCheck{Name: name, Err: err, Elapsed: time.Since(start)})
+	}
+
+	core := logger.Core()
+	fields := append(logger.contextFields(ctx), zap.String(SelfTestKey, report.ProbeID))
+	for level := zapcore.DebugLevel; level <= zapcore.ErrorLevel; level++ {
+		if !core.Enabled(level) {
+			continue
+		}
+		check("write "+level.String(), func() error {
+			entry := zapcore.Entry{Level: level, Time: time.Now(), LoggerName: logger.Name(), Message: "logging self-test probe"}
+			ce := core.Check(entry, nil)
+			if ce == nil {
+				return errors.New("probe dropped by the core")
+			}
+			var errs writeErrors
+			ce.ErrorOutput = &errs
+			ce.Write(fields...)
+			return errors.Join(errs...)
+		})
+	}
+	check("sync", core.Sync)
+	check("roundtrip", func() error {
+		return checkRoundTrip(fields)
+	})
+	return report
+}
+
+// writeErrors collects the write errors that a CheckedEntry reports to its
+// ErrorOutput, as "<time> write error: <err>" lines.
+type writeErrors []error
+
+func (w *writeErrors) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	if _, after, ok := strings.Cut(msg, " write error: "); ok {
+		msg = after
+	}
+	*w = append(*w, errors.New(msg))
+	return len(p), nil
+}
+
+func (w *writeErrors) Sync() error {
+	return nil
+}
+
+// checkRoundTrip serializes fields with the codec behind MarshalFields and
+// verifies that the restored fields encode identically.
+func checkRoundTrip(fields []zap.Field) error {
+	data, err := fieldcodec.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	restored, err := fieldcodec.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+	want, err := enc.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return err
+	}
+	defer want.Free()
+	got, err := enc.EncodeEntry(zapcore.Entry{}, restored)
+	if err != nil {
+		return err
+	}
+	defer got.Free()
+	if want.String() != got.String() {
+		return fmt.Errorf("restored fields encode as %s, want %s", got.String(), want.String())
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type failingSyncer struct{}
+
+func (failingSyncer) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+func (failingSyncer) Sync() error               { return errors.New("sync failed") }
+
+func TestSelfTest(t *testing.T) {
+	infoCore, infoLogs := observer.New(zapcore.InfoLevel)
+	errorCore, errorLogs := observer.New(zapcore.ErrorLevel)
+	logger := New(zap.New(zapcore.NewTee(infoCore, errorCore)))
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "abc"),
+		zap.Duration("timeout", time.Second),
+		zap.Error(errors.New("boom")),
+	)
+
+	report := SelfTest(ctx, logger)
+	if !report.OK() {
+		t.Fatalf("expected a passing report, got %v", report.Err())
+	}
+	var names []string
+	for _, c := range report.Checks {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "write info,write warn,write error,sync,roundtrip" {
+		t.Errorf("unexpected checks: %s", got)
+	}
+
+	if infoLogs.Len() != 3 || errorLogs.Len() != 1 {
+		t.Fatalf("expected probes in every sink, got %d and %d", infoLogs.Len(), errorLogs.Len())
+	}
+	probe := errorLogs.All()[0].ContextMap()
+	if probe[SelfTestKey] != report.ProbeID || probe["request_id"] != "abc" {
+		t.Errorf("unexpected probe fields: %v", probe)
+	}
+}
+
+func TestSelfTestReportsFailures(t *testing.T) {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	logger := New(zap.New(zapcore.NewCore(enc, failingSyncer{}, zapcore.WarnLevel)))
+
+	report := SelfTest(context.Background(), logger)
+	if report.OK() {
+		t.Fatal("expected a failing report")
+	}
+	err := report.Err().Error()
+	for _, want := range []string{"write warn: disk full", "write error: disk full", "sync: sync failed"} {
+		if !strings.Contains(err, want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	zap.New(core).Info("report", zap.Object("selftest", report))
+	if observed.All()[0].ContextMap()["selftest"].(map[string]any)["ok"] != false {
+		t.Errorf("unexpected logged report: %v", observed.All()[0].ContextMap())
+	}
+}
@@ -0,0 +1,28 @@
+package ctxzap
+
+import (
+	"context"
+
+	"github.com/algobardo/ctxzap/internal/fieldcodec"
+)
+
+// MarshalFields serializes the fields of ctx to JSON, as the building block
+// for propagating them over custom transports such as message queues or
+// in-house RPC. Every zap field type is supported: scalar values, durations,
+// times and errors keep their types, and objects, arrays and reflected
+// values are carried in their JSON form, so restored fields encode the same
+// way as the originals. It returns an error if a reflected value cannot be
+// marshaled.
+func MarshalFields(ctx context.Context) ([]byte, error) {
+	return fieldcodec.Marshal(entryFields(ctx))
+}
+
+// UnmarshalFields returns ctx with the fields serialized by MarshalFields
+// added to it. Errors are restored as errors carrying the original message.
+func UnmarshalFields(ctx context.Context, data []byte) (context.Context, error) {
+	fields, err := fieldcodec.Unmarshal(data)
+	if err != nil {
+		return ctx, err
+	}
+	return WithFields(ctx, fields...), nil
+}
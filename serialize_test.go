@@ -0,0 +1,70 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMarshalFields(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ctx := WithFields(context.Background(),
+		zap.String("request_id", "req-1"),
+		zap.Bool("retry", true),
+		zap.Uint64("offset", math.MaxUint64),
+		zap.Float64("ratio", math.Inf(1)),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.Time("deadline", ts),
+		zap.Error(errors.New("boom")),
+		zap.Strings("tags", []string{"a", "b"}),
+	)
+
+	data, err := MarshalFields(ctx)
+	if err != nil {
+		t.Fatalf("MarshalFields failed: %v", err)
+	}
+
+	restored, err := UnmarshalFields(context.Background(), data)
+	if err != nil {
+		t.Fatalf("UnmarshalFields failed: %v", err)
+	}
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	logger.Info(ctx, "original")
+	logger.Info(restored, "restored")
+
+	entries := observed.All()
+	want, got := entries[0].ContextMap(), entries[1].ContextMap()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d fields, got %d: %v", len(want), len(got), got)
+	}
+	for key := range want {
+		if key == "tags" {
+			continue
+		}
+		if got[key] != want[key] {
+			t.Errorf("field %s: expected %v (%T), got %v (%T)", key, want[key], want[key], got[key], got[key])
+		}
+	}
+	if tags, ok := got["tags"].([]interface{}); !ok || len(tags) != 2 || tags[0] != "a" {
+		t.Errorf("unexpected tags: %v", got["tags"])
+	}
+}
+
+func TestMarshalFieldsErrors(t *testing.T) {
+	ctx := WithFields(context.Background(), zap.Reflect("fn", func() {}))
+	if _, err := MarshalFields(ctx); err == nil {
+		t.Error("expected an error for an unmarshalable value")
+	}
+
+	if _, err := UnmarshalFields(context.Background(), []byte("{")); err == nil {
+		t.Error("expected an error for malformed data")
+	}
+}
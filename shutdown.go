@@ -0,0 +1,118 @@
+package ctxzap
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithShutdownReport makes the logger count the entries it writes, so that
+// Close can log a summary of what the logging subsystem did over the process
+// lifetime: entries per level, failed writes, entries whose fields were
+// trimmed by a FieldLimit, and uptime. The summary is written to sink, which
+// should be a destination that is still reliable at shutdown, such as
+// stderr; if sink is nil, it is written to the logger's own core. Loggers
+// derived from the logger share its counts.
+func WithShutdownReport(sink zapcore.Core) Option {
+	return func(l *Logger) {
+		report := &shutdownReport{start: time.Now(), sink: sink}
+		l.Logger = l.Logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			if report.sink == nil {
+				report.sink = core
+			}
+			return decorate(core, reportDecorator{report: report})
+		}))
+		l.report = report
+	}
+}
+
+// Close logs the shutdown summary of a logger created with
+// WithShutdownReport, once, then flushes the logger. For a logger created
+// with NewDevelopment, it then closes the file the logger writes to, which
+// the logger and the loggers derived from it must not use afterwards.
+func (l *Logger) Close() error {
+	if l.report != nil {
+		l.report.write()
+	}
+	err := l.Sync()
+	if l.closeOutputs != nil {
+		l.closeOutputs()
+	}
+	return err
+}
+
+type shutdownReport struct {
+	start time.Time
+	sink  zapcore.Core
+	once  sync.Once
+
+	mu          sync.Mutex
+	levels      map[zapcore.Level]int64
+	writeErrors int64
+	truncated   int64
+}
+
+type reportDecorator struct {
+	report *shutdownReport
+}
+
+func (d reportDecorator) with(fields []zap.Field) (decoration, []zap.Field) {
+	return d, fields
+}
+
+func (d reportDecorator) write(core zapcore.Core, entry zapcore.Entry, fields []zap.Field) error {
+	err := core.Write(entry, fields)
+
+	truncated := false
+	for _, f := range fields {
+		if f.Key == TrimmedFieldsKey {
+			truncated = true
+			break
+		}
+	}
+
+	r := d.report
+	r.mu.Lock()
+	if r.levels == nil {
+		r.levels = make(map[zapcore.Level]int64)
+	}
+	r.levels[entry.Level]++
+	if err != nil {
+		r.writeErrors++
+	}
+	if truncated {
+		r.truncated++
+	}
+	r.mu.Unlock()
+	return err
+}
+
+func (r *shutdownReport) write() {
+	r.once.Do(func() {
+		r.mu.Lock()
+		var total int64
+		byLevel := make([]zap.Field, 0, len(r.levels))
+		for level := zapcore.DebugLevel; level <= zapcore.FatalLevel; level++ {
+			if n := r.levels[level]; n > 0 {
+				total += n
+				byLevel = append(byLevel, zap.Int64(level.String(), n))
+			}
+		}
+		fields := []zap.Field{
+			zap.Duration("uptime", time.Since(r.start)),
+			zap.Int64("entries", total),
+			zap.Dict("entries_by_level", byLevel...),
+			zap.Int64("write_errors", r.writeErrors),
+			zap.Int64("truncated", r.truncated),
+		}
+		r.mu.Unlock()
+
+		entry := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "logging summary"}
+		if ce := r.sink.Check(entry, nil); ce != nil {
+			ce.Write(fields...)
+		}
+		_ = r.sink.Sync()
+	})
+}
@@ -0,0 +1,65 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestShutdownReport(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	sink, reported := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithFieldLimit(FieldLimit{MaxFields: 2}), WithShutdownReport(sink))
+	ctx := context.Background()
+
+	logger.Info(ctx, "one")
+	logger.With(zap.String("service", "api")).Info(ctx, "two")
+	logger.Warn(ctx, "three", zap.Int("a", 1), zap.Int("b", 2), zap.Int("c", 3))
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	_ = logger.Close()
+
+	if observed.Len() != 3 {
+		t.Errorf("expected entries to be written unchanged, got %d", observed.Len())
+	}
+	if reported.Len() != 1 {
+		t.Fatalf("expected 1 summary, got %d", reported.Len())
+	}
+	summary := reported.All()[0]
+	fields := summary.ContextMap()
+	if summary.Message != "logging summary" || fields["entries"] != int64(3) || fields["truncated"] != int64(1) {
+		t.Errorf("unexpected summary: %v %v", summary.Message, fields)
+	}
+	byLevel, _ := fields["entries_by_level"].(map[string]any)
+	if byLevel["info"] != int64(2) || byLevel["warn"] != int64(1) {
+		t.Errorf("unexpected per-level counts: %v", byLevel)
+	}
+	if uptime, _ := fields["uptime"].(time.Duration); uptime <= 0 {
+		t.Errorf("expected a positive uptime, got %v", fields["uptime"])
+	}
+}
+
+func TestShutdownReportToOwnCore(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithShutdownReport(nil))
+
+	logger.Info(context.Background(), "one")
+	_ = logger.Close()
+
+	if observed.Len() != 2 || observed.All()[1].Message != "logging summary" {
+		t.Errorf("expected the summary on the logger's core, got %v", observed.All())
+	}
+}
+
+func TestCloseWithoutReport(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	if err := New(zap.New(core)).Close(); err != nil || observed.Len() != 0 {
+		t.Errorf("expected Close to only sync, got %v and %d entries", err, observed.Len())
+	}
+}
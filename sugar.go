@@ -0,0 +1,184 @@
+package ctxzap
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// IgnoredKey is the key of the field listing the key-value arguments of a
+// SugaredLogger that could not be turned into fields: non-string keys and a
+// final key without a value.
+const IgnoredKey = "ignored"
+
+// SugaredLogger is the context-aware counterpart of zap.SugaredLogger, with
+// printf-style and loosely typed key-value methods. Entries include context
+// fields exactly as with Logger, so code falling back to the sugared API
+// does not lose them:
+//
+//	sugar := logger.CtxSugar()
+//	sugar.Infof(ctx, "processed %d items", n)
+//	sugar.Infow(ctx, "processed items", "count", n, "source", src)
+type SugaredLogger struct {
+	base *Logger
+}
+
+// CtxSugar returns a SugaredLogger sharing the logger's core and settings.
+// Sugar, promoted from the embedded zap.Logger, still returns a plain
+// zap.SugaredLogger, which does not read context fields.
+func (l *Logger) CtxSugar() *SugaredLogger {
+	return &SugaredLogger{base: l}
+}
+
+// Desugar returns the Logger behind s.
+func (s *SugaredLogger) Desugar() *Logger {
+	return s.base
+}
+
+// With creates a child SugaredLogger adding the key-value pairs, which are
+// handled as in the w methods, to every entry.
+func (s *SugaredLogger) With(keysAndValues ...any) *SugaredLogger {
+	return &SugaredLogger{base: s.base.With(sweetenFields(keysAndValues)...)}
+}
+
+// Debugf formats a message with fmt.Sprintf and logs it at DebugLevel.
+func (s *SugaredLogger) Debugf(ctx context.Context, template string, args ...any) {
+	if !s.enabled(ctx, zapcore.DebugLevel) {
+		return
+	}
+	s.base.Logger.Debug(fmt.Sprintf(template, args...), s.base.fields(ctx, zapcore.DebugLevel, nil, nil)...)
+}
+
+// Debugw logs a message at DebugLevel with the key-value pairs as fields.
+func (s *SugaredLogger) Debugw(ctx context.Context, msg string, keysAndValues ...any) {
+	if !s.enabled(ctx, zapcore.DebugLevel) {
+		return
+	}
+	s.base.Logger.Debug(msg, s.base.fields(ctx, zapcore.DebugLevel, sweetenFields(keysAndValues), nil)...)
+}
+
+// Infof formats a message with fmt.Sprintf and logs it at InfoLevel.
+func (s *SugaredLogger) Infof(ctx context.Context, template string, args ...any) {
+	if !s.enabled(ctx, zapcore.InfoLevel) {
+		return
+	}
+	s.base.Logger.Info(fmt.Sprintf(template, args...), s.base.fields(ctx, zapcore.InfoLevel, nil, nil)...)
+}
+
+// Infow logs a message at InfoLevel with the key-value pairs as fields.
+func (s *SugaredLogger) Infow(ctx context.Context, msg string, keysAndValues ...any) {
+	if !s.enabled(ctx, zapcore.InfoLevel) {
+		return
+	}
+	s.base.Logger.Info(msg, s.base.fields(ctx, zapcore.InfoLevel, sweetenFields(keysAndValues), nil)...)
+}
+
+// Warnf formats a message with fmt.Sprintf and logs it at WarnLevel.
+func (s *SugaredLogger) Warnf(ctx context.Context, template string, args ...any) {
+	if !s.enabled(ctx, zapcore.WarnLevel) {
+		return
+	}
+	s.base.Logger.Warn(fmt.Sprintf(template, args...), s.base.fields(ctx, zapcore.WarnLevel, nil, nil)...)
+}
+
+// Warnw logs a message at WarnLevel with the key-value pairs as fields.
+func (s *SugaredLogger) Warnw(ctx context.Context, msg string, keysAndValues ...any) {
+	if !s.enabled(ctx, zapcore.WarnLevel) {
+		return
+	}
+	s.base.Logger.Warn(msg, s.base.fields(ctx, zapcore.WarnLevel, sweetenFields(keysAndValues), nil)...)
+}
+
+// Errorf formats a message with fmt.Sprintf and logs it at ErrorLevel.
+func (s *SugaredLogger) Errorf(ctx context.Context, template string, args ...any) {
+	if !s.enabled(ctx, zapcore.ErrorLevel) {
+		return
+	}
+	s.base.Logger.Error(fmt.Sprintf(template, args...), s.base.fields(ctx, zapcore.ErrorLevel, nil, nil)...)
+}
+
+// Errorw logs a message at ErrorLevel with the key-value pairs as fields.
+func (s *SugaredLogger) Errorw(ctx context.Context, msg string, keysAndValues ...any) {
+	if !s.enabled(ctx, zapcore.ErrorLevel) {
+		return
+	}
+	s.base.Logger.Error(msg, s.base.fields(ctx, zapcore.ErrorLevel, sweetenFields(keysAndValues), nil)...)
+}
+
+// DPanicf formats a message with fmt.Sprintf and logs it at DPanicLevel.
+func (s *SugaredLogger) DPanicf(ctx context.Context, template string, args ...any) {
+	s.base.Logger.DPanic(fmt.Sprintf(template, args...), s.base.fields(ctx, zapcore.DPanicLevel, nil, nil)...)
+}
+
+// DPanicw logs a message at DPanicLevel with the key-value pairs as fields.
+func (s *SugaredLogger) DPanicw(ctx context.Context, msg string, keysAndValues ...any) {
+	s.base.Logger.DPanic(msg, s.base.fields(ctx, zapcore.DPanicLevel, sweetenFields(keysAndValues), nil)...)
+}
+
+// Panicf formats a message with fmt.Sprintf and logs it at PanicLevel,
+// then panics.
+func (s *SugaredLogger) Panicf(ctx context.Context, template string, args ...any) {
+	s.base.Logger.Panic(fmt.Sprintf(template, args...), s.base.fields(ctx, zapcore.PanicLevel, nil, nil)...)
+}
+
+// Panicw logs a message at PanicLevel with the key-value pairs as fields,
+// then panics.
+func (s *SugaredLogger) Panicw(ctx context.Context, msg string, keysAndValues ...any) {
+	s.base.Logger.Panic(msg, s.base.fields(ctx, zapcore.PanicLevel, sweetenFields(keysAndValues), nil)...)
+}
+
+// Fatalf formats a message with fmt.Sprintf and logs it at FatalLevel,
+// then calls os.Exit(1).
+func (s *SugaredLogger) Fatalf(ctx context.Context, template string, args ...any) {
+	s.base.Logger.Fatal(fmt.Sprintf(template, args...), s.base.fields(ctx, zapcore.FatalLevel, nil, nil)...)
+}
+
+// Fatalw logs a message at FatalLevel with the key-value pairs as fields,
+// then calls os.Exit(1).
+func (s *SugaredLogger) Fatalw(ctx context.Context, msg string, keysAndValues ...any) {
+	s.base.Logger.Fatal(msg, s.base.fields(ctx, zapcore.FatalLevel, sweetenFields(keysAndValues), nil)...)
+}
+
+// enabled reports whether an entry at level is logged, consuming the budget
+// in ctx if any, so that messages are not formatted and key-value pairs not
+// turned into fields for entries that are dropped. DPanic, Panic and Fatal
+// do not use it, as they panic or exit even when their level is disabled.
+func (s *SugaredLogger) enabled(ctx context.Context, level zapcore.Level) bool {
+	return s.base.Core().Enabled(level) && s.base.withinBudget(ctx, level)
+}
+
+// sweetenFields turns alternating keys and values into fields. zap.Field
+// arguments are used as is, without a key.
+func sweetenFields(keysAndValues []any) []zap.Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+
+	fields := make([]zap.Field, 0, len(keysAndValues)/2)
+	var ignored []any
+	for i := 0; i < len(keysAndValues); {
+		if f, ok := keysAndValues[i].(zap.Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+		if i == len(keysAndValues)-1 {
+			ignored = append(ignored, keysAndValues[i])
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			ignored = append(ignored, keysAndValues[i], keysAndValues[i+1])
+		} else {
+			fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+		}
+		i += 2
+	}
+
+	if len(ignored) > 0 {
+		fields = append(fields, zap.Any(IgnoredKey, ignored))
+	}
+	return fields
+}
@@ -0,0 +1,109 @@
+package ctxzap
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSugaredLogger(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	sugar := New(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))).CtxSugar().With("service", "api")
+	ctx := WithFields(context.Background(), zap.String("request_id", "123"))
+
+	sugar.Infof(ctx, "processed %d items", 3)
+	sugar.Warnw(ctx, "slow request", "elapsed_ms", 250, zap.Bool("retried", true))
+	sugar.Debugw(ctx, "disabled", "key", "value")
+
+	entries := observed.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+
+	if entries[0].Message != "processed 3 items" || entries[0].Level != zapcore.InfoLevel {
+		t.Errorf("unexpected entry: %v %v", entries[0].Level, entries[0].Message)
+	}
+	if file := filepath.Base(entries[0].Caller.File); file != "sugar_test.go" {
+		t.Errorf("expected caller in sugar_test.go, got %s", file)
+	}
+
+	fields := entries[1].ContextMap()
+	want := map[string]any{"service": "api", "request_id": "123", "elapsed_ms": int64(250), "retried": true}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, fields[key])
+		}
+	}
+}
+
+func TestSweetenFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		keysAndValues []any
+		wantKeys      []string
+		wantIgnored   []any
+	}{
+		{"empty", nil, nil, nil},
+		{"pairs", []any{"a", 1, "b", "x"}, []string{"a", "b"}, nil},
+		{"field", []any{zap.Int("a", 1), "b", 2}, []string{"a", "b"}, nil},
+		{"dangling key", []any{"a", 1, "b"}, []string{"a", IgnoredKey}, []any{"b"}},
+		{"non-string key", []any{42, "x", "a", 1}, []string{"a", IgnoredKey}, []any{42, "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := sweetenFields(tt.keysAndValues)
+			if len(fields) != len(tt.wantKeys) {
+				t.Fatalf("expected keys %v, got %v", tt.wantKeys, fields)
+			}
+			for i, key := range tt.wantKeys {
+				if fields[i].Key != key {
+					t.Errorf("field %d: expected key %s, got %s", i, key, fields[i].Key)
+				}
+			}
+			if tt.wantIgnored != nil {
+				enc := zapcore.NewMapObjectEncoder()
+				fields[len(fields)-1].AddTo(enc)
+				ignored, _ := enc.Fields[IgnoredKey].([]any)
+				if len(ignored) != len(tt.wantIgnored) {
+					t.Errorf("expected ignored %v, got %v", tt.wantIgnored, enc.Fields[IgnoredKey])
+				}
+			}
+		})
+	}
+}
+
+// formatCounter counts how many times it is formatted.
+type formatCounter struct{ n *int }
+
+func (c formatCounter) String() string {
+	*c.n++
+	return "formatted"
+}
+
+func TestSugaredLoggerSkipsDisabledLevels(t *testing.T) {
+	core, observed := observer.New(zapcore.WarnLevel)
+	sugar := New(zap.New(core)).CtxSugar()
+	ctx := context.Background()
+
+	var n int
+	sugar.Debugf(ctx, "%v", formatCounter{&n})
+	sugar.Infof(ctx, "%v", formatCounter{&n})
+	if n != 0 || observed.Len() != 0 {
+		t.Errorf("expected disabled entries not to be formatted, formatted %d times", n)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		sugar.Infow(ctx, "disabled", "count", 1, "source", "test")
+	}); allocs > 1 {
+		t.Errorf("expected disabled entries not to be sweetened, got %v allocations", allocs)
+	}
+
+	sugar.Warnf(ctx, "%v", formatCounter{&n})
+	if n != 1 || observed.Len() != 1 {
+		t.Errorf("expected the enabled entry to be formatted once, formatted %d times", n)
+	}
+}
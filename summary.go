@@ -0,0 +1,149 @@
+package ctxzap
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SummaryLimits bounds the preview produced by Summary.
+type SummaryLimits struct {
+	// MaxItems is the maximum number of slice elements or map entries
+	// included in the preview.
+	MaxItems int
+	// MaxStringLen is the maximum number of bytes kept from any string in
+	// the preview, including strings nested in slices and maps.
+	MaxStringLen int
+}
+
+// DefaultSummaryLimits are used when Summary is given zero limits.
+var DefaultSummaryLimits = SummaryLimits{
+	MaxItems:     5,
+	MaxStringLen: 64,
+}
+
+// Summary returns a field describing v instead of logging it in full. The
+// field contains the value's type, its length for strings, slices, arrays and
+// maps, its number of fields for structs, and a preview bounded by limits:
+// values other than numbers and bools are previewed as truncated text. Use it in place of zap.Any for
+// payloads that may be arbitrarily large.
+func Summary(key string, v any, limits SummaryLimits) zap.Field {
+	if limits.MaxItems <= 0 {
+		limits.MaxItems = DefaultSummaryLimits.MaxItems
+	}
+	if limits.MaxStringLen <= 0 {
+		limits.MaxStringLen = DefaultSummaryLimits.MaxStringLen
+	}
+	return zap.Object(key, summaryMarshaler{value: v, limits: limits})
+}
+
+type summaryMarshaler struct {
+	value  any
+	limits SummaryLimits
+}
+
+func (s summaryMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	v := indirect(reflect.ValueOf(s.value))
+	if !v.IsValid() {
+		enc.AddString("type", "nil")
+		return nil
+	}
+
+	enc.AddString("type", reflect.TypeOf(s.value).String())
+
+	switch v.Kind() {
+	case reflect.String:
+		enc.AddInt("len", v.Len())
+		enc.AddString("preview", truncateString(v.String(), s.limits.MaxStringLen))
+	case reflect.Slice, reflect.Array:
+		enc.AddInt("len", v.Len())
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are usually encoded payloads; only their size matters.
+			return nil
+		}
+		return enc.AddArray("preview", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			n := min(v.Len(), s.limits.MaxItems)
+			for i := 0; i < n; i++ {
+				if err := arr.AppendReflected(s.preview(v.Index(i))); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	case reflect.Map:
+		enc.AddInt("len", v.Len())
+		return enc.AddObject("preview", zapcore.ObjectMarshalerFunc(func(obj zapcore.ObjectEncoder) error {
+			keys := v.MapKeys()
+			names := make([]string, len(keys))
+			for i, k := range keys {
+				names[i] = keyString(k)
+			}
+			sort.Sort(keysByName{keys: keys, names: names})
+			n := min(len(keys), s.limits.MaxItems)
+			for i := 0; i < n; i++ {
+				if err := obj.AddReflected(names[i], s.preview(v.MapIndex(keys[i]))); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	case reflect.Struct:
+		enc.AddInt("fields", v.NumField())
+		enc.AddString("preview", truncateString(fmt.Sprintf("%+v", interfaceOf(v)), s.limits.MaxStringLen))
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return enc.AddReflected("preview", interfaceOf(v))
+	default:
+		// Other values (channels, functions, ...) may print arbitrarily long.
+		enc.AddString("preview", truncateString(fmt.Sprintf("%v", interfaceOf(v)), s.limits.MaxStringLen))
+	}
+	return nil
+}
+
+// preview returns a bounded representation of a nested value: strings are
+// truncated and containers are replaced by their type and length.
+func (s summaryMarshaler) preview(v reflect.Value) any {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return truncateString(v.String(), s.limits.MaxStringLen)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return map[string]any{"type": v.Type().String(), "len": v.Len()}
+	case reflect.Struct:
+		return v.Type().String()
+	default:
+		return interfaceOf(v)
+	}
+}
+
+// truncateString shortens s to at most n bytes without splitting a rune,
+// appending an ellipsis when anything was removed.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
+
+type keysByName struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (k keysByName) Len() int           { return len(k.keys) }
+func (k keysByName) Less(i, j int) bool { return k.names[i] < k.names[j] }
+func (k keysByName) Swap(i, j int) {
+	k.keys[i], k.keys[j] = k.keys[j], k.keys[i]
+	k.names[i], k.names[j] = k.names[j], k.names[i]
+}
@@ -0,0 +1,124 @@
+package ctxzap
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		limits   SummaryLimits
+		expected map[string]interface{}
+	}{
+		{
+			name:   "long string is truncated",
+			value:  strings.Repeat("x", 100),
+			limits: SummaryLimits{MaxStringLen: 4},
+			expected: map[string]interface{}{
+				"type":    "string",
+				"len":     100,
+				"preview": "xxxx…",
+			},
+		},
+		{
+			name:   "nil value",
+			value:  nil,
+			limits: SummaryLimits{},
+			expected: map[string]interface{}{
+				"type": "nil",
+			},
+		},
+		{
+			name:   "byte slice omits preview",
+			value:  make([]byte, 2048),
+			limits: SummaryLimits{},
+			expected: map[string]interface{}{
+				"type": "[]uint8",
+				"len":  2048,
+			},
+		},
+		{
+			name:   "scalar",
+			value:  42,
+			limits: SummaryLimits{},
+			expected: map[string]interface{}{
+				"type":    "int",
+				"preview": 42,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			Summary("payload", tt.value, tt.limits).AddTo(enc)
+
+			got, ok := enc.Fields["payload"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected payload object, got %T", enc.Fields["payload"])
+			}
+
+			if len(got) != len(tt.expected) {
+				t.Errorf("expected %d keys, got %d: %v", len(tt.expected), len(got), got)
+			}
+			for k, v := range tt.expected {
+				if got[k] != v {
+					t.Errorf("key %q: expected %v, got %v", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+func TestSummaryContainers(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	Summary("items", []string{"a", "b", "c", "d"}, SummaryLimits{MaxItems: 2}).AddTo(enc)
+
+	items := enc.Fields["items"].(map[string]interface{})
+	if items["len"] != 4 {
+		t.Errorf("expected len 4, got %v", items["len"])
+	}
+	preview := items["preview"].([]interface{})
+	if len(preview) != 2 || preview[0] != "a" || preview[1] != "b" {
+		t.Errorf("expected preview [a b], got %v", preview)
+	}
+
+	enc = zapcore.NewMapObjectEncoder()
+	Summary("labels", map[string]int{"z": 1, "a": 2, "m": 3}, SummaryLimits{MaxItems: 2}).AddTo(enc)
+
+	labels := enc.Fields["labels"].(map[string]interface{})
+	mapPreview := labels["preview"].(map[string]interface{})
+	if len(mapPreview) != 2 || mapPreview["a"] != 2 || mapPreview["m"] != 3 {
+		t.Errorf("expected first two keys in order, got %v", mapPreview)
+	}
+}
+
+type summaryPayload struct {
+	ID    string
+	Body  string
+	Items []int
+}
+
+func TestSummaryLargeStruct(t *testing.T) {
+	payload := &summaryPayload{ID: "p1", Body: strings.Repeat("x", 1<<20), Items: make([]int, 10000)}
+
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+	buf, err := enc.EncodeEntry(zapcore.Entry{}, []zapcore.Field{Summary("payload", payload, SummaryLimits{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+
+	if buf.Len() > 256 {
+		t.Errorf("expected a bounded summary, got %d bytes", buf.Len())
+	}
+	for _, want := range []string{`"type":"*ctxzap.summaryPayload"`, `"fields":3`, `"preview":"{ID:p1 Body:xxx`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in %s", want, buf.String())
+		}
+	}
+}
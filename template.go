@@ -0,0 +1,156 @@
+package ctxzap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// EventIDKey is the key of the field identifying an event, used to select
+// message templates.
+const EventIDKey = "event_id"
+
+// MessageTemplates renders known messages as human sentences in the console
+// encoder, while structured outputs keep the original message and fields.
+// Templates are keyed by the entry's event_id field or, failing that, its
+// message, and reference fields by key in braces:
+//
+//	templates.Register("profile.updated", "{user_id} updated profile in {elapsed_ms}ms")
+//
+// Placeholders without a matching field are left as is.
+//
+// Templates can also be registered per locale, so that operator-facing
+// views render messages in the operator's language while the structured
+// data stays canonical. A MessageTemplates is safe for concurrent use.
+type MessageTemplates struct {
+	mu sync.RWMutex
+	// locales maps locales to templates by key; "" holds the defaults.
+	locales map[string]map[string]messageTemplate
+}
+
+// messageTemplate alternates literal text and field keys: even elements are
+// literals, odd elements are keys.
+type messageTemplate []string
+
+// NewMessageTemplates creates an empty set of templates.
+func NewMessageTemplates() *MessageTemplates {
+	return &MessageTemplates{locales: make(map[string]map[string]messageTemplate)}
+}
+
+// Register sets the default template for an event ID or message, replacing
+// any previous one. It returns an error if a placeholder is not closed.
+func (t *MessageTemplates) Register(key, template string) error {
+	return t.RegisterLocale("", key, template)
+}
+
+// RegisterLocale sets the template for an event ID or message in locale, a
+// BCP 47 tag such as "de" or "pt-BR".
+func (t *MessageTemplates) RegisterLocale(locale, key, template string) error {
+	return t.RegisterCatalog(locale, map[string]string{key: template})
+}
+
+// RegisterCatalog sets the templates of locale from a catalog mapping event
+// IDs or messages to templates, e.g. loaded from a translation file. No
+// template is registered if any of them is invalid.
+func (t *MessageTemplates) RegisterCatalog(locale string, catalog map[string]string) error {
+	parsed := make(map[string]messageTemplate, len(catalog))
+	for key, template := range catalog {
+		tmpl, err := parseTemplate(template)
+		if err != nil {
+			return err
+		}
+		parsed[key] = tmpl
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	templates := t.locales[locale]
+	if templates == nil {
+		templates = make(map[string]messageTemplate, len(parsed))
+		t.locales[locale] = templates
+	}
+	for key, tmpl := range parsed {
+		templates[key] = tmpl
+	}
+	return nil
+}
+
+func parseTemplate(template string) (messageTemplate, error) {
+	var parsed messageTemplate
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			return append(parsed, rest), nil
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("ctxzap: unclosed placeholder in template %q", template)
+		}
+		parsed = append(parsed, rest[:start], rest[start+1:start+end])
+		rest = rest[start+end+1:]
+	}
+}
+
+// Render returns the sentence for an entry with message msg and fields in
+// locale, and whether a template matched. Templates are looked up in locale,
+// then in its parent locales ("pt-BR", then "pt"), then in the defaults.
+func (t *MessageTemplates) Render(locale, msg string, fields []zap.Field) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tmpl, ok := t.lookup(locale, msg, fields)
+	if !ok {
+		return "", false
+	}
+
+	values := zapcore.NewMapObjectEncoder()
+	for i := range fields {
+		fields[i].AddTo(values)
+	}
+
+	var sb strings.Builder
+	for i, part := range tmpl {
+		if i%2 == 0 {
+			sb.WriteString(part)
+			continue
+		}
+		if v, ok := values.Fields[part]; ok {
+			fmt.Fprint(&sb, v)
+		} else {
+			sb.WriteString("{" + part + "}")
+		}
+	}
+	return sb.String(), true
+}
+
+func (t *MessageTemplates) lookup(locale, msg string, fields []zap.Field) (messageTemplate, bool) {
+	eventID := ""
+	for i := range fields {
+		if fields[i].Key == EventIDKey && fields[i].Type == zapcore.StringType {
+			eventID = fields[i].String
+		}
+	}
+
+	for {
+		if templates := t.locales[locale]; templates != nil {
+			if tmpl, ok := templates[eventID]; ok && eventID != "" {
+				return tmpl, true
+			}
+			if tmpl, ok := templates[msg]; ok {
+				return tmpl, true
+			}
+		}
+		if locale == "" {
+			return nil, false
+		}
+		if i := strings.LastIndexByte(locale, '-'); i >= 0 {
+			locale = locale[:i]
+		} else {
+			locale = ""
+		}
+	}
+}
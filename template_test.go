@@ -0,0 +1,110 @@
+package ctxzap
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMessageTemplates(t *testing.T) {
+	templates := NewMessageTemplates()
+	if err := templates.Register("profile.updated", "{user_id} updated profile in {elapsed_ms}ms"); err != nil {
+		t.Fatal(err)
+	}
+	if err := templates.Register("cache miss", "cache miss for {key} ({unknown})"); err != nil {
+		t.Fatal(err)
+	}
+	if err := templates.Register("broken", "oops {user_id"); err == nil {
+		t.Error("expected an error for an unclosed placeholder")
+	}
+
+	cfg := zap.NewDevelopmentEncoderConfig()
+	cfg.TimeKey = ""
+	cfg.CallerKey = ""
+	enc := NewConsoleEncoder(cfg, ConsoleOptions{Templates: templates})
+
+	tests := []struct {
+		name   string
+		msg    string
+		fields []zap.Field
+		want   string
+	}{
+		{
+			name:   "by event ID",
+			msg:    "profile updated",
+			fields: []zap.Field{zap.String(EventIDKey, "profile.updated"), zap.String("user_id", "u1"), zap.Int64("elapsed_ms", 42)},
+			want:   "u1 updated profile in 42ms",
+		},
+		{
+			name:   "by message with missing field",
+			msg:    "cache miss",
+			fields: []zap.Field{zap.String("key", "user:1")},
+			want:   "cache miss for user:1 ({unknown})",
+		},
+		{
+			name: "no template",
+			msg:  "plain",
+			want: "plain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Message: tt.msg}, tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			columns := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\t")
+			if columns[1] != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, columns[1])
+			}
+		})
+	}
+}
+
+func TestMessageTemplateLocales(t *testing.T) {
+	templates := NewMessageTemplates()
+	_ = templates.Register("order.shipped", "order {order_id} shipped")
+	_ = templates.RegisterLocale("de", "order.shipped", "Bestellung {order_id} versandt")
+	if err := templates.RegisterCatalog("pt", map[string]string{
+		"order.shipped": "pedido {order_id} enviado",
+		"cache miss":    "falha de cache para {key}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := templates.RegisterCatalog("fr", map[string]string{"x": "{broken"}); err == nil {
+		t.Error("expected an error for an invalid catalog")
+	}
+
+	fields := []zap.Field{zap.String(EventIDKey, "order.shipped"), zap.Int("order_id", 7)}
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "", want: "order 7 shipped"},
+		{locale: "de", want: "Bestellung 7 versandt"},
+		{locale: "pt-BR", want: "pedido 7 enviado"},
+		{locale: "fr", want: "order 7 shipped"},
+	}
+
+	for _, tt := range tests {
+		got, ok := templates.Render(tt.locale, "shipped", fields)
+		if !ok || got != tt.want {
+			t.Errorf("locale %q: expected %q, got %q (%v)", tt.locale, tt.want, got, ok)
+		}
+	}
+
+	cfg := zap.NewDevelopmentEncoderConfig()
+	cfg.TimeKey = ""
+	cfg.CallerKey = ""
+	buf, err := NewConsoleEncoder(cfg, ConsoleOptions{Templates: templates, Locale: "pt-BR"}).
+		EncodeEntry(zapcore.Entry{Message: "cache miss"}, []zap.Field{zap.String("key", "u:1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\tfalha de cache para u:1\t") {
+		t.Errorf("expected the localized message, got %q", buf.String())
+	}
+}
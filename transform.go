@@ -0,0 +1,77 @@
+package ctxzap
+
+import (
+	"slices"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Transformer rewrites the fields of an entry before they are encoded. It
+// receives a slice it owns and may modify it in place or return a new one.
+type Transformer func(fields []zap.Field) []zap.Field
+
+// WithTransformers makes the logger apply transformers, in order, to every
+// field it writes: context fields, call-site fields and fields added with
+// With alike.
+func WithTransformers(transformers ...Transformer) Option {
+	return func(l *Logger) {
+		if len(transformers) == 0 {
+			return
+		}
+		l.Logger = l.Logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewTransformCore(core, transformers...)
+		}))
+	}
+}
+
+// NewTransformCore wraps core so that transformers are applied to all fields
+// passed to With and Write.
+func NewTransformCore(core zapcore.Core, transformers ...Transformer) zapcore.Core {
+	return decorate(core, transformDecorator{transformers: transformers})
+}
+
+type transformDecorator struct {
+	transformers []Transformer
+	// namespaces are the namespaces added with With that the transformers
+	// consumed, e.g. by flattening them into the keys, so that they still
+	// apply to the fields written later.
+	namespaces []zap.Field
+}
+
+func (d transformDecorator) apply(fields []zap.Field) []zap.Field {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	owned := make([]zap.Field, 0, len(d.namespaces)+len(fields))
+	owned = append(owned, d.namespaces...)
+	owned = append(owned, fields...)
+	for _, t := range d.transformers {
+		owned = t(owned)
+	}
+	return owned
+}
+
+func (d transformDecorator) with(fields []zap.Field) (decoration, []zap.Field) {
+	transformed := d.apply(fields)
+	if namespaces := namespaceFields(fields); len(namespaces) > 0 && len(namespaceFields(transformed)) == 0 {
+		d.namespaces = append(slices.Clip(d.namespaces), namespaces...)
+	}
+	return d, transformed
+}
+
+func (d transformDecorator) write(core zapcore.Core, entry zapcore.Entry, fields []zap.Field) error {
+	return core.Write(entry, d.apply(fields))
+}
+
+// namespaceFields returns the zap.Namespace fields among fields.
+func namespaceFields(fields []zap.Field) []zap.Field {
+	var namespaces []zap.Field
+	for _, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			namespaces = append(namespaces, f)
+		}
+	}
+	return namespaces
+}
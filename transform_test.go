@@ -0,0 +1,118 @@
+package ctxzap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCoerceTypes(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core).With(zap.Int("tenant_id", 7)), WithTransformers(CoerceTypes(
+		CoercionRule{Key: "*_id", To: CoerceString},
+		CoercionRule{Key: "elapsed", To: CoerceSeconds},
+		CoercionRule{Key: "err", To: CoerceString},
+	)))
+
+	ctx := WithFields(context.Background(), zap.Int64("user_id", 42))
+	logger.Info(ctx, "coerced",
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.NamedError("err", errors.New("boom")),
+		zap.Int("count", 3),
+	)
+
+	// The parent's With field was added before the transformer.
+	expected := map[string]interface{}{
+		"tenant_id": int64(7),
+		"user_id":   "42",
+		"elapsed":   1.5,
+		"err":       "boom",
+		"count":     int64(3),
+	}
+
+	fields := observed.All()[0].ContextMap()
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("field %q: expected %v (%T), got %v (%T)", k, v, v, fields[k], fields[k])
+		}
+	}
+}
+
+func TestCoerceTypesInlineFields(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithTransformers(CoerceTypes(
+		CoercionRule{Key: "size", To: CoerceString},
+		CoercionRule{Key: "rate", To: CoerceString},
+	)))
+
+	logger.Info(context.Background(), "coerced",
+		Bytes("size", 1536),
+		Rate("rate", 3000, 2*time.Second),
+	)
+
+	expected := map[string]interface{}{
+		"size":       "1536",
+		"size_human": "1.5 KiB",
+		"rate":       "1500",
+		"rate_human": "1.5k/s",
+	}
+
+	fields := observed.All()[0].ContextMap()
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("field %q: expected %v (%T), got %v (%T)", k, v, v, fields[k], fields[k])
+		}
+	}
+}
+
+func TestTransformerAppliesToWith(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core), WithTransformers(CoerceTypes(CoercionRule{Key: "*_id", To: CoerceString}))).
+		With(zap.Int("tenant_id", 7))
+
+	logger.Info(context.Background(), "with")
+
+	if got := observed.All()[0].ContextMap()["tenant_id"]; got != "7" {
+		t.Errorf("expected With field to be coerced, got %v (%T)", got, got)
+	}
+}
+
+func TestKeyTransformers(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+
+	New(zap.New(core), WithTransformers(RenameKeys(DotsToUnderscores))).
+		Info(context.Background(), "renamed", zap.String("http.method", "GET"))
+
+	New(zap.New(core), WithTransformers(NestDottedKeys())).
+		Info(context.Background(), "nested",
+			zap.String("http.method", "GET"),
+			zap.String("user_id", "u1"),
+			zap.Int("http.response.status", 200),
+		)
+
+	entries := observed.All()
+	if got := entries[0].ContextMap()["http_method"]; got != "GET" {
+		t.Errorf("expected http_method=GET, got %v", got)
+	}
+
+	fields := entries[1].ContextMap()
+	if len(fields) != 2 || fields["user_id"] != "u1" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+	http, ok := fields["http"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected http object, got %T", fields["http"])
+	}
+	if http["method"] != "GET" {
+		t.Errorf("expected http.method=GET, got %v", http["method"])
+	}
+	response, ok := http["response"].(map[string]interface{})
+	if !ok || response["status"] != int64(200) {
+		t.Errorf("expected nested http.response.status=200, got %v", http["response"])
+	}
+}
@@ -0,0 +1,167 @@
+package ctxzap
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// generationContextKey is used as a key for storing the field generation
+// counter in context
+type generationContextKey struct{}
+
+var generationKey = generationContextKey{}
+
+// expiringField is a field added with WithFieldsTTL. It is stored in the
+// context as a zapcore.SkipType field under the key of the wrapped field, so
+// that overriding and removing it work as for other fields, and zap ignores
+// it should it escape unresolved.
+type expiringField struct {
+	field    zap.Field
+	deadline time.Time
+	gen      *atomic.Uint64
+	born     uint64
+}
+
+// WithFieldsTTL adds fields to the context like WithFields, for a limited
+// time: they stop being emitted once ttl has elapsed, or once the generation
+// is bumped with BumpFieldGeneration, whichever comes first. A ttl of zero or
+// less only expires them with the generation. This suits long-lived contexts,
+// such as a connection's, where per-message metadata must not leak into the
+// logs of later messages, including from contexts that outlive their message
+// (a goroutine still running, a context stored in a struct):
+//
+//	for msg := range conn.Messages() {
+//		ctxzap.BumpFieldGeneration(ctx)
+//		msgCtx := ctxzap.WithFieldsTTL(ctx, time.Minute, zap.String("message_id", msg.ID))
+//		handle(msgCtx, msg)
+//	}
+//
+// Derive each message's context from the long-lived one, as above, rather
+// than reassigning it: every context keeps its parents alive. Expired fields
+// already in ctx are dropped when fields are added, so they are not carried
+// any further.
+//
+// Expired fields are omitted by the logger and by FieldsFromContext,
+// GetField and the other accessors.
+func WithFieldsTTL(ctx context.Context, ttl time.Duration, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	gen := generation(ctx)
+	if gen == nil {
+		gen = new(atomic.Uint64)
+		ctx = context.WithValue(ctx, generationKey, gen)
+	} else if existing := storedFields(ctx); hasExpired(existing) {
+		ctx = context.WithValue(ctx, fieldsKey, resolvedFieldNode(withoutExpired(existing)))
+	}
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = time.Now().Add(ttl)
+	}
+
+	expiring := make([]zap.Field, len(fields))
+	for i, f := range fields {
+		expiring[i] = zap.Field{
+			Key:       f.Key,
+			Type:      zapcore.SkipType,
+			Interface: &expiringField{field: f, deadline: deadline, gen: gen, born: gen.Load()},
+		}
+	}
+	return WithFields(ctx, expiring...)
+}
+
+// BumpFieldGeneration expires every field added with WithFieldsTTL to ctx
+// and to the contexts derived from the context where the first such field
+// was added. It does nothing if ctx has no such fields.
+func BumpFieldGeneration(ctx context.Context) {
+	if gen := generation(ctx); gen != nil {
+		gen.Add(1)
+	}
+}
+
+// generation returns the generation counter of the fields added to ctx with
+// WithFieldsTTL, or nil if there is none. It is looked up in the fields of
+// ctx first, which are stored near the top of the context chain.
+func generation(ctx context.Context) *atomic.Uint64 {
+	for _, f := range storedFields(ctx) {
+		if e, ok := f.Interface.(*expiringField); ok && f.Type == zapcore.SkipType {
+			return e.gen
+		}
+	}
+	gen, _ := ctx.Value(generationKey).(*atomic.Uint64)
+	return gen
+}
+
+// hasExpired reports whether fields holds expired fields added with
+// WithFieldsTTL.
+func hasExpired(fields []zap.Field) bool {
+	var now time.Time
+	for _, f := range fields {
+		if _, ok := liveField(f, &now); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutExpired returns a copy of fields without the expired fields added
+// with WithFieldsTTL. The live ones stay wrapped, so they still expire.
+func withoutExpired(fields []zap.Field) []zap.Field {
+	kept := make([]zap.Field, 0, len(fields))
+	var now time.Time
+	for _, f := range fields {
+		if _, ok := liveField(f, &now); ok {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// liveField resolves a stored field: fields added with WithFieldsTTL are
+// unwrapped, or reported as absent once expired. now is set on first use.
+func liveField(f zap.Field, now *time.Time) (zap.Field, bool) {
+	if f.Type != zapcore.SkipType {
+		return f, true
+	}
+	e, ok := f.Interface.(*expiringField)
+	if !ok {
+		return f, true
+	}
+
+	if e.gen.Load() != e.born {
+		return zap.Field{}, false
+	}
+	if !e.deadline.IsZero() {
+		if now.IsZero() {
+			*now = time.Now()
+		}
+		if !now.Before(e.deadline) {
+			return zap.Field{}, false
+		}
+	}
+	return e.field, true
+}
+
+// liveFields returns fields without the expired fields added with
+// WithFieldsTTL, and with the live ones unwrapped. fields is returned
+// unchanged if it holds no such fields.
+func liveFields(fields []zap.Field) []zap.Field {
+	for i := range fields {
+		if _, ok := fields[i].Interface.(*expiringField); ok && fields[i].Type == zapcore.SkipType {
+			live := make([]zap.Field, 0, len(fields))
+			var now time.Time
+			for _, f := range fields {
+				if f, ok := liveField(f, &now); ok {
+					live = append(live, f)
+				}
+			}
+			return live
+		}
+	}
+	return fields
+}
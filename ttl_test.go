@@ -0,0 +1,81 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithFieldsTTL(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	conn := WithFields(context.Background(), zap.String("conn_id", "c1"))
+
+	ctx := WithFieldsTTL(conn, time.Hour, zap.String("message_id", "m1"))
+	ctx = WithFieldsTTL(ctx, time.Nanosecond, zap.String("trace_id", "t1"))
+	logger.Info(ctx, "handling")
+
+	m := observed.All()[0].ContextMap()
+	if m["conn_id"] != "c1" || m["message_id"] != "m1" || len(m) != 2 {
+		t.Errorf("expected the expired field to be omitted, got %v", m)
+	}
+	if f, ok := GetField(ctx, "message_id"); !ok || f.String != "m1" {
+		t.Errorf("expected the live field unwrapped, got %v, %v", f, ok)
+	}
+	if HasField(ctx, "trace_id") {
+		t.Error("expected the expired field to be absent")
+	}
+
+	BumpFieldGeneration(ctx)
+	ctx = WithFieldsTTL(ctx, 0, zap.String("message_id", "m2"), zap.Int("attempt", 1))
+	logger.Info(ctx, "next message")
+	m = observed.All()[1].ContextMap()
+	if m["message_id"] != "m2" || m["attempt"] != int64(1) || len(m) != 3 {
+		t.Errorf("expected only fields of the current generation, got %v", m)
+	}
+
+	BumpFieldGeneration(ctx)
+	if fields := FieldsFromContext(ctx); len(fields) != 1 || fields[0].Key != "conn_id" {
+		t.Errorf("expected only the plain field after a bump, got %v", fields)
+	}
+	if m := FieldsMapFromContext(ctx); len(m) != 1 {
+		t.Errorf("expected only the plain field in the map, got %v", m)
+	}
+
+	// Plain fields replace expiring ones with the same key.
+	ctx = WithFields(ctx, zap.String("message_id", "kept"))
+	BumpFieldGeneration(ctx)
+	if f, ok := GetField(ctx, "message_id"); !ok || f.String != "kept" {
+		t.Errorf("expected the plain override to survive, got %v, %v", f, ok)
+	}
+}
+
+func TestWithFieldsTTLDropsExpiredFields(t *testing.T) {
+	conn := WithFields(context.Background(), zap.String("conn_id", "c1"))
+
+	// Even when the context is reassigned, expired fields are not carried
+	// from one message to the next.
+	ctx := conn
+	for i := 0; i < 10000; i++ {
+		BumpFieldGeneration(ctx)
+		ctx = WithFieldsTTL(ctx, time.Minute, zap.Int("message", i), zap.String("user", "alice"))
+	}
+
+	if stored := storedFields(ctx); len(stored) != 3 {
+		t.Errorf("expected the connection field and the last message's fields, got %d fields", len(stored))
+	}
+	depth := 0
+	for n := fieldNodeFrom(ctx); n != nil; n = n.parent {
+		depth++
+	}
+	if depth > 2 {
+		t.Errorf("expected expired nodes to be pruned, got a chain of %d nodes", depth)
+	}
+	if m := FieldsMapFromContext(ctx); m["message"] != int64(9999) || m["conn_id"] != "c1" || len(m) != 3 {
+		t.Errorf("unexpected fields: %v", m)
+	}
+}
@@ -0,0 +1,55 @@
+package ctxzap
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Key is a typed context key whose value is also logged as a field, so that
+// a value needed both programmatically and in the logs is stored once:
+//
+//	ctx = ctxzap.RequestID.Set(ctx, "abc")
+//	id, ok := ctxzap.RequestID.Get(ctx)
+//
+// Keys are compared by identity; create them once, with NewKey.
+type Key[T any] struct {
+	name  string
+	field func(key string, value T) zap.Field
+}
+
+// NewKey creates a key logged as a field named name, built with field, e.g.
+// zap.String or zap.Int.
+func NewKey[T any](name string, field func(key string, value T) zap.Field) *Key[T] {
+	return &Key[T]{name: name, field: field}
+}
+
+// Well-known keys.
+var (
+	RequestID = NewKey("request_id", zap.String)
+	TraceID   = NewKey("trace_id", zap.String)
+	TenantID  = NewKey("tenant_id", zap.String)
+	UserID    = NewKey("user_id", zap.String)
+)
+
+// Name returns the name of the field.
+func (k *Key[T]) Name() string {
+	return k.name
+}
+
+// Set returns a context carrying value, both for Get and as a field added
+// with WithFields.
+func (k *Key[T]) Set(ctx context.Context, value T) context.Context {
+	ctx = context.WithValue(ctx, k, value)
+	return WithFields(ctx, k.field(k.name, value))
+}
+
+// Get returns the value set in ctx with Set, if any.
+func (k *Key[T]) Get(ctx context.Context) (T, bool) {
+	var zero T
+	if ctx == nil {
+		return zero, false
+	}
+	value, ok := ctx.Value(k).(T)
+	return value, ok
+}
@@ -0,0 +1,38 @@
+package ctxzap
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTypedKeys(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	attempt := NewKey("attempt", zap.Int)
+
+	ctx := RequestID.Set(context.Background(), "abc")
+	ctx = attempt.Set(ctx, 2)
+
+	if id, ok := RequestID.Get(ctx); !ok || id != "abc" {
+		t.Errorf("expected request ID abc, got %q %v", id, ok)
+	}
+	if n, ok := attempt.Get(ctx); !ok || n != 2 {
+		t.Errorf("expected attempt 2, got %d %v", n, ok)
+	}
+	if _, ok := UserID.Get(ctx); ok {
+		t.Error("expected no user ID")
+	}
+	if _, ok := TraceID.Get(nil); ok { //nolint:staticcheck // nil context is handled
+		t.Error("expected no trace ID in a nil context")
+	}
+
+	logger.Info(ctx, "msg")
+	fields := observed.All()[0].ContextMap()
+	if fields["request_id"] != "abc" || fields["attempt"] != int64(2) {
+		t.Errorf("expected the values as fields, got %v", fields)
+	}
+}